@@ -0,0 +1,213 @@
+package session
+
+import (
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type AdminRole int
+
+const (
+	AdminReadOnly AdminRole = iota + 1
+	AdminReadWrite
+)
+
+// Authentication of the admin handler. A request is granted the highest role
+// among its bearer token and verified client certificate; requests with
+// neither are rejected.
+type AdminConfig struct {
+	// Bearer tokens accepted in the Authorization header and their role.
+	// Empty tokens are ignored.
+	Tokens map[string]AdminRole
+
+	// Role of a verified TLS client certificate, false to reject it. The
+	// server must verify client certificates for this to be consulted.
+	ClientCertRole func(cert *x509.Certificate) (AdminRole, bool)
+}
+
+func (ac AdminConfig) role(r *http.Request) AdminRole {
+	var role AdminRole
+
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") && len(auth) > len("Bearer ") {
+		token := []byte(strings.TrimPrefix(auth, "Bearer "))
+		for t, tr := range ac.Tokens {
+			// An empty token, e.g. from an unset environment variable,
+			// must not match an empty bearer token
+			if t != "" && subtle.ConstantTimeCompare(token, []byte(t)) == 1 && tr > role {
+				role = tr
+			}
+		}
+	}
+
+	if ac.ClientCertRole != nil && r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		if cr, ok := ac.ClientCertRole(r.TLS.VerifiedChains[0][0]); ok && cr > role {
+			role = cr
+		}
+	}
+
+	return role
+}
+
+// Session ids are bearer credentials, so the admin API only exposes the
+// AffinityHash of a session as its handle
+func (sm *SessionManager) adminSessions() []SessionInfo {
+	list := sm.Sessions()
+	for i := range list {
+		list[i].ID = ""
+	}
+	return list
+}
+
+func (sm *SessionManager) adminSession(s *Session) SessionInfo {
+	info := sm.sessionInfo(s)
+	info.ID = ""
+	return info
+}
+
+// Session whose AffinityHash is handle, nil if there is none. Caller must
+// hold sm.lock.
+func (sm *SessionManager) resolveHandle(handle string) *Session {
+	var found *Session
+	sm.store.Iterate(func(sid string, s *Session) bool {
+		if s != nil && AffinityHash(sid) == handle {
+			found = s
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(v)
+}
+
+// AdminHandler serves an API to inspect and destroy sessions. Mount it with
+// http.StripPrefix on an internal listener.
+//
+//	GET    /sessions          list sessions            (AdminReadOnly)
+//	DELETE /sessions?user_id= destroy a user's sessions (AdminReadWrite)
+//	                          with dry_run=true only list their handles
+//	                          (AdminReadOnly)
+//	GET    /sessions/idle     longest idle sessions    (AdminReadOnly)
+//	                          first, limit= of them (default 10)
+//	GET    /sessions/{handle} inspect a single session (AdminReadOnly)
+//	DELETE /sessions/{handle} destroy a session        (AdminReadWrite)
+//	GET    /dashboard         HTML debug dashboard     (AdminReadOnly)
+//	GET    /debug/contention  lock contention stats    (AdminReadOnly)
+//	GET    /metrics           OpenMetrics snapshot     (AdminReadOnly)
+func (sm *SessionManager) AdminHandler(config AdminConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role := config.role(r)
+		if role == 0 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="session-admin"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		path := strings.Trim(r.URL.Path, "/")
+
+		// A bulk dry run changes nothing, so reading is enough
+		need := AdminReadOnly
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			if dryRun, _ := adminDryRun(r); !dryRun || path != "sessions" || r.Method != http.MethodDelete {
+				need = AdminReadWrite
+			}
+		}
+		if role < need {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		switch {
+		case path == "sessions" && r.Method == http.MethodGet:
+			writeJSON(w, sm.adminSessions())
+
+		case path == "sessions" && r.Method == http.MethodDelete:
+			sm.serveAdminBulkDestroy(w, r)
+
+		case path == "sessions/idle" && r.Method == http.MethodGet:
+			sm.serveAdminIdle(w, r)
+
+		case strings.HasPrefix(path, "sessions/"):
+			sm.serveAdminSession(w, r, strings.TrimPrefix(path, "sessions/"))
+
+		case path == "dashboard" && r.Method == http.MethodGet:
+			sm.serveDashboard(w, r)
+
+		case path == "debug/contention" && r.Method == http.MethodGet:
+			writeJSON(w, sm.ContentionStats())
+
+		case path == "metrics" && r.Method == http.MethodGet:
+			sm.serveMetrics(w)
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// Handles are hex, so "idle" never shadows a session
+func (sm *SessionManager) serveAdminIdle(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	idle := sm.IdleSessions(limit)
+
+	sm.rlock()
+	list := make([]SessionInfo, len(idle))
+	for i, s := range idle {
+		list[i] = sm.adminSession(s)
+	}
+	sm.lock.RUnlock()
+
+	writeJSON(w, list)
+}
+
+func (sm *SessionManager) serveAdminSession(w http.ResponseWriter, r *http.Request, handle string) {
+	sm.rlock()
+	s := sm.resolveHandle(handle)
+	var info SessionInfo
+	var sid string
+	if s != nil {
+		info = sm.adminSession(s)
+		sid = s.ID()
+	}
+	sm.lock.RUnlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		if s == nil {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, info)
+
+	case http.MethodDelete:
+		if s == nil {
+			http.NotFound(w, r)
+			return
+		}
+		if err := sm.SessionDestroy(sid); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,167 @@
+package session
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_AdminHandler(t *testing.T) {
+	sm := New()
+	sm.SessionCreate("sessionid123")
+	sm.SessionCreate("sessionid456")
+
+	handler := sm.AdminHandler(AdminConfig{
+		Tokens: map[string]AdminRole{
+			"ro-token": AdminReadOnly,
+			"rw-token": AdminReadWrite,
+		},
+		ClientCertRole: func(cert *x509.Certificate) (AdminRole, bool) {
+			return AdminReadWrite, cert.Subject.CommonName == "ops"
+		},
+	})
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Case 1: No Credentials
+	if rec := do("GET", "/sessions", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %v", rec.Code)
+	}
+
+	// Case 2: Unknown Token
+	if rec := do("GET", "/sessions", "bogus"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %v", rec.Code)
+	}
+
+	// Case 3: Empty Token Never Matches
+	empty := sm.AdminHandler(AdminConfig{Tokens: map[string]AdminRole{"": AdminReadWrite}})
+	req := httptest.NewRequest("DELETE", "/sessions/sessionid123", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	empty.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized || !sm.SessionExist("sessionid123") {
+		t.Errorf("Expected 401, got %v", rec.Code)
+	}
+
+	// Case 4: Read-Only Token Lists Sessions
+	rec = do("GET", "/sessions", "ro-token")
+	var list []SessionInfo
+	if rec.Code != http.StatusOK || json.NewDecoder(rec.Body).Decode(&list) != nil || len(list) != 2 {
+		t.Errorf("Expected 2 sessions, got %v, code: %v", list, rec.Code)
+	}
+
+	// Case 5: Inspect Single Session
+	rec = do("GET", "/sessions/"+AffinityHash("sessionid123"), "ro-token")
+	var info SessionInfo
+	if rec.Code != http.StatusOK || json.NewDecoder(rec.Body).Decode(&info) != nil || info.Handle != AffinityHash("sessionid123") {
+		t.Errorf("Expected sessionid123, got %v, code: %v", info, rec.Code)
+	}
+	for _, rec := range []*httptest.ResponseRecorder{do("GET", "/sessions", "ro-token"), do("GET", "/sessions/"+AffinityHash("sessionid123"), "ro-token")} {
+		if strings.Contains(rec.Body.String(), "sessionid123") {
+			t.Errorf("Expected session ids not to be exposed, got %v", rec.Body.String())
+		}
+	}
+	if rec = do("GET", "/sessions/sessionid123", "ro-token"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a raw session id, got %v", rec.Code)
+	}
+	if rec = do("GET", "/sessions/nonexistent", "ro-token"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %v", rec.Code)
+	}
+
+	// Case 6: Read-Only Token Cannot Destroy
+	if rec = do("DELETE", "/sessions/"+AffinityHash("sessionid123"), "ro-token"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403, got %v", rec.Code)
+	}
+	if !sm.SessionExist("sessionid123") {
+		t.Errorf("Expected sessionid123 to still exist")
+	}
+
+	// Case 7: Read-Write Token Destroys
+	if rec = do("DELETE", "/sessions/"+AffinityHash("sessionid123"), "rw-token"); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %v", rec.Code)
+	}
+	if sm.SessionExist("sessionid123") {
+		t.Errorf("Expected sessionid123 to be destroyed")
+	}
+
+	// Case 8: Client Certificate
+	req = httptest.NewRequest("DELETE", "/sessions/"+AffinityHash("sessionid456"), nil)
+	req.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "ops"}}}},
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %v", rec.Code)
+	}
+
+	// Case 9: Rejected Client Certificate
+	req = httptest.NewRequest("GET", "/sessions", nil)
+	req.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "intruder"}}}},
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %v", rec.Code)
+	}
+
+	// Case 10: Unknown Route
+	if rec = do("GET", "/unknown", "ro-token"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %v", rec.Code)
+	}
+}
+
+func TestSessionManager_AdminIdle(t *testing.T) {
+	sm := New()
+	sm.Config.MaxLifetime = time.Hour
+	for i, age := range []time.Duration{time.Minute, 40 * time.Minute, 10 * time.Minute} {
+		s, _ := sm.SessionCreate(fmt.Sprintf("sessionid%d", i))
+		s.age(age)
+	}
+
+	handler := sm.AdminHandler(AdminConfig{Tokens: map[string]AdminRole{"ro-token": AdminReadOnly}})
+	do := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer ro-token")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Case 1: Longest Idle First
+	var list []SessionInfo
+	rec := do("/sessions/idle?limit=2")
+	if rec.Code != http.StatusOK || json.NewDecoder(rec.Body).Decode(&list) != nil || len(list) != 2 {
+		t.Fatalf("Expected 2 sessions, got %v: %v", rec.Code, rec.Body)
+	}
+	if list[0].Handle != AffinityHash("sessionid1") || list[1].Handle != AffinityHash("sessionid2") {
+		t.Errorf("Expected sessionid1 then sessionid2, got %v", list)
+	}
+
+	// Case 2: Default Limit
+	list = nil
+	if rec := do("/sessions/idle"); json.NewDecoder(rec.Body).Decode(&list) != nil || len(list) != 3 {
+		t.Errorf("Expected all 3 sessions, got %v", list)
+	}
+
+	// Case 3: Invalid Limit
+	if rec := do("/sessions/idle?limit=-1"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %v", rec.Code)
+	}
+}
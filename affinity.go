@@ -0,0 +1,62 @@
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"time"
+)
+
+// Cookie carrying the affinity hash when Config.AffinityCookie is not set
+const DefaultAffinityCookie = "session_affinity"
+
+// Stable hash of sid for sticky load balancing. It does not reveal the
+// session id, so it is safe to expose to the load balancer and its logs.
+func AffinityHash(sid string) string {
+	sum := sha256.Sum256([]byte(sid))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Hash of a session id for affinity and lock striping, see Config.Hash
+type HashFunc func(sid string) uint64
+
+// CRC-32 (IEEE) of sid, the hash nginx's hash directive and many other load
+// balancers key upstreams by
+func CRC32Hash(sid string) uint64 {
+	return uint64(crc32.ChecksumIEEE([]byte(sid)))
+}
+
+// Hash of sid with Config.Hash, XXHash if nil
+func (sm *SessionManager) hash(sid string) uint64 {
+	if sm.Config.Hash != nil {
+		return sm.Config.Hash(sid)
+	}
+	return XXHash(sid)
+}
+
+// Write the Config.Hash of s in hex as a cookie expiring with the session
+// cookie, and as the Config.AffinityHeader response header if set
+func (sm *SessionManager) SetAffinity(w http.ResponseWriter, s *Session) {
+	hash := fmt.Sprintf("%016x", sm.hash(s.ID()))
+
+	if sm.Config.AffinityHeader != "" {
+		w.Header().Set(sm.Config.AffinityHeader, hash)
+	}
+
+	name := sm.Config.AffinityCookie
+	if name == "" {
+		name = DefaultAffinityCookie
+	}
+	cookie := sm.newCookie(name, hash)
+	if expires := sm.cookieExpires(s); !expires.IsZero() {
+		cookie.Expires = expires
+		cookie.MaxAge = int(time.Until(expires).Seconds())
+		if cookie.MaxAge <= 0 {
+			cookie.MaxAge = -1
+		}
+	}
+
+	http.SetCookie(w, cookie)
+}
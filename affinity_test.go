@@ -0,0 +1,84 @@
+package session
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAffinityHash(t *testing.T) {
+	// Case 1: Stable
+	if AffinityHash("sessionid123") != AffinityHash("sessionid123") {
+		t.Errorf("Expected the same hash for the same session id")
+	}
+
+	// Case 2: Distinct and Opaque
+	h := AffinityHash("sessionid123")
+	if h == AffinityHash("sessionid456") || len(h) != 16 || h == "sessionid123" {
+		t.Errorf("Expected distinct 16 character hashes, got %v", h)
+	}
+
+	// Case 3: CRC-32 Check Value
+	if got := CRC32Hash("123456789"); got != 0xcbf43926 {
+		t.Errorf("Expected cbf43926, got %x", got)
+	}
+}
+
+func TestXXHash(t *testing.T) {
+	tests := map[string]uint64{
+		"":    0xef46db3751d8e999,
+		"a":   0xd24ec4f1a98c6e5b,
+		"abc": 0x44bc2cf5ad770999,
+		"Nobody inspects the spammish repetition": 0xfbcea83c8a378bf1,
+	}
+	for in, want := range tests {
+		if got := XXHash(in); got != want {
+			t.Errorf("XXHash(%q): expected %x, got %x", in, want, got)
+		}
+	}
+}
+
+func TestSessionManager_SetAffinity(t *testing.T) {
+	sm := New()
+	s, _ := sm.SessionCreate("sessionid123")
+
+	// Case 1: Cookie Only
+	rec := httptest.NewRecorder()
+	sm.SetAffinity(rec, s)
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != DefaultAffinityCookie || cookies[0].Value != fmt.Sprintf("%016x", XXHash("sessionid123")) {
+		t.Errorf("Expected affinity cookie, got %v", cookies)
+	}
+	if cookies[0].MaxAge <= 0 {
+		t.Errorf("Expected the affinity cookie to expire with the session cookie, got %v", cookies[0].MaxAge)
+	}
+
+	// Case 2: Custom Cookie and Header
+	sm.Config.AffinityCookie = "lb"
+	sm.Config.AffinityHeader = "X-Affinity"
+	rec = httptest.NewRecorder()
+	sm.SetAffinity(rec, s)
+	if got := rec.Header().Get("X-Affinity"); got != fmt.Sprintf("%016x", XXHash("sessionid123")) {
+		t.Errorf("Expected affinity header, got %v", got)
+	}
+	if cookies := rec.Result().Cookies(); len(cookies) != 1 || cookies[0].Name != "lb" {
+		t.Errorf("Expected lb cookie, got %v", cookies)
+	}
+
+	// Case 3: Configured Hash Used for the Cookie, Not Handles
+	sm.Config.Hash = CRC32Hash
+	rec = httptest.NewRecorder()
+	sm.SetAffinity(rec, s)
+	if got := rec.Header().Get("X-Affinity"); got != fmt.Sprintf("%016x", CRC32Hash("sessionid123")) {
+		t.Errorf("Expected CRC-32 affinity header, got %v", got)
+	}
+	if info, _ := sm.SessionInfo("sessionid123"); info.Handle != AffinityHash("sessionid123") {
+		t.Errorf("Expected the handle to stay AffinityHash, got %v", info.Handle)
+	}
+	sm.lock.RLock()
+	found := sm.resolveHandle(AffinityHash("sessionid123"))
+	sm.lock.RUnlock()
+	if found != s {
+		t.Errorf("Expected the session resolved by its handle")
+	}
+}
@@ -0,0 +1,225 @@
+package session
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Peer able to list the sessions it holds, used by RepairReplicas to
+// reconcile copies that diverged, e.g. during a network partition
+type DigestPeer interface {
+	Peer
+
+	// Last write time of every session held by the peer
+	Digest() (map[string]time.Time, error)
+}
+
+// Outcome of the anti-entropy repairs so far
+type AntiEntropyStats struct {
+	Runs    uint64    // completed repair runs
+	Pulled  uint64    // newer copies fetched from peers
+	Pushed  uint64    // newer copies sent to peers
+	Deleted uint64    // destroyed sessions removed from peers
+	Errors  uint64    // failed peer calls
+	LastRun time.Time // zero if no run completed yet
+}
+
+type antiEntropy struct {
+	runs    atomic.Uint64
+	pulled  atomic.Uint64
+	pushed  atomic.Uint64
+	deleted atomic.Uint64
+	errors  atomic.Uint64
+	lastRun atomic.Int64
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// Remember that sid was destroyed so older copies held by peers are not
+// brought back
+func (sm *SessionManager) tombstone(sid string) {
+	if len(sm.Config.Peers) == 0 {
+		return
+	}
+
+	sm.markLock.Lock()
+	defer sm.markLock.Unlock()
+
+	if sm.tombstones == nil {
+		sm.tombstones = make(map[string]time.Time)
+	}
+	sm.tombstones[sid] = time.Now()
+}
+
+// Whether a copy of sid last written at updated was destroyed since
+func (sm *SessionManager) buried(sid string, updated time.Time) bool {
+	sm.markLock.RLock()
+	defer sm.markLock.RUnlock()
+
+	t, ok := sm.tombstones[sid]
+	return ok && !updated.After(t)
+}
+
+// Tombstones outlive the copies they guard against by MaxLifetime
+func (sm *SessionManager) cleanTombstones() {
+	if len(sm.Config.Peers) == 0 {
+		return
+	}
+
+	sm.markLock.Lock()
+	defer sm.markLock.Unlock()
+
+	now := time.Now()
+	for sid, t := range sm.tombstones {
+		if now.After(t.Add(sm.lifetime())) {
+			delete(sm.tombstones, sid)
+		}
+	}
+}
+
+// Last write time of every live local session
+func (sm *SessionManager) digest() map[string]time.Time {
+	sm.rlock()
+	defer sm.lock.RUnlock()
+
+	digest := make(map[string]time.Time, sm.store.Count())
+	sm.each(func(sid string, s *Session) {
+		s.lock.RLock()
+		digest[sid] = s.updatedAt
+		s.lock.RUnlock()
+	})
+
+	return digest
+}
+
+// Digest of the local sessions, to answer DigestPeer.Digest
+func (sm *SessionManager) Digest() map[string]time.Time {
+	return sm.digest()
+}
+
+// RepairReplicas compares the sessions held by every DigestPeer in
+// Config.Peers with the local ones by last write time. The newer copy is
+// pulled or pushed, and sessions destroyed here are removed from the peer.
+// Returns the last peer error, the other peers are still repaired.
+func (sm *SessionManager) RepairReplicas() error {
+	var lastErr error
+	fail := func(err error) {
+		sm.antiEntropy.errors.Add(1)
+		lastErr = err
+	}
+
+	for _, p := range sm.Config.Peers {
+		dp, ok := p.(DigestPeer)
+		if !ok {
+			continue
+		}
+
+		remote, err := dp.Digest()
+		if err != nil {
+			fail(err)
+			continue
+		}
+		local := sm.digest()
+
+		for sid, rt := range remote {
+			buried := sm.buried(sid, rt)
+			if lt, ok := local[sid]; buried || (ok && !rt.After(lt)) {
+				if buried {
+					if err := dp.DeleteSession(sid); err != nil {
+						fail(err)
+						continue
+					}
+					sm.antiEntropy.deleted.Add(1)
+				}
+				continue
+			}
+
+			snap, found, err := dp.FetchSession(sid)
+			if err != nil {
+				fail(err)
+				continue
+			}
+			if found {
+				sm.ApplySnapshot(snap)
+				sm.antiEntropy.pulled.Add(1)
+			}
+		}
+
+		for sid, lt := range local {
+			if rt, ok := remote[sid]; ok && !lt.After(rt) {
+				continue
+			}
+
+			snap, ok := sm.Snapshot(sid)
+			if !ok {
+				continue
+			}
+			if err := dp.StoreSession(snap); err != nil {
+				fail(err)
+				continue
+			}
+			sm.antiEntropy.pushed.Add(1)
+		}
+	}
+
+	sm.antiEntropy.runs.Add(1)
+	sm.antiEntropy.lastRun.Store(time.Now().UnixNano())
+
+	return lastErr
+}
+
+func (sm *SessionManager) AntiEntropyStats() AntiEntropyStats {
+	stats := AntiEntropyStats{
+		Runs:    sm.antiEntropy.runs.Load(),
+		Pulled:  sm.antiEntropy.pulled.Load(),
+		Pushed:  sm.antiEntropy.pushed.Load(),
+		Deleted: sm.antiEntropy.deleted.Load(),
+		Errors:  sm.antiEntropy.errors.Load(),
+	}
+	if t := sm.antiEntropy.lastRun.Load(); t != 0 {
+		stats.LastRun = time.Unix(0, t)
+	}
+
+	return stats
+}
+
+// Run RepairReplicas every Config.AntiEntropyInterval until Close
+func (sm *SessionManager) startAntiEntropy() {
+	interval := sm.Config.AntiEntropyInterval
+	if interval <= 0 || len(sm.Config.Peers) == 0 {
+		return
+	}
+
+	sm.antiEntropy.stop = make(chan struct{})
+	sm.antiEntropy.done = make(chan struct{})
+
+	go func() {
+		defer close(sm.antiEntropy.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				sm.RepairReplicas()
+			case <-sm.antiEntropy.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (sm *SessionManager) stopAntiEntropy() {
+	if sm.antiEntropy.stop == nil {
+		return
+	}
+
+	sm.antiEntropy.once.Do(func() {
+		close(sm.antiEntropy.stop)
+		<-sm.antiEntropy.done
+	})
+}
@@ -0,0 +1,90 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+type failingDigestPeer struct {
+	failingPeer
+}
+
+func (failingDigestPeer) Digest() (map[string]time.Time, error) {
+	return nil, errPeerUnreachable
+}
+
+func TestSessionManager_RepairReplicas(t *testing.T) {
+	a, b := New(), New()
+
+	// Diverge while partitioned
+	a.Config.Peers = []Peer{failingPeer{}}
+	b.SessionCreate("fromB")
+	a.SessionCreate("fromA")
+	shared, _ := a.SessionCreate("shared")
+	snap, _ := a.Snapshot("shared")
+	b.ApplySnapshot(snap)
+	time.Sleep(time.Millisecond)
+	b.stored("shared").Set("cart", 2)
+	a.SessionCreate("doomed")
+	snap, _ = a.Snapshot("doomed")
+	b.ApplySnapshot(snap)
+	a.SessionDestroy("doomed")
+
+	// Case 1: Copies Are Reconciled After the Partition Heals
+	a.Config.Peers = []Peer{managerPeer{b}}
+	if err := a.RepairReplicas(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !a.SessionExist("fromB") || !b.SessionExist("fromA") {
+		t.Errorf("Expected missing sessions to be copied both ways")
+	}
+	if shared.Get("cart") != 2 {
+		t.Errorf("Expected the newer copy to win, got %v", shared.Get("cart"))
+	}
+	if a.SessionExist("doomed") || b.SessionExist("doomed") {
+		t.Errorf("Expected the destroyed session to stay destroyed")
+	}
+	stats := a.AntiEntropyStats()
+	if stats.Runs != 1 || stats.Pulled != 2 || stats.Pushed != 1 || stats.Deleted != 1 || stats.LastRun.IsZero() {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	// Case 2: Converged Replicas Need No Repair
+	a.RepairReplicas()
+	if again := a.AntiEntropyStats(); again.Pulled != 2 || again.Pushed != 1 || again.Deleted != 1 {
+		t.Errorf("Expected no further repairs, got %+v", again)
+	}
+
+	// Case 3: Destroyed Sessions Are Not Adopted Again
+	a.ApplySnapshot(snap)
+	if a.SessionExist("doomed") {
+		t.Errorf("Expected the stale copy to be rejected")
+	}
+
+	// Case 4: Peer Errors Are Counted
+	a.Config.Peers = []Peer{failingDigestPeer{}}
+	if err := a.RepairReplicas(); err == nil || a.AntiEntropyStats().Errors != 1 {
+		t.Errorf("Expected a counted peer error, got %v", err)
+	}
+}
+
+func TestSessionManager_AntiEntropyInterval(t *testing.T) {
+	b := New()
+	b.SessionCreate("sessionid123")
+	a := New(SessionManagerConfig{MaxLifetime: time.Hour, Peers: []Peer{managerPeer{b}}, AntiEntropyInterval: 5 * time.Millisecond})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !a.SessionExist("sessionid123") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !a.SessionExist("sessionid123") {
+		t.Errorf("Expected the background repair to pull sessionid123")
+	}
+
+	a.Close()
+	runs := a.AntiEntropyStats().Runs
+	time.Sleep(20 * time.Millisecond)
+	if a.AntiEntropyStats().Runs != runs {
+		t.Errorf("Expected repairs to stop after Close")
+	}
+}
@@ -0,0 +1,231 @@
+package session
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Backend is the byte-oriented storage under a BackendStore, e.g. a Redis
+// or SQL client. Sessions are handed over encoded by the store's Codec.
+type Backend interface {
+	// Data of session sid, nil if there is none
+	Load(sid string) ([]byte, error)
+
+	Save(r Record) error
+
+	Remove(sid string) error
+
+	// Call fn for every stored session until it returns false
+	Scan(fn func(sid string, data []byte) bool) error
+
+	Len() (int, error)
+}
+
+// Session as handed to a Backend
+type Record struct {
+	ID           string
+	Data         []byte // encoded by the store's Codec
+	LastAccessed time.Time
+	ExpiresAt    time.Time // to be dropped from then on
+}
+
+// Implemented by stores and backends that drop expired sessions on their
+// own, e.g. through a TTL. The cleaner does not scan such a store for
+// expired sessions, so no EventExpired is emitted for them.
+type Expiring interface {
+	SelfExpiring() bool
+}
+
+// Implemented by stores and backends with housekeeping to do, e.g. deleting
+// expired rows with a single query. Sweep is called on every cleaner run.
+type Sweeper interface {
+	Sweep() error
+}
+
+// BackendStore is a Store keeping sessions in a Backend, so they survive
+// restarts and are shared by every instance using the same backend. Each
+// Get decodes a fresh copy, so caches such as evaluated flags are not kept
+// across requests.
+type BackendStore struct {
+	backend  Backend
+	codec    Codec
+	sm       *SessionManager
+	watching atomic.Bool
+	removing sync.Map // sid to time of removal, while watching
+}
+
+// Store over backend encoding sessions with codec, GobCodec if nil
+func NewBackendStore(backend Backend, codec Codec) *BackendStore {
+	if codec == nil {
+		codec = GobCodec{}
+	}
+	return &BackendStore{backend: backend, codec: codec}
+}
+
+// Stores needing the manager they serve, called by New
+type attachable interface {
+	attach(sm *SessionManager)
+}
+
+func (bs *BackendStore) attach(sm *SessionManager) {
+	bs.sm = sm
+}
+
+func (bs *BackendStore) SelfExpiring() bool {
+	e, ok := bs.backend.(Expiring)
+	return ok && e.SelfExpiring()
+}
+
+func (bs *BackendStore) Sweep() error {
+	bs.pruneRemoving()
+	if sw, ok := bs.backend.(Sweeper); ok {
+		return sw.Sweep()
+	}
+	return nil
+}
+
+func (bs *BackendStore) Get(sid string) (*Session, error) {
+	key := bs.key(sid)
+	data, err := bs.backend.Load(key)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	snap, err := bs.codec.Decode(data)
+	if err != nil {
+		return nil, bs.sm.corrupt(bs.backend, key, data, err)
+	}
+	snap.ID = sid
+
+	return bs.sm.restore(snap), nil
+}
+
+func (bs *BackendStore) Set(sid string, s *Session) error {
+	r, expired, err := bs.record(sid, s)
+	if err != nil {
+		return err
+	}
+	if expired {
+		return bs.remove(sid)
+	}
+	return bs.backend.Save(r)
+}
+
+// Sessions are saved in one SaveBatch if the backend implements BatchSaver
+func (bs *BackendStore) SetBatch(sessions map[string]*Session) error {
+	saver, ok := bs.backend.(BatchSaver)
+	if !ok {
+		var err error
+		for sid, s := range sessions {
+			if serr := bs.Set(sid, s); serr != nil && err == nil {
+				err = serr
+			}
+		}
+		return err
+	}
+
+	records := make([]Record, 0, len(sessions))
+	for sid, s := range sessions {
+		r, expired, err := bs.record(sid, s)
+		if err != nil {
+			return err
+		}
+		if expired {
+			if err := bs.remove(sid); err != nil {
+				return err
+			}
+			continue
+		}
+		records = append(records, r)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return saver.SaveBatch(records)
+}
+
+// Record of s, or whether it expired and is to be removed instead
+func (bs *BackendStore) record(sid string, s *Session) (Record, bool, error) {
+	snap := s.snapshot()
+	expires := bs.sm.deadline(snap.LastAccessed, snap.Metadata.CreatedAt)
+	if !time.Now().Before(expires) {
+		return Record{}, true, nil
+	}
+
+	data, err := bs.codec.Encode(snap)
+	if err != nil {
+		return Record{}, false, err
+	}
+	return Record{ID: bs.key(sid), Data: data, LastAccessed: snap.LastAccessed, ExpiresAt: expires}, false, nil
+}
+
+func (bs *BackendStore) Delete(sid string) error {
+	return bs.remove(sid)
+}
+
+// Entries that fail to decode are skipped, and handled as
+// Config.CorruptSessions says once the scan is over
+func (bs *BackendStore) Iterate(fn func(sid string, s *Session) bool) error {
+	type entry struct {
+		key  string
+		data []byte
+		err  error
+	}
+	var corrupt []entry
+
+	err := bs.backend.Scan(func(key string, data []byte) bool {
+		sid, ok := bs.sid(key)
+		if !ok {
+			return true
+		}
+		snap, err := bs.codec.Decode(data)
+		if err != nil {
+			corrupt = append(corrupt, entry{key, data, err})
+			return true
+		}
+		snap.ID = sid
+		return fn(sid, bs.sm.restore(snap))
+	})
+
+	for _, e := range corrupt {
+		bs.sm.corrupt(bs.backend, e.key, e.data, e.err)
+	}
+	return err
+}
+
+// Zero if the backend fails. With a Config.Namespace the keys are scanned
+// to count only those of the namespace.
+func (bs *BackendStore) Count() int {
+	if bs.namespaced() {
+		n := 0
+		err := bs.backend.Scan(func(key string, data []byte) bool {
+			if _, ok := bs.sid(key); ok {
+				n++
+			}
+			return true
+		})
+		if err != nil {
+			return 0
+		}
+		return n
+	}
+
+	n, err := bs.backend.Len()
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// Session holding exactly the state of snap
+func (sm *SessionManager) restore(snap Snapshot) *Session {
+	s := sm.newSession(snap.ID)
+
+	s.lock.Lock()
+	s.applyLocked(snap)
+	s.lastAccessed = monotonic(snap.LastAccessed)
+	s.lock.Unlock()
+
+	return s
+}
@@ -0,0 +1,183 @@
+package session
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// Backend keeping encoded sessions in a map, recording the last expiry
+type mapBackend struct {
+	lock    sync.Mutex
+	data    map[string][]byte
+	last    Record
+	expires bool
+	sweeps  int
+}
+
+func newMapBackend() *mapBackend {
+	return &mapBackend{data: make(map[string][]byte)}
+}
+
+func (b *mapBackend) Load(sid string) ([]byte, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.data[sid], nil
+}
+
+func (b *mapBackend) Save(r Record) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.data[r.ID] = r.Data
+	b.last = r
+	return nil
+}
+
+func (b *mapBackend) Remove(sid string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	delete(b.data, sid)
+	return nil
+}
+
+func (b *mapBackend) Scan(fn func(sid string, data []byte) bool) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for sid, data := range b.data {
+		if !fn(sid, data) {
+			break
+		}
+	}
+	return nil
+}
+
+func (b *mapBackend) Len() (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.data), nil
+}
+
+func (b *mapBackend) SelfExpiring() bool {
+	return b.expires
+}
+
+func (b *mapBackend) Sweep() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.sweeps++
+	return nil
+}
+
+func TestBackendStore(t *testing.T) {
+	backend := newMapBackend()
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: NewBackendStore(backend, nil)})
+
+	// Case 1: Writes Persisted
+	s, _ := sm.SessionCreate("sessionid123")
+	s.Set("key1", "value1")
+	if ttl := time.Until(backend.last.ExpiresAt); ttl <= 59*time.Minute || backend.last.ExpiresAt.Sub(backend.last.LastAccessed) != time.Hour {
+		t.Errorf("Expected expiry in an hour, got %v", ttl)
+	}
+	other := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: NewBackendStore(backend, nil)})
+	s2, ok := other.session("sessionid123")
+	if !ok || s2.Get("key1") != "value1" {
+		t.Errorf("Expected persisted value, got %v", s2)
+	}
+
+	// Case 2: Last Access Kept Exactly
+	s.age(30 * time.Minute)
+	sm.store.Set("sessionid123", s)
+	s2, _ = other.session("sessionid123")
+	if time.Since(s2.lastAccessed) < 29*time.Minute {
+		t.Errorf("Expected last access 30 minutes ago, got %v", s2.lastAccessed)
+	}
+
+	// Case 3: Expired Sessions Cleaned
+	s.age(2 * time.Hour)
+	backend.data["sessionid123"], _ = GobCodec{}.Encode(s.snapshot())
+	sm.GlobalCleaner()
+	if n, _ := backend.Len(); n != 0 {
+		t.Errorf("Expected expired session to be removed, got %v", n)
+	}
+
+	// Case 4: Corrupt Entries Skipped When Listing
+	backend.data["corrupt"] = []byte("garbage")
+	if _, ok := sm.session("corrupt"); ok {
+		t.Errorf("Expected corrupt entry to read as missing")
+	}
+	if len(sm.adminSessions()) != 0 {
+		t.Errorf("Expected corrupt entry to be skipped")
+	}
+}
+
+func TestBackendStore_SelfExpiring(t *testing.T) {
+	backend := newMapBackend()
+	backend.expires = true
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: NewBackendStore(backend, nil)})
+
+	// Case 1: Not Scanned For Expired Sessions
+	s, _ := sm.SessionCreate("sessionid123")
+	s.age(2 * time.Hour)
+	backend.data["sessionid123"], _ = GobCodec{}.Encode(s.snapshot())
+	sm.GlobalCleaner()
+	if n, _ := backend.Len(); n != 1 {
+		t.Errorf("Expected the backend to be left to expire it, got %v sessions", n)
+	}
+
+	// Case 2: Swept On Every Cleaner Run
+	sm.GlobalCleaner()
+	if backend.sweeps != 2 {
+		t.Errorf("Expected 2 sweeps, got %v", backend.sweeps)
+	}
+}
+
+func TestBackendStore_SessionState(t *testing.T) {
+	backend := newMapBackend()
+	sm := New(SessionManagerConfig{
+		MaxLifetime:  time.Hour,
+		Store:        NewBackendStore(backend, nil),
+		ValueClasses: map[string]time.Duration{"auth": time.Minute},
+	})
+	s, _ := sm.SessionCreate("sessionid123")
+
+	// Case 1: Value Classes Stored
+	s.SetIn("auth", "uid", "user1")
+	s.Set("cart", 1)
+	stored := sm.stored("sessionid123")
+	if stored.ClassExpiresIn("auth") <= 0 || stored.Get("uid") != "user1" {
+		t.Fatalf("Expected the auth class to be stored")
+	}
+	stored.lock.Lock()
+	stored.classes["auth"].expires = time.Now().Add(-time.Second)
+	stored.lock.Unlock()
+	sm.store.Set("sessionid123", stored)
+	if stored = sm.stored("sessionid123"); stored.Get("uid") != nil || stored.Get("cart") != 1 {
+		t.Errorf("Expected uid to expire with its class and cart to survive")
+	}
+
+	// Case 2: Step-Up Flag Stored
+	s.lock.Lock()
+	s.stepUp = true
+	s.lock.Unlock()
+	s.commit(nil)
+	if !sm.stored("sessionid123").StepUpRequired() {
+		t.Errorf("Expected the step-up flag to be stored")
+	}
+
+	// Case 3: Sudo Deadline Encodes
+	if err := s.EnterSudo(time.Minute); err != nil || !sm.stored("sessionid123").InSudo() {
+		t.Errorf("Expected the sudo window to be stored, got %v", err)
+	}
+
+	// Case 4: Unencodable Value Rolled Back
+	type unregistered struct{ N int }
+	if err := s.Set("bad", unregistered{1}); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	if s.Exist("bad") {
+		t.Errorf("Expected the rejected value to be rolled back")
+	}
+	if err := s.Set("cart", 2); err != nil || sm.stored("sessionid123").Get("cart") != 2 {
+		t.Errorf("Expected later writes to be stored, got %v", err)
+	}
+}
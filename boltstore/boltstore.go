@@ -0,0 +1,175 @@
+// Package boltstore keeps sessions in an embedded bbolt file, so servers
+// started from the command line persist sessions without an external
+// service. Every manager keeps its sessions in a bucket of its own, so one
+// file can serve several managers.
+//
+// bbolt has no expiry of its own: each value starts with the expiry time of
+// the session, expired entries read as missing and are deleted on every
+// cleaner run, after which the file is compacted if the client supports it
+// and sessions were deleted.
+package boltstore
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+)
+
+// Bucket used when Options.Bucket is empty
+const DefaultBucket = "sessions"
+
+// Client is the subset of bbolt the store needs, implemented by a thin
+// adapter over a *bbolt.DB: Get and ForEach in a View transaction, Put and
+// Delete in an Update creating the bucket if it does not exist.
+type Client interface {
+	// Copy of the value of key in bucket, nil if there is none. bbolt values
+	// are only valid during their transaction.
+	Get(bucket, key string) ([]byte, error)
+
+	Put(bucket, key string, value []byte) error
+
+	// Delete keys of bucket in a single transaction
+	Delete(bucket string, keys ...string) error
+
+	// Call fn for every key of bucket in a single read transaction until it
+	// returns false
+	ForEach(bucket string, fn func(key string, value []byte) bool) error
+}
+
+// Implemented by clients able to compact the file, e.g. by copying it with
+// bbolt.Compact, reclaiming the pages of deleted sessions. Called after a
+// cleaner run deleted expired sessions.
+type Compactor interface {
+	Compact() error
+}
+
+type Options struct {
+	// Bucket of the manager, DefaultBucket if empty
+	Bucket string
+
+	// Serialization of sessions, session.GobCodec if nil
+	Codec session.Codec
+}
+
+// Backend implements session.Backend on top of a Client
+type Backend struct {
+	client Client
+	bucket string
+}
+
+// Length of the expiry time prefixed to values
+const expiryLen = 8
+
+var errShortValue = errors.New("stored session is too short")
+
+// Store keeping sessions in bbolt through client. Pass it as Config.Store.
+func New(client Client, opts Options) *session.BackendStore {
+	return session.NewBackendStore(NewBackend(client, opts.Bucket), opts.Codec)
+}
+
+func NewBackend(client Client, bucket string) *Backend {
+	if bucket == "" {
+		bucket = DefaultBucket
+	}
+	return &Backend{client: client, bucket: bucket}
+}
+
+func (b *Backend) Load(sid string) ([]byte, error) {
+	value, err := b.client.Get(b.bucket, sid)
+	if err != nil || value == nil {
+		return nil, err
+	}
+
+	data, live, err := unpack(value, time.Now())
+	if err != nil {
+		// Left for the store to treat as corrupt
+		return value, nil
+	}
+	if !live {
+		return nil, nil
+	}
+	return data, nil
+}
+
+func (b *Backend) Save(r session.Record) error {
+	value := make([]byte, expiryLen+len(r.Data))
+	binary.BigEndian.PutUint64(value, uint64(r.ExpiresAt.UnixNano()))
+	copy(value[expiryLen:], r.Data)
+
+	return b.client.Put(b.bucket, r.ID, value)
+}
+
+func (b *Backend) Remove(sid string) error {
+	return b.client.Delete(b.bucket, sid)
+}
+
+// Values too short to hold an expiry are passed on as is, for the store to
+// treat as corrupt
+func (b *Backend) Scan(fn func(sid string, data []byte) bool) error {
+	now := time.Now()
+	return b.client.ForEach(b.bucket, func(key string, value []byte) bool {
+		data, live, err := unpack(value, now)
+		if err != nil {
+			return fn(key, value)
+		}
+		if !live {
+			return true
+		}
+		return fn(key, data)
+	})
+}
+
+func (b *Backend) Len() (int, error) {
+	n := 0
+	now := time.Now()
+	err := b.client.ForEach(b.bucket, func(key string, value []byte) bool {
+		if _, live, err := unpack(value, now); err != nil || live {
+			n++
+		}
+		return true
+	})
+	return n, err
+}
+
+// Expired entries read as missing until Sweep deletes them
+func (b *Backend) SelfExpiring() bool {
+	return true
+}
+
+// Delete expired entries, run on every cleaner run, and compact the file if
+// any were deleted
+func (b *Backend) Sweep() error {
+	var expired []string
+	now := time.Now()
+	err := b.client.ForEach(b.bucket, func(key string, value []byte) bool {
+		if _, live, err := unpack(value, now); err == nil && !live {
+			expired = append(expired, key)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(expired) == 0 {
+		return nil
+	}
+	if err := b.client.Delete(b.bucket, expired...); err != nil {
+		return err
+	}
+	if c, ok := b.client.(Compactor); ok {
+		return c.Compact()
+	}
+	return nil
+}
+
+// Session data of value and whether it is still live at now
+func unpack(value []byte, now time.Time) ([]byte, bool, error) {
+	if len(value) < expiryLen {
+		return nil, false, errShortValue
+	}
+	expires := time.Unix(0, int64(binary.BigEndian.Uint64(value)))
+	return value[expiryLen:], now.Before(expires), nil
+}
@@ -0,0 +1,171 @@
+package boltstore
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+	"github.com/vpatel95/session-manager/v2/storetest"
+)
+
+// In-memory stand-in for bbolt keeping buckets as maps
+type fakeBolt struct {
+	lock     sync.Mutex
+	buckets  map[string]map[string][]byte
+	compacts int
+}
+
+func newFakeBolt() *fakeBolt {
+	return &fakeBolt{buckets: make(map[string]map[string][]byte)}
+}
+
+func (f *fakeBolt) Get(bucket, key string) ([]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if v, ok := f.buckets[bucket][key]; ok {
+		return append([]byte(nil), v...), nil
+	}
+	return nil, nil
+}
+
+func (f *fakeBolt) Put(bucket, key string, value []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.buckets[bucket] == nil {
+		f.buckets[bucket] = make(map[string][]byte)
+	}
+	f.buckets[bucket][key] = value
+	return nil
+}
+
+func (f *fakeBolt) Delete(bucket string, keys ...string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, key := range keys {
+		delete(f.buckets[bucket], key)
+	}
+	return nil
+}
+
+func (f *fakeBolt) ForEach(bucket string, fn func(key string, value []byte) bool) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	keys := make([]string, 0, len(f.buckets[bucket]))
+	for key := range f.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !fn(key, f.buckets[bucket][key]) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeBolt) Compact() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.compacts++
+	return nil
+}
+
+func newManager(client Client, bucket string) *session.SessionManager {
+	return session.New(session.SessionManagerConfig{
+		CleanerInterval: time.Minute,
+		MaxLifetime:     time.Hour,
+		Store:           New(client, Options{Bucket: bucket}),
+	})
+}
+
+func TestStore(t *testing.T) {
+	bolt := newFakeBolt()
+	sm := newManager(bolt, "")
+	defer sm.Close()
+
+	// Case 1: Session Written To The Default Bucket
+	s, err := sm.SessionCreate("sessionid123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	s.Set("key1", "value1")
+	if _, ok := bolt.buckets[DefaultBucket]["sessionid123"]; !ok {
+		t.Fatalf("Expected session in bucket %v, got %v", DefaultBucket, bolt.buckets)
+	}
+
+	// Case 2: Session Read Back After A Restart
+	restarted := newManager(bolt, "")
+	defer restarted.Close()
+	if got, err := restarted.SessionExpiresIn("sessionid123"); err != nil || got <= 59*time.Minute {
+		t.Errorf("Expected about an hour left, got %v, %v", got, err)
+	}
+
+	// Case 3: Bucket Per Manager
+	other := newManager(bolt, "admin")
+	defer other.Close()
+	other.SessionCreate("sessionid456")
+	if other.SessionExist("sessionid123") || sm.SessionExist("sessionid456") {
+		t.Errorf("Expected managers not to see each other's sessions")
+	}
+	if sm.SessionCount() != 1 || other.SessionCount() != 1 {
+		t.Errorf("Expected 1 session each, got %v and %v", sm.SessionCount(), other.SessionCount())
+	}
+
+	// Case 4: Destroy Deletes The Key
+	sm.SessionDestroy("sessionid123")
+	if v, _ := bolt.Get(DefaultBucket, "sessionid123"); v != nil {
+		t.Errorf("Expected key to be deleted")
+	}
+}
+
+func TestBackend_Sweep(t *testing.T) {
+	bolt := newFakeBolt()
+	b := NewBackend(bolt, "")
+	b.Save(session.Record{ID: "live", Data: []byte("a"), ExpiresAt: time.Now().Add(time.Hour)})
+	b.Save(session.Record{ID: "expired", Data: []byte("b"), ExpiresAt: time.Now().Add(-time.Second)})
+
+	// Case 1: Expired Entries Read As Missing
+	if data, err := b.Load("expired"); err != nil || data != nil {
+		t.Errorf("Expected missing entry, got %q, %v", data, err)
+	}
+	if data, _ := b.Load("live"); string(data) != "a" {
+		t.Errorf("Expected a, got %q", data)
+	}
+	if n, _ := b.Len(); n != 1 {
+		t.Errorf("Expected 1 entry, got %v", n)
+	}
+
+	// Case 2: Expired Entries Deleted And File Compacted
+	if err := b.Sweep(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if _, ok := bolt.buckets[DefaultBucket]["expired"]; ok || bolt.compacts != 1 {
+		t.Errorf("Expected expired entry deleted and 1 compaction, got %v", bolt.compacts)
+	}
+
+	// Case 3: No Compaction Without Deletions
+	b.Sweep()
+	if bolt.compacts != 1 {
+		t.Errorf("Expected 1 compaction, got %v", bolt.compacts)
+	}
+
+	// Case 4: Short Values Passed On As Corrupt
+	bolt.Put(DefaultBucket, "short", []byte("x"))
+	if data, err := b.Load("short"); err != nil || string(data) != "x" {
+		t.Errorf("Expected raw value, got %q, %v", data, err)
+	}
+}
+
+func TestEquivalence(t *testing.T) {
+	// Case 1: Same Behavior As MemoryStore
+	for seed := int64(1); seed <= 5; seed++ {
+		storetest.Equivalent(t, New(newFakeBolt(), Options{Bucket: "sessions"}), seed, 200)
+	}
+}
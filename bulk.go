@@ -0,0 +1,160 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// Stores keeping local copies of sessions in front of another store, such
+// as TieredStore
+type Evicter interface {
+	// Whether a local copy of sid is held
+	Holds(sid string) bool
+	// Drop the local copy of sid, the next read loads it again
+	Evict(sid string)
+}
+
+var errNoEvicter = errors.New("store keeps no local copies to evict")
+
+// Ids of the sessions match returns true for, sorted
+func (sm *SessionManager) matchSessions(match func(s *Session) bool) []string {
+	sm.rlock()
+	var sids []string
+	sm.each(func(sid string, s *Session) {
+		if match(s) {
+			sids = append(sids, sid)
+		}
+	})
+	sm.lock.RUnlock()
+	sort.Strings(sids)
+
+	return sids
+}
+
+// Destroy every session match returns true for and return the ids of those
+// destroyed. With dryRun nothing is changed and the ids of the sessions
+// that would be destroyed are returned, so the scope of the operation can
+// be checked first; a dry run is allowed while the manager is frozen.
+// Sessions destroyed concurrently are left out. The first error stops the
+// operation.
+func (sm *SessionManager) DestroySessions(match func(s *Session) bool, dryRun bool) ([]string, error) {
+	sids := sm.matchSessions(match)
+
+	if dryRun {
+		return sids, nil
+	}
+	if sm.Frozen() {
+		return nil, ErrFrozen
+	}
+
+	destroyed := sids[:0]
+	for _, sid := range sids {
+		if err := sm.SessionDestroy(sid); err != nil {
+			if !sm.SessionExist(sid) {
+				continue
+			}
+			return destroyed, err
+		}
+		destroyed = append(destroyed, sid)
+	}
+	return destroyed, nil
+}
+
+// Drop the local copies of the sessions match returns true for, e.g. after
+// changing them in the persistent store behind the manager's back, and
+// return their ids. The sessions themselves are kept. dryRun works as for
+// DestroySessions. Fails unless the store is an Evicter.
+func (sm *SessionManager) EvictSessions(match func(s *Session) bool, dryRun bool) ([]string, error) {
+	ev, ok := sm.store.(Evicter)
+	if !ok {
+		return nil, errNoEvicter
+	}
+
+	sids := sm.matchSessions(func(s *Session) bool {
+		return ev.Holds(s.ID()) && match(s)
+	})
+	if !dryRun {
+		for _, sid := range sids {
+			ev.Evict(sid)
+		}
+	}
+	return sids, nil
+}
+
+// Move the sessions match returns true for to dst, e.g. to rebalance the
+// tenants of a ManagerPool, and return the ids of those moved. Each session
+// is stored by dst before it is destroyed here. dryRun works as for
+// DestroySessions. Sessions destroyed concurrently are left out. The first
+// error stops the operation.
+func (sm *SessionManager) MigrateSessions(dst *SessionManager, match func(s *Session) bool, dryRun bool) ([]string, error) {
+	sids := sm.matchSessions(match)
+
+	if dryRun {
+		return sids, nil
+	}
+	if sm.Frozen() || dst.Frozen() {
+		return nil, ErrFrozen
+	}
+
+	moved := sids[:0]
+	for _, sid := range sids {
+		s, ok := sm.session(sid)
+		if !ok || s == nil {
+			continue
+		}
+		dst.ApplySnapshot(s.snapshot())
+		if !dst.SessionExist(sid) {
+			return moved, errors.New("session not stored by the destination")
+		}
+		if err := sm.SessionDestroy(sid); err != nil && sm.SessionExist(sid) {
+			return moved, err
+		}
+		moved = append(moved, sid)
+	}
+	return moved, nil
+}
+
+type adminBulkResult struct {
+	DryRun  bool     `json:"dry_run"`
+	Handles []string `json:"handles"`
+}
+
+// DELETE /sessions?user_id={id}[&dry_run=true]. A user is required so a
+// request missing it can't destroy every session.
+func (sm *SessionManager) serveAdminBulkDestroy(w http.ResponseWriter, r *http.Request) {
+	user := r.URL.Query().Get("user_id")
+	if user == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+	dryRun, err := adminDryRun(r)
+	if err != nil {
+		http.Error(w, "invalid dry_run", http.StatusBadRequest)
+		return
+	}
+
+	sids, err := sm.DestroySessions(func(s *Session) bool {
+		return s.UserID() == user
+	}, dryRun)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	res := adminBulkResult{DryRun: dryRun, Handles: make([]string, len(sids))}
+	for i, sid := range sids {
+		res.Handles[i] = AffinityHash(sid)
+	}
+	writeJSON(w, res)
+}
+
+// The dry_run query parameter, false if it is not set
+func adminDryRun(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("dry_run")
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
@@ -0,0 +1,176 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_DestroySessions(t *testing.T) {
+	sm := New()
+	for _, sid := range []string{"sessionid123", "sessionid456", "sessionid789"} {
+		s, _ := sm.SessionCreate(sid)
+		if sid != "sessionid789" {
+			s.SetUserID("alice")
+		}
+	}
+	alice := func(s *Session) bool { return s.UserID() == "alice" }
+
+	// Case 1: Dry Run Lists Without Destroying
+	sids, err := sm.DestroySessions(alice, true)
+	if err != nil || len(sids) != 2 || sids[0] != "sessionid123" || sids[1] != "sessionid456" {
+		t.Errorf("Expected both sessions of alice, got %v, %v", sids, err)
+	}
+	if sm.SessionCount() != 3 {
+		t.Errorf("Expected 3 sessions, got %v", sm.SessionCount())
+	}
+
+	// Case 2: Dry Run Allowed While Frozen
+	ctx, cancel := context.WithCancel(context.Background())
+	sm.Freeze(ctx)
+	if sids, err := sm.DestroySessions(alice, true); err != nil || len(sids) != 2 {
+		t.Errorf("Expected dry run while frozen, got %v, %v", sids, err)
+	}
+	if _, err := sm.DestroySessions(alice, false); err != ErrFrozen {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+	cancel()
+	for sm.Frozen() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Case 3: Matching Sessions Destroyed
+	sids, err = sm.DestroySessions(alice, false)
+	if err != nil || len(sids) != 2 {
+		t.Errorf("Expected 2 sessions destroyed, got %v, %v", sids, err)
+	}
+	if sm.SessionCount() != 1 || !sm.SessionExist("sessionid789") {
+		t.Errorf("Expected only sessionid789 left, got %v", sm.SessionCount())
+	}
+}
+
+func TestSessionManager_AdminBulkDestroy(t *testing.T) {
+	sm := New()
+	s, _ := sm.SessionCreate("sessionid123")
+	s.SetUserID("alice")
+	sm.SessionCreate("sessionid456")
+	handler := sm.AdminHandler(AdminConfig{Tokens: map[string]AdminRole{"ro-token": AdminReadOnly, "rw-token": AdminReadWrite}})
+
+	token := "rw-token"
+	do := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("DELETE", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Case 1: User Required
+	if rec := do("/sessions"); rec.Code != 400 || sm.SessionCount() != 2 {
+		t.Errorf("Expected 400, got %v", rec.Code)
+	}
+
+	// Case 2: Dry Run
+	var res adminBulkResult
+	rec := do("/sessions?user_id=alice&dry_run=true")
+	json.NewDecoder(rec.Body).Decode(&res)
+	if !res.DryRun || len(res.Handles) != 1 || res.Handles[0] != AffinityHash("sessionid123") {
+		t.Errorf("Expected handle of sessionid123, got %+v", res)
+	}
+	if !sm.SessionExist("sessionid123") {
+		t.Errorf("Expected sessionid123 kept by the dry run")
+	}
+
+	// Case 3: Dry Run Needs Only Reading
+	token = "ro-token"
+	if rec := do("/sessions?user_id=alice&dry_run=true"); rec.Code != 200 {
+		t.Errorf("Expected 200, got %v", rec.Code)
+	}
+	if rec := do("/sessions?user_id=alice"); rec.Code != 403 || !sm.SessionExist("sessionid123") {
+		t.Errorf("Expected 403, got %v", rec.Code)
+	}
+	if rec := do("/sessions/" + AffinityHash("sessionid123") + "?dry_run=true"); rec.Code != 403 || !sm.SessionExist("sessionid123") {
+		t.Errorf("Expected 403 for a single session, got %v", rec.Code)
+	}
+	token = "rw-token"
+
+	// Case 4: Destroy
+	rec = do("/sessions?user_id=alice")
+	res = adminBulkResult{}
+	json.NewDecoder(rec.Body).Decode(&res)
+	if res.DryRun || len(res.Handles) != 1 || sm.SessionExist("sessionid123") {
+		t.Errorf("Expected sessionid123 destroyed, got %+v", res)
+	}
+}
+
+func TestSessionManager_EvictSessions(t *testing.T) {
+	// Case 1: Store Without Local Copies
+	if _, err := New().EvictSessions(func(*Session) bool { return true }, false); err == nil {
+		t.Errorf("Expected error for a MemoryStore")
+	}
+
+	ts := NewTieredStore(NewBackendStore(newMapBackend(), nil), 16, 0)
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: ts})
+	defer sm.Close()
+	for _, sid := range []string{"sessionid123", "sessionid456"} {
+		s, _ := sm.SessionCreate(sid)
+		s.SetUserID("alice")
+	}
+	ts.Evict("sessionid456")
+	alice := func(s *Session) bool { return s.UserID() == "alice" }
+
+	// Case 2: Dry Run Lists Held Sessions Only
+	sids, err := sm.EvictSessions(alice, true)
+	if err != nil || len(sids) != 1 || sids[0] != "sessionid123" || !ts.Holds("sessionid123") {
+		t.Errorf("Expected sessionid123 listed and kept, got %v, %v", sids, err)
+	}
+
+	// Case 3: Evicted but Kept in the Store
+	if sids, err = sm.EvictSessions(alice, false); err != nil || len(sids) != 1 || ts.Holds("sessionid123") {
+		t.Errorf("Expected sessionid123 evicted, got %v, %v", sids, err)
+	}
+	if s, ok := sm.session("sessionid123"); !ok || s.UserID() != "alice" {
+		t.Errorf("Expected sessionid123 loaded again")
+	}
+}
+
+func TestSessionManager_MigrateSessions(t *testing.T) {
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour})
+	dst := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: NewBackendStore(newMapBackend(), nil)})
+	defer dst.Close()
+	for _, sid := range []string{"sessionid123", "sessionid456"} {
+		s, _ := sm.SessionCreate(sid)
+		s.Set("key1", sid)
+	}
+	match := func(s *Session) bool { return s.ID() == "sessionid123" }
+
+	// Case 1: Dry Run Moves Nothing
+	sids, err := sm.MigrateSessions(dst, match, true)
+	if err != nil || len(sids) != 1 || dst.SessionExist("sessionid123") || !sm.SessionExist("sessionid123") {
+		t.Errorf("Expected sessionid123 listed only, got %v, %v", sids, err)
+	}
+
+	// Case 2: Refused While Either Manager Is Frozen
+	ctx, cancel := context.WithCancel(context.Background())
+	dst.Freeze(ctx)
+	if _, err := sm.MigrateSessions(dst, match, false); err != ErrFrozen {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+	cancel()
+	for dst.Frozen() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Case 3: Moved With Values
+	if sids, err = sm.MigrateSessions(dst, match, false); err != nil || len(sids) != 1 {
+		t.Errorf("Expected sessionid123 moved, got %v, %v", sids, err)
+	}
+	if s, ok := dst.session("sessionid123"); !ok || s.Get("key1") != "sessionid123" {
+		t.Errorf("Expected sessionid123 with its values in dst")
+	}
+	if sm.SessionExist("sessionid123") || !sm.SessionExist("sessionid456") {
+		t.Errorf("Expected only sessionid456 left")
+	}
+}
@@ -0,0 +1,44 @@
+package session
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Cache-Control of responses personalized by a session: shared caches must
+// not store them and browsers must revalidate before reuse
+const PrivateCacheControl = "private, no-cache"
+
+// Mark the response as depending on the session of the request, so shared
+// caches don't serve it to other users: Cache-Control is set to
+// PrivateCacheControl and Vary lists the session cookie and, if enabled,
+// the session header. Middleware, SessionStart and SessionRegenerate call
+// it unless Config.DisableCacheHeaders is set; call it before writing the
+// response header.
+func (sm *SessionManager) PrivateResponse(w http.ResponseWriter) {
+	h := w.Header()
+	h.Set("Cache-Control", PrivateCacheControl)
+	addVary(h, "Cookie")
+	if sm.Config.EnableHttpHeader && sm.Config.SessionHeader != "" {
+		addVary(h, sm.Config.SessionHeader)
+	}
+}
+
+func (sm *SessionManager) writeCacheHeaders(w http.ResponseWriter) {
+	if !sm.Config.DisableCacheHeaders {
+		sm.PrivateResponse(w)
+	}
+}
+
+// Add name to the Vary header unless it is already listed
+func addVary(h http.Header, name string) {
+	for _, v := range h.Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			field = strings.TrimSpace(field)
+			if field == "*" || strings.EqualFold(field, name) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", name)
+}
@@ -0,0 +1,64 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionManager_CacheHeaders(t *testing.T) {
+	sm := New()
+	sm.SessionCreate("sessionid123")
+	handler := sm.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(sid string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		if sid != "" {
+			req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: sid})
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Case 1: Response With a Session Marked Private
+	rec := serve("sessionid123")
+	if cc := rec.Header().Get("Cache-Control"); cc != PrivateCacheControl {
+		t.Errorf("Expected %q, got %q", PrivateCacheControl, cc)
+	}
+	if vary := rec.Header().Values("Vary"); len(vary) != 1 || vary[0] != "Cookie" {
+		t.Errorf("Expected Vary: Cookie, got %v", vary)
+	}
+
+	// Case 2: Response Without a Session Left Alone
+	rec = serve("")
+	if cc := rec.Header().Get("Cache-Control"); cc != "" {
+		t.Errorf("Expected no Cache-Control, got %q", cc)
+	}
+
+	// Case 3: Session Header Listed, Existing Vary Kept
+	sm.Config.EnableHttpHeader = true
+	sm.Config.SessionHeader = "Session-Id"
+	rec = httptest.NewRecorder()
+	rec.Header().Set("Vary", "Accept-Encoding, cookie")
+	sm.PrivateResponse(rec)
+	if vary := rec.Header().Values("Vary"); len(vary) != 2 || vary[1] != "Session-Id" {
+		t.Errorf("Expected Vary: Accept-Encoding, cookie and Session-Id, got %v", vary)
+	}
+
+	// Case 4: Session Start Marks the Response Private
+	rec = httptest.NewRecorder()
+	if _, err := sm.SessionStart(rec, httptest.NewRequest("GET", "/", nil)); err != nil {
+		t.Fatalf("Expected session, got error: %v", err)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != PrivateCacheControl {
+		t.Errorf("Expected %q, got %q", PrivateCacheControl, cc)
+	}
+
+	// Case 5: Disabled
+	sm.Config.DisableCacheHeaders = true
+	rec = serve("sessionid123")
+	if cc := rec.Header().Get("Cache-Control"); cc != "" {
+		t.Errorf("Expected no Cache-Control, got %q", cc)
+	}
+}
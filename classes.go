@@ -0,0 +1,145 @@
+package session
+
+import (
+	"errors"
+	"time"
+)
+
+// Keys of a value class and when they expire
+type valueClass struct {
+	keys    map[interface{}]struct{}
+	expires time.Time
+}
+
+// Caller must hold s.lock
+func (s *Session) classExpired(key interface{}, now time.Time) bool {
+	name, ok := s.keyClass[key]
+	return ok && !now.Before(s.classes[name].expires)
+}
+
+// Set key as part of a value class from Config.ValueClasses. The keys of a
+// class expire together, Lifetime after the class was last written, while
+// the rest of the session lives on, e.g. to expire the login but keep the
+// cart for after the user signed in again. Class membership is stored and
+// replicated along with the session.
+func (s *Session) SetIn(class string, key, sd interface{}) error {
+	if isReservedKey(key) {
+		return ErrReservedKey
+	}
+	if s.manager == nil {
+		return errors.New("unknown value class")
+	}
+	lifetime, ok := s.manager.Config.ValueClasses[class]
+	if !ok {
+		return errors.New("unknown value class")
+	}
+	if err := s.validate(key, sd); err != nil {
+		return err
+	}
+	if err := s.frozen(); err != nil {
+		return err
+	}
+
+	return s.setIn(class, time.Now().Add(lifetime), key, sd)
+}
+
+// Add key to class, expiring at expires, and return the undo restoring the
+// class key was in before. Caller must hold s.lock, also for the undo.
+func (s *Session) classify(class string, key interface{}, expires time.Time) func() {
+	old, classed := s.keyClass[key]
+	if classed && old != class {
+		delete(s.classes[old].keys, key)
+	}
+	if s.classes == nil {
+		s.classes = make(map[string]*valueClass)
+		s.keyClass = make(map[interface{}]string)
+	}
+	c, ok := s.classes[class]
+	if !ok {
+		c = &valueClass{keys: make(map[interface{}]struct{})}
+		s.classes[class] = c
+	}
+	was := c.expires
+	c.keys[key] = struct{}{}
+	c.expires = expires
+	s.keyClass[key] = class
+
+	return func() {
+		c.expires = was
+		s.unclass(key)
+		if classed {
+			s.classes[old].keys[key] = struct{}{}
+			s.keyClass[key] = old
+		}
+	}
+}
+
+// Time left before the keys of class expire, zero if they did or the class
+// holds no keys
+func (s *Session) ClassExpiresIn(class string) time.Duration {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	c, ok := s.classes[class]
+	if !ok || len(c.keys) == 0 {
+		return 0
+	}
+	if remaining := time.Until(c.expires); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// Delete the keys of class right away, e.g. on logout
+func (s *Session) ExpireClass(class string) {
+	s.lock.Lock()
+	c, ok := s.classes[class]
+	if ok {
+		c.expires = time.Time{}
+	}
+	s.lock.Unlock()
+
+	if ok {
+		s.purgeClasses(time.Now())
+	}
+}
+
+// Whether s holds a value class that expired. Caller must hold s.lock.
+func (s *Session) hasExpiredClass(now time.Time) bool {
+	for _, c := range s.classes {
+		if len(c.keys) > 0 && !now.Before(c.expires) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delete the keys of expired value classes. Until then they read as absent.
+func (s *Session) purgeClasses(now time.Time) {
+	var keys []interface{}
+
+	s.lock.Lock()
+	for _, c := range s.classes {
+		if now.Before(c.expires) {
+			continue
+		}
+		for key := range c.keys {
+			keys = append(keys, key)
+			delete(s.keyClass, key)
+		}
+		c.keys = make(map[interface{}]struct{})
+	}
+	s.lock.Unlock()
+
+	for _, key := range keys {
+		s.delete(key)
+	}
+}
+
+// Drop key from its value class. Caller must hold s.lock.
+func (s *Session) unclass(key interface{}) {
+	if name, ok := s.keyClass[key]; ok {
+		delete(s.classes[name].keys, key)
+		delete(s.keyClass, key)
+	}
+}
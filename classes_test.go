@@ -0,0 +1,114 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSession_SetIn(t *testing.T) {
+	sm := New()
+	sm.Config.ValueClasses = map[string]time.Duration{"auth": time.Hour, "cart": 30 * 24 * time.Hour}
+	s, _ := sm.SessionCreate("sessionid123")
+
+	// Case 1: Unknown Class and Reserved Keys
+	if err := s.SetIn("unknown", "user_id", "user1"); err == nil {
+		t.Errorf("Expected error for an unknown class")
+	}
+	if err := s.SetIn("auth", returnURLKey, "/"); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey, got %v", err)
+	}
+	if err := (&Session{sd: make(dict)}).SetIn("auth", "user_id", "user1"); err == nil {
+		t.Errorf("Expected error without a manager")
+	}
+
+	// Case 2: Classes Expire Independently
+	s.SetIn("auth", "user_id", "user1")
+	s.SetIn("cart", "items", 3)
+	s.Set("theme", "dark")
+	if d := s.ClassExpiresIn("auth"); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("Expected auth to expire in 1h, got %v", d)
+	}
+	s.lock.Lock()
+	s.classes["auth"].expires = time.Now().Add(-time.Second)
+	s.lock.Unlock()
+	if s.Get("user_id") != nil || s.Exist("user_id") || s.ClassExpiresIn("auth") != 0 {
+		t.Errorf("Expected user_id to read as absent once auth expired")
+	}
+	if s.Get("items") != 3 || s.Get("theme") != "dark" {
+		t.Errorf("Expected the cart and unclassed keys to survive")
+	}
+
+	// Case 3: Cleaner Purges Expired Classes
+	sm.GlobalCleaner()
+	s.lock.RLock()
+	_, stored := s.sd["user_id"]
+	s.lock.RUnlock()
+	if stored || !sm.SessionExist("sessionid123") {
+		t.Errorf("Expected user_id to be purged and the session kept")
+	}
+
+	// Case 4: Restored After Re-Login
+	s.SetIn("auth", "user_id", "user1")
+	if s.Get("user_id") != "user1" || s.Get("items") != 3 {
+		t.Errorf("Expected a fresh login next to the old cart")
+	}
+
+	// Case 5: Plain Set After Expiry Keeps the Value
+	s.lock.Lock()
+	s.classes["auth"].expires = time.Now().Add(-time.Second)
+	s.lock.Unlock()
+	s.Set("user_id", "user2")
+	if s.Get("user_id") != "user2" {
+		t.Errorf("Expected the new value to be readable, got %v", s.Get("user_id"))
+	}
+
+	// Case 6: Explicit Expiry
+	s.ExpireClass("cart")
+	if s.Exist("items") || s.Get("theme") != "dark" {
+		t.Errorf("Expected only the cart to be deleted")
+	}
+
+	// Case 7: Delete Drops Class Membership
+	s.SetIn("auth", "role", "admin")
+	s.Delete("role")
+	s.Set("role", "user")
+	s.ExpireClass("auth")
+	if s.Get("role") != "user" {
+		t.Errorf("Expected role to have left the auth class, got %v", s.Get("role"))
+	}
+}
+
+func TestSession_SetInFailedCommit(t *testing.T) {
+	cs := &countingStore{MemoryStore: NewMemoryStore(0)}
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: cs})
+	sm.Config.ValueClasses = map[string]time.Duration{"auth": time.Hour, "cart": 30 * 24 * time.Hour}
+	s, _ := sm.SessionCreate("sessionid123")
+	s.SetIn("cart", "items", 3)
+	s.SetIn("auth", "user_id", "user1")
+	s.lock.RLock()
+	expires := s.classes["auth"].expires
+	s.lock.RUnlock()
+
+	// Case 1: Value and Class Reverted Together
+	cs.fail = true
+	if err := s.SetIn("auth", "items", 4); err == nil {
+		t.Fatalf("Expected the commit to fail")
+	}
+	if err := s.SetIn("auth", "theme", "dark"); err == nil {
+		t.Fatalf("Expected the commit to fail")
+	}
+	cs.fail = false
+	s.lock.RLock()
+	class, themed := s.keyClass["items"], s.keyClass["theme"]
+	reverted := s.classes["auth"].expires.Equal(expires)
+	s.lock.RUnlock()
+	if s.Get("items") != 3 || class != "cart" || themed != "" || !reverted {
+		t.Errorf("Expected items back in cart and auth untouched, got %v in %q", s.Get("items"), class)
+	}
+
+	// Case 2: Expiring the New Class Keeps the Reverted Key
+	s.ExpireClass("auth")
+	if s.Get("items") != 3 || s.Exist("user_id") {
+		t.Errorf("Expected only the auth keys to be deleted")
+	}
+}
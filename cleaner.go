@@ -0,0 +1,321 @@
+package session
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Expired sessions removed per write lock acquisition when
+// Config.CleanerBatchSize is not set
+const DefaultCleanerBatchSize = 256
+
+type idleWarning struct {
+	s         *Session
+	remaining time.Duration
+}
+
+func (sm *SessionManager) cleanerBatchSize() int {
+	if sm.Config.CleanerBatchSize > 0 {
+		return sm.Config.CleanerBatchSize
+	}
+	return DefaultCleanerBatchSize
+}
+
+// Caller must hold sm.lock or the lock of the session id
+func (sm *SessionManager) expired(s *Session, now time.Time) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return now.After(sm.deadline(s.lastAccessed, s.meta.CreatedAt))
+}
+
+// Config.IdleTimeout, or MaxLifetime if it is not set
+func (sm *SessionManager) idleTimeout() time.Duration {
+	if sm.Config.IdleTimeout > 0 {
+		return sm.Config.IdleTimeout
+	}
+	return sm.Config.MaxLifetime
+}
+
+// When a session last accessed at lastAccessed and created at createdAt
+// expires: after the idle timeout, or at the end of Config.AbsoluteLifetime
+// if that comes first
+func (sm *SessionManager) expiry(lastAccessed, createdAt time.Time) time.Time {
+	at := lastAccessed.Add(sm.idleTimeout())
+	if sm.Config.AbsoluteLifetime > 0 && !createdAt.IsZero() {
+		if end := createdAt.Add(sm.Config.AbsoluteLifetime); end.Before(at) {
+			at = end
+		}
+	}
+	return at
+}
+
+// expiry extended by the tolerated ClockSkew, to decide whether a session
+// expired
+func (sm *SessionManager) deadline(lastAccessed, createdAt time.Time) time.Time {
+	at := sm.expiry(lastAccessed, createdAt)
+	if sm.Config.ClockSkew > 0 {
+		at = at.Add(sm.Config.ClockSkew)
+	}
+	return at
+}
+
+// Expiry of s if it is not accessed again
+func (sm *SessionManager) sessionExpiry(s *Session) time.Time {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return sm.expiry(s.lastAccessed, s.meta.CreatedAt)
+}
+
+// Wall-clock time t read from a store or a peer as a local clock reading, so
+// idle and lifetime checks against it use the monotonic clock like those of
+// sessions created here, and wall-clock jumps from then on don't expire or
+// keep alive the session
+func monotonic(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	now := time.Now()
+	return now.Add(t.Sub(now))
+}
+
+// Idle timeout extended by the tolerated ClockSkew, the longest a session
+// can live past its last access
+func (sm *SessionManager) lifetime() time.Duration {
+	if sm.Config.ClockSkew <= 0 {
+		return sm.idleTimeout()
+	}
+	return sm.idleTimeout() + sm.Config.ClockSkew
+}
+
+// Find expired sessions under the read lock so traffic keeps flowing during
+// the scan, and collect idle warnings and expired value classes on the way
+func (sm *SessionManager) scanExpired() ([]string, []idleWarning, []*Session) {
+	var candidates []string
+	var warnings []idleWarning
+	var purge []*Session
+
+	if e, ok := sm.store.(Expiring); ok && e.SelfExpiring() {
+		return nil, nil, nil
+	}
+
+	sm.rlock()
+	defer sm.lock.RUnlock()
+
+	now := time.Now()
+	sm.each(func(sid string, s *Session) {
+		if sm.expired(s, now) {
+			candidates = append(candidates, sid)
+			return
+		}
+
+		if sm.Config.OnIdleWarning != nil && !s.idleWarned.Load() {
+			remaining := sm.sessionExpiry(s).Sub(now)
+			if remaining <= sm.Config.IdleWarningThreshold && s.idleWarned.CompareAndSwap(false, true) {
+				warnings = append(warnings, idleWarning{s, remaining})
+			}
+		}
+
+		s.lock.RLock()
+		if s.hasExpiredClass(now) {
+			purge = append(purge, s)
+		}
+		s.lock.RUnlock()
+	})
+
+	return candidates, warnings, purge
+}
+
+// Delete candidates in small batches under the write lock, summarizing them
+// for Config.ExpirySink first if set. Each session is checked again as it
+// may have been accessed since the scan. Stores other than MemoryStore do
+// I/O, so they are locked per session instead.
+func (sm *SessionManager) removeExpired(candidates []string) ([]*Session, []ExpirySummary) {
+	var expired []*Session
+	var summaries []ExpirySummary
+	var pause time.Duration
+	batch := sm.cleanerBatchSize()
+
+	remove := func(sid string, now time.Time) {
+		if s := sm.lookup(sid); s != nil && sm.expired(s, now) {
+			var sum ExpirySummary
+			if sm.Config.ExpirySink != nil {
+				sum = s.summary()
+			}
+			if sm.store.Delete(sid) == nil {
+				expired = append(expired, s)
+				if sm.Config.ExpirySink != nil {
+					summaries = append(summaries, sum)
+				}
+			}
+		}
+	}
+
+	for len(candidates) > 0 {
+		n := batch
+		if n > len(candidates) {
+			n = len(candidates)
+		}
+
+		if sm.inMemory() {
+			sm.wlock()
+			start := time.Now()
+			for _, sid := range candidates[:n] {
+				remove(sid, start)
+			}
+			pause += time.Since(start)
+			sm.lock.Unlock()
+		} else {
+			for _, sid := range candidates[:n] {
+				unlock := sm.lockSid(sid)
+				remove(sid, time.Now())
+				unlock()
+			}
+		}
+
+		candidates = candidates[n:]
+	}
+
+	sm.contention.recordCleanerPause(pause)
+
+	return expired, summaries
+}
+
+func (sm *SessionManager) GlobalCleaner() {
+	if sm.Frozen() {
+		return
+	}
+
+	candidates, warnings, purge := sm.scanExpired()
+	expired, summaries := sm.removeExpired(candidates)
+	for _, s := range purge {
+		s.purgeClasses(time.Now())
+	}
+
+	sm.cleanerLastRun.Store(time.Now().UnixNano())
+	sm.cleanerLastExpired.Store(int64(len(expired)))
+
+	// Hooks run outside the lock so they are free to use the manager
+	for _, sum := range summaries {
+		sm.Config.ExpirySink(sum)
+	}
+	for _, s := range expired {
+		sm.emit(Event{Type: EventExpired, SessionID: s.ID(), RequestID: s.requestID(), Info: sm.eventInfo(s)})
+	}
+	for _, w := range warnings {
+		sm.Config.OnIdleWarning(w.s, w.remaining)
+	}
+
+	sm.cleanTrustedDevices()
+	sm.cleanTombstones()
+	if sw, ok := sm.store.(Sweeper); ok {
+		sw.Sweep()
+	}
+
+	for _, s := range expired {
+		sm.release(s)
+	}
+}
+
+// Background loop running GlobalCleaner every Config.CleanerInterval, or the
+// shared scheduler the manager is registered with. current is the interval
+// in effect, Config.CleanerInterval is only read at start.
+type cleaner struct {
+	scheduler *CleanerScheduler
+	current   atomic.Int64
+	interval  chan time.Duration
+	stop      chan struct{}
+	done      chan struct{}
+	once      sync.Once
+}
+
+func (sm *SessionManager) startCleaner() {
+	sm.cleaner.current.Store(int64(sm.Config.CleanerInterval))
+	if cs := sm.Config.Scheduler; cs != nil {
+		sm.cleaner.scheduler = cs
+		cs.add(sm, sm.Config.CleanerInterval)
+		return
+	}
+
+	sm.cleaner.interval = make(chan time.Duration)
+	sm.cleaner.stop = make(chan struct{})
+	sm.cleaner.done = make(chan struct{})
+
+	go sm.runCleaner(sm.Config.CleanerInterval)
+}
+
+func (sm *SessionManager) runCleaner(interval time.Duration) {
+	defer close(sm.cleaner.done)
+
+	var ticker *time.Ticker
+	var tick <-chan time.Time
+	reset := func(d time.Duration) {
+		if ticker != nil {
+			ticker.Stop()
+			ticker, tick = nil, nil
+		}
+		// A non-positive interval disables automatic cleaning
+		if d > 0 {
+			ticker = time.NewTicker(d)
+			tick = ticker.C
+		}
+	}
+	reset(interval)
+	defer reset(0)
+
+	for {
+		select {
+		case <-tick:
+			sm.GlobalCleaner()
+		case d := <-sm.cleaner.interval:
+			reset(d)
+		case <-sm.cleaner.stop:
+			return
+		}
+	}
+}
+
+// Change how often the cleaner runs. Zero or less stops automatic cleaning
+// until a positive interval is set again.
+func (sm *SessionManager) SetCleanerInterval(d time.Duration) {
+	if cs := sm.cleaner.scheduler; cs != nil {
+		if cs.setInterval(sm, d) {
+			sm.cleaner.current.Store(int64(d))
+		}
+		return
+	}
+
+	select {
+	case sm.cleaner.interval <- d:
+		sm.cleaner.current.Store(int64(d))
+	case <-sm.cleaner.done:
+	}
+}
+
+// Interval the cleaner currently runs at, as last set by SetCleanerInterval
+func (sm *SessionManager) CleanerInterval() time.Duration {
+	return time.Duration(sm.cleaner.current.Load())
+}
+
+// Stop the background cleaner and anti-entropy repairs. The manager keeps
+// serving sessions, expired ones are only removed by explicit GlobalCleaner
+// calls afterwards.
+func (sm *SessionManager) Close() error {
+	sm.cleaner.once.Do(func() {
+		if cs := sm.cleaner.scheduler; cs != nil {
+			cs.remove(sm)
+			return
+		}
+		close(sm.cleaner.stop)
+		<-sm.cleaner.done
+	})
+	sm.stopAntiEntropy()
+	sm.stopWatch()
+
+	if sm.writeBehind != nil {
+		return sm.writeBehind.close()
+	}
+	return nil
+}
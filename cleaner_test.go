@@ -0,0 +1,215 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_RemoveExpired(t *testing.T) {
+	sm := New()
+	sm.Config.MaxLifetime = time.Hour
+	sm.Config.CleanerBatchSize = 3
+	for i := 0; i < 10; i++ {
+		sm.SessionCreate(fmt.Sprintf("sessionid%d", i))
+		sm.stored(fmt.Sprintf("sessionid%d", i)).age(2 * time.Hour)
+	}
+	sm.SessionCreate("fresh")
+
+	// Case 1: Scan Finds Only Expired Sessions
+	candidates, _, _ := sm.scanExpired()
+	if len(candidates) != 10 {
+		t.Fatalf("Expected 10 candidates, got %v", len(candidates))
+	}
+
+	// Case 2: Session Accessed After the Scan Survives
+	sm.SessionUpdate("sessionid0")
+	before := sm.ContentionStats().LockAcquisitions
+	expired, _ := sm.removeExpired(append(candidates, "nonexistent"))
+	if len(expired) != 9 || !sm.SessionExist("sessionid0") || !sm.SessionExist("fresh") {
+		t.Errorf("Expected 9 expired and sessionid0 to survive, got %v", len(expired))
+	}
+
+	// Case 3: Removed in Batches
+	if n := sm.ContentionStats().LockAcquisitions - before; n < 4 {
+		t.Errorf("Expected at least 4 lock acquisitions for 11 candidates in batches of 3, got %v", n)
+	}
+
+	// Case 4: Nothing to Remove
+	if expired, _ = sm.removeExpired(nil); len(expired) != 0 {
+		t.Errorf("Expected nothing removed, got %v", len(expired))
+	}
+}
+
+func TestSessionManager_SetCleanerInterval(t *testing.T) {
+	sm := New()
+	defer sm.Close()
+	sm.Config.MaxLifetime = time.Hour
+
+	waitGone := func(sid string) bool {
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if !sm.SessionExist(sid) {
+				return true
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		return false
+	}
+
+	// Case 1: Shorter Interval Takes Effect
+	sm.SessionCreate("sessionid123")
+	sm.stored("sessionid123").age(2 * time.Hour)
+	sm.SetCleanerInterval(10 * time.Millisecond)
+	if !waitGone("sessionid123") {
+		t.Errorf("Expected sessionid123 to be cleaned up")
+	}
+	if d := sm.CleanerInterval(); d != 10*time.Millisecond {
+		t.Errorf("Expected 10ms, got %v", d)
+	}
+	if d := sm.Diagnostics().CleanerInterval; d != 10*time.Millisecond {
+		t.Errorf("Expected 10ms in diagnostics, got %v", d)
+	}
+
+	// Case 2: Disabled Cleaner
+	sm.SetCleanerInterval(0)
+	sm.SessionCreate("sessionid456")
+	sm.stored("sessionid456").age(2 * time.Hour)
+	time.Sleep(50 * time.Millisecond)
+	if !sm.SessionExist("sessionid456") {
+		t.Errorf("Expected sessionid456 to survive with cleaner disabled")
+	}
+
+	// Case 3: Changed While Diagnostics Are Read
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			sm.Diagnostics()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		sm.SetCleanerInterval(time.Duration(i+1) * time.Hour)
+	}
+	wg.Wait()
+	if d := sm.CleanerInterval(); d != 100*time.Hour {
+		t.Errorf("Expected 100h, got %v", d)
+	}
+}
+
+func TestSessionManager_Close(t *testing.T) {
+	sm := New(SessionManagerConfig{CleanerInterval: 10 * time.Millisecond, MaxLifetime: time.Hour})
+
+	// Case 1: Close Stops the Cleaner
+	sm.Close()
+	sm.SessionCreate("sessionid123")
+	sm.stored("sessionid123").age(2 * time.Hour)
+	time.Sleep(50 * time.Millisecond)
+	if !sm.SessionExist("sessionid123") {
+		t.Errorf("Expected sessionid123 to survive after Close")
+	}
+
+	// Case 2: Close and SetCleanerInterval After Close Don't Block
+	sm.Close()
+	sm.SetCleanerInterval(time.Millisecond)
+
+	// Case 3: Explicit Cleaning Still Works
+	sm.GlobalCleaner()
+	if sm.SessionExist("sessionid123") {
+		t.Errorf("Expected sessionid123 to be cleaned up")
+	}
+}
+
+func TestSessionManager_ClockSkew(t *testing.T) {
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, ClockSkew: 5 * time.Minute})
+	defer sm.Close()
+
+	// Case 1: Session Within The Skew Not Expired
+	s, _ := sm.SessionCreate("sessionid123")
+	s.age(time.Hour + time.Minute)
+	if candidates, _, _ := sm.scanExpired(); len(candidates) != 0 {
+		t.Errorf("Expected no candidates, got %v", candidates)
+	}
+
+	// Case 2: Session Past The Skew Expired
+	s.age(time.Hour + 10*time.Minute)
+	if candidates, _, _ := sm.scanExpired(); len(candidates) != 1 {
+		t.Errorf("Expected sessionid123 expired, got %v", candidates)
+	}
+
+	// Case 3: Store Expiry Extended By The Skew
+	b := newMapBackend()
+	bm := New(SessionManagerConfig{MaxLifetime: time.Hour, ClockSkew: 5 * time.Minute, Store: NewBackendStore(b, nil)})
+	defer bm.Close()
+	bm.SessionCreate("sessionid456")
+	if d := time.Until(b.last.ExpiresAt); d < time.Hour+4*time.Minute {
+		t.Errorf("Expected expiry after 1h5m, got %v", d)
+	}
+}
+
+func TestSessionManager_AbsoluteLifetime(t *testing.T) {
+	sm := New(SessionManagerConfig{MaxLifetime: 24 * time.Hour, IdleTimeout: time.Hour, AbsoluteLifetime: 8 * time.Hour})
+	defer sm.Close()
+
+	// Case 1: Idle Timeout Replaces MaxLifetime
+	idle, _ := sm.SessionCreate("sessionid123")
+	idle.age(2 * time.Hour)
+	if candidates, _, _ := sm.scanExpired(); len(candidates) != 1 || candidates[0] != "sessionid123" {
+		t.Errorf("Expected sessionid123 expired, got %v", candidates)
+	}
+	sm.SessionDestroy("sessionid123")
+
+	// Case 2: Constantly Touched Session Expires From Creation
+	busy, _ := sm.SessionCreate("sessionid456")
+	busy.lock.Lock()
+	busy.meta.CreatedAt = time.Now().Add(-7*time.Hour - 30*time.Minute)
+	busy.lock.Unlock()
+	if d, _ := sm.SessionExpiresIn("sessionid456"); d > 30*time.Minute || d < 29*time.Minute {
+		t.Errorf("Expected about 30m left, got %v", d)
+	}
+	if candidates, _, _ := sm.scanExpired(); len(candidates) != 0 {
+		t.Errorf("Expected no candidates, got %v", candidates)
+	}
+
+	busy.lock.Lock()
+	busy.meta.CreatedAt = time.Now().Add(-9 * time.Hour)
+	busy.lock.Unlock()
+	sm.SessionUpdate("sessionid456")
+	if candidates, _, _ := sm.scanExpired(); len(candidates) != 1 || candidates[0] != "sessionid456" {
+		t.Errorf("Expected sessionid456 expired, got %v", candidates)
+	}
+
+	// Case 3: Store Expiry Capped By The Absolute Lifetime
+	b := newMapBackend()
+	bm := New(SessionManagerConfig{IdleTimeout: time.Hour, AbsoluteLifetime: 30 * time.Minute, Store: NewBackendStore(b, nil)})
+	defer bm.Close()
+	bm.SessionCreate("sessionid789")
+	if d := time.Until(b.last.ExpiresAt); d > 30*time.Minute {
+		t.Errorf("Expected expiry within 30m, got %v", d)
+	}
+}
+
+func TestSessionManager_MonotonicRestore(t *testing.T) {
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour})
+	defer sm.Close()
+	wall := time.Now().Add(-time.Minute).Round(0)
+
+	// Case 1: Restored Times Carry A Monotonic Reading
+	s := sm.restore(Snapshot{ID: "sessionid123", LastAccessed: wall, Metadata: Metadata{CreatedAt: wall}})
+	if !strings.Contains(s.accessed().String(), "m=") || !strings.Contains(s.Metadata().CreatedAt.String(), "m=") {
+		t.Errorf("Expected monotonic readings, got %v", s.accessed())
+	}
+
+	// Case 2: Wall Clock Time Kept
+	if d := s.accessed().Sub(wall); d < -time.Millisecond || d > time.Millisecond {
+		t.Errorf("Expected last access at %v, got %v", wall, s.accessed())
+	}
+
+	// Case 3: Zero Time Stays Zero
+	if !monotonic(time.Time{}).IsZero() {
+		t.Errorf("Expected zero time")
+	}
+}
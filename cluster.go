@@ -0,0 +1,185 @@
+package session
+
+import "time"
+
+// Copy of a session as exchanged between the instances of a cluster
+type Snapshot struct {
+	ID           string
+	UserID       string
+	Metadata     Metadata
+	LastAccessed time.Time
+	UpdatedAt    time.Time // last write
+	Owner        string    // NodeID holding the write lease
+	LeaseExpires time.Time
+	Values       map[interface{}]interface{}
+
+	StepUp       bool                   // see Session.StepUpRequired
+	LastAccess   Access                 // previous access for travel checks
+	ClassExpires map[string]time.Time   // expiry of each value class
+	KeyClasses   map[interface{}]string // value class of each classed key
+}
+
+// Peer is another instance of a clustered in-memory deployment, implemented
+// on top of whatever transport connects the instances. The serving side
+// answers with SessionManager.Snapshot, ApplySnapshot and ApplyDelete.
+type Peer interface {
+	// Session sid held by the peer, false if it has none
+	FetchSession(sid string) (Snapshot, bool, error)
+
+	// Replicate a copy of a session to the peer
+	StoreSession(snap Snapshot) error
+
+	// Remove session sid from the peer
+	DeleteSession(sid string) error
+}
+
+// Copy of session sid for a peer, false if there is no such session
+func (sm *SessionManager) Snapshot(sid string) (Snapshot, bool) {
+	sm.rlock()
+	defer sm.lock.RUnlock()
+
+	s := sm.lookup(sid)
+	if s == nil {
+		return Snapshot{}, false
+	}
+
+	return s.snapshot(), true
+}
+
+func (s *Session) snapshot() Snapshot {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.snapshotLocked()
+}
+
+// Caller must hold s.lock
+func (s *Session) snapshotLocked() Snapshot {
+	values := make(map[interface{}]interface{}, len(s.sd))
+	for k, v := range s.sd {
+		values[k] = v
+	}
+
+	var classExpires map[string]time.Time
+	var keyClasses map[interface{}]string
+	if len(s.keyClass) > 0 {
+		classExpires = make(map[string]time.Time, len(s.classes))
+		for name, c := range s.classes {
+			classExpires[name] = c.expires
+		}
+		keyClasses = make(map[interface{}]string, len(s.keyClass))
+		for k, name := range s.keyClass {
+			keyClasses[k] = name
+		}
+	}
+
+	return Snapshot{
+		ID:           s.sessionId,
+		UserID:       s.userId,
+		Metadata:     s.meta,
+		LastAccessed: s.lastAccessed,
+		UpdatedAt:    s.updatedAt,
+		Owner:        s.owner,
+		LeaseExpires: s.leaseExpires,
+		Values:       values,
+		StepUp:       s.stepUp,
+		LastAccess:   s.lastAccess,
+		ClassExpires: classExpires,
+		KeyClasses:   keyClasses,
+	}
+}
+
+// Ask Config.Peers in order for a session that missed locally and adopt the
+// first live copy, so failing over to this instance is transparent. Peer
+// errors are treated as misses.
+func (sm *SessionManager) fetchFromPeers(sid string) *Session {
+	for _, p := range sm.Config.Peers {
+		snap, found, err := p.FetchSession(sid)
+		if err != nil || !found || snap.ID != sid {
+			continue
+		}
+		if !sm.live(snap) {
+			continue
+		}
+
+		return sm.adopt(snap)
+	}
+
+	return nil
+}
+
+func (sm *SessionManager) live(snap Snapshot) bool {
+	return time.Now().Before(sm.deadline(snap.LastAccessed, snap.Metadata.CreatedAt))
+}
+
+func (sm *SessionManager) adopt(snap Snapshot) *Session {
+	s := sm.newSession(snap.ID)
+	s.apply(snap)
+
+	unlock := sm.lockSid(snap.ID)
+	if sm.buried(snap.ID, snap.UpdatedAt) {
+		// Destroyed here after the copy was written
+		unlock()
+		sm.release(s)
+		return nil
+	}
+	if existing := sm.lookup(snap.ID); existing != nil {
+		// Adopted concurrently by another request
+		unlock()
+		sm.release(s)
+		return existing
+	}
+	if err := sm.store.Set(snap.ID, s); err != nil {
+		unlock()
+		return nil
+	}
+	unlock()
+
+	sm.emit(Event{Type: EventAdopted, SessionID: snap.ID, Info: sm.eventInfo(s)})
+	return s
+}
+
+// Replace the state of s with snap
+func (s *Session) apply(snap Snapshot) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.applyLocked(snap)
+}
+
+// Caller must hold s.lock
+func (s *Session) applyLocked(snap Snapshot) {
+	for k := range s.sd {
+		delete(s.sd, k)
+	}
+	for k, v := range snap.Values {
+		s.sd[s.internKey(k)] = v
+	}
+	s.userId = snap.UserID
+	s.meta = snap.Metadata
+	s.meta.CreatedAt = monotonic(snap.Metadata.CreatedAt)
+	s.updatedAt = snap.UpdatedAt
+	s.owner = snap.Owner
+	s.leaseExpires = snap.LeaseExpires
+	s.stepUp = snap.StepUp
+	s.lastAccess = snap.LastAccess
+
+	s.classes, s.keyClass = nil, nil
+	for k, name := range snap.KeyClasses {
+		if s.classes == nil {
+			s.classes = make(map[string]*valueClass)
+			s.keyClass = make(map[interface{}]string)
+		}
+		c, ok := s.classes[name]
+		if !ok {
+			c = &valueClass{keys: make(map[interface{}]struct{}), expires: monotonic(snap.ClassExpires[name])}
+			s.classes[name] = c
+		}
+		k = s.internKey(k)
+		c.keys[k] = struct{}{}
+		s.keyClass[k] = name
+	}
+	if snap.LastAccessed.After(s.lastAccessed) {
+		s.lastAccessed = monotonic(snap.LastAccessed)
+	}
+}
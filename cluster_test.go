@@ -0,0 +1,120 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Peer backed by a manager in the same process
+type managerPeer struct {
+	sm *SessionManager
+}
+
+func (p managerPeer) FetchSession(sid string) (Snapshot, bool, error) {
+	snap, ok := p.sm.Snapshot(sid)
+	return snap, ok, nil
+}
+
+func (p managerPeer) StoreSession(snap Snapshot) error {
+	p.sm.ApplySnapshot(snap)
+	return nil
+}
+
+func (p managerPeer) DeleteSession(sid string) error {
+	p.sm.ApplyDelete(sid)
+	return nil
+}
+
+func (p managerPeer) Digest() (map[string]time.Time, error) {
+	return p.sm.Digest(), nil
+}
+
+func (p managerPeer) NodeID() string {
+	return p.sm.Config.NodeID
+}
+
+func (p managerPeer) ForwardWrite(w Write) error {
+	return p.sm.ApplyWrite(w)
+}
+
+var errPeerUnreachable = errors.New("peer unreachable")
+
+type failingPeer struct{}
+
+func (failingPeer) FetchSession(string) (Snapshot, bool, error) {
+	return Snapshot{}, false, errPeerUnreachable
+}
+
+func (failingPeer) StoreSession(Snapshot) error {
+	return errPeerUnreachable
+}
+
+func (failingPeer) DeleteSession(string) error {
+	return errPeerUnreachable
+}
+
+func TestSessionManager_Snapshot(t *testing.T) {
+	sm := New()
+	s, _ := sm.SessionCreate("sessionid123")
+	s.Set("cart", 1)
+	s.SetUserID("user1")
+
+	// Case 1: Copy of the Session
+	snap, ok := sm.Snapshot("sessionid123")
+	if !ok || snap.ID != "sessionid123" || snap.UserID != "user1" || snap.Values["cart"] != 1 {
+		t.Errorf("Expected snapshot of sessionid123, got %v", snap)
+	}
+
+	// Case 2: Values Are Copied
+	snap.Values["cart"] = 2
+	if s.Get("cart") != 1 {
+		t.Errorf("Expected the session to be unaffected, got %v", s.Get("cart"))
+	}
+
+	// Case 3: Unknown Session
+	if _, ok := sm.Snapshot("unknown"); ok {
+		t.Errorf("Expected no snapshot for an unknown session")
+	}
+}
+
+func TestSessionManager_FetchFromPeers(t *testing.T) {
+	owner := New()
+	s, _ := owner.SessionCreate("sessionid123")
+	s.Set("cart", 1)
+	expired, _ := owner.SessionCreate("sessionid456")
+	expired.age(48 * time.Hour)
+
+	sm := New(SessionManagerConfig{MaxLifetime: 24 * time.Hour, Peers: []Peer{failingPeer{}, managerPeer{owner}}})
+	read := func(sid string) (*Session, error) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: sid})
+		return sm.SessionRead(req)
+	}
+
+	// Case 1: Local Miss Adopts the Peer Copy
+	adopted, err := read("sessionid123")
+	if err != nil || adopted.Get("cart") != 1 || !sm.SessionExist("sessionid123") {
+		t.Fatalf("Expected adopted session, got %v, error: %v", adopted, err)
+	}
+	if events := sm.RecentEvents(); len(events) != 1 || events[0].Type != EventAdopted {
+		t.Errorf("Expected adopted event, got %v", events)
+	}
+
+	// Case 2: Adopted Session Is Served Locally
+	if again, _ := read("sessionid123"); again != adopted {
+		t.Errorf("Expected the adopted session, got %v", again)
+	}
+
+	// Case 3: Expired Peer Copy Is Ignored
+	if _, err := read("sessionid456"); err == nil || sm.SessionExist("sessionid456") {
+		t.Errorf("Expected expired peer copy to be ignored")
+	}
+
+	// Case 4: Unknown Everywhere
+	if _, err := read("unknown"); err == nil {
+		t.Errorf("Expected session not found")
+	}
+}
@@ -0,0 +1,36 @@
+package session
+
+import (
+	"bytes"
+	"encoding/gob"
+	"time"
+)
+
+// Codec serializes sessions for stores that keep them outside the process
+type Codec interface {
+	Encode(snap Snapshot) ([]byte, error)
+	Decode(data []byte) (Snapshot, error)
+}
+
+func init() {
+	// Types the package itself stores as values, e.g. the sudo deadline
+	RegisterType(time.Time{})
+}
+
+// GobCodec is the default Codec. Custom types stored as values must be
+// registered with RegisterType.
+type GobCodec struct{}
+
+func (GobCodec) Encode(snap Snapshot) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(snap); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Decode(data []byte) (Snapshot, error) {
+	var snap Snapshot
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&snap)
+	return snap, err
+}
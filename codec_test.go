@@ -0,0 +1,55 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGobCodec(t *testing.T) {
+	now := time.Now().Round(0)
+	snap := Snapshot{
+		ID:           "sessionid123",
+		UserID:       "user1",
+		Metadata:     Metadata{CreatedAt: now, IP: "10.0.0.1"},
+		LastAccessed: now,
+		Values:       map[interface{}]interface{}{"key1": "value1", 2: 3.5},
+	}
+
+	// Case 1: Round Trip
+	data, err := GobCodec{}.Encode(snap)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got, err := GobCodec{}.Decode(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.UserID != "user1" || got.Values["key1"] != "value1" || got.Values[2] != 3.5 || !got.LastAccessed.Equal(now) || got.Metadata.IP != "10.0.0.1" {
+		t.Errorf("Expected decoded snapshot to match, got %+v", got)
+	}
+
+	// Case 2: Corrupt Data
+	if _, err := (GobCodec{}).Decode([]byte("garbage")); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func FuzzGobCodec_Decode(f *testing.F) {
+	seed, _ := GobCodec{}.Encode(Snapshot{ID: "sessionid123", UserID: "user1", LastAccessed: time.Now(), Values: map[interface{}]interface{}{"key1": "value1"}})
+	f.Add(seed)
+	f.Add([]byte("garbage"))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Case 1: Malformed Data Never Panics
+		snap, err := GobCodec{}.Decode(data)
+		if err != nil {
+			return
+		}
+
+		// Case 2: Decoded Snapshots Encode Again
+		if _, err := (GobCodec{}).Encode(snap); err != nil {
+			t.Errorf("Expected decoded snapshot to encode, got %v", err)
+		}
+	})
+}
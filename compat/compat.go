@@ -0,0 +1,46 @@
+// Package compat maps v1 calls onto the v2 core, so code written against
+// github.com/vpatel95/session-manager migrates by changing its import:
+//
+//	import sm "github.com/vpatel95/session-manager/v2/compat"
+//
+// The v1 types are aliases of their v2 counterparts, so values can be passed
+// to v2 APIs as they are and callers can move over one call at a time. Calls
+// whose v1 form no longer exists in v2 are functions here taking the session
+// first, e.g. compat.SetLocale(s, "de-DE") for s.SetLocale("de-DE"). They
+// drop the errors v2 reports, as v1 did.
+package compat
+
+import (
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+)
+
+type (
+	Session              = session.Session
+	SessionManager       = session.SessionManager
+	SessionManagerConfig = session.SessionManagerConfig
+	SessionCookie        = session.SessionCookie
+)
+
+// Create a new instance of session manager, with the v1 defaults if no
+// config is given
+func New(config ...SessionManagerConfig) *SessionManager {
+	return session.New(config...)
+}
+
+// v1 s.SetLocale(locale)
+func SetLocale(s *Session, locale string) {
+	s.SetLocale(locale)
+}
+
+// v1 s.EnterSudo(d)
+func EnterSudo(s *Session, d time.Duration) {
+	s.EnterSudo(d)
+}
+
+// v1 s.Bucket(experiment, variants...)
+func Bucket(s *Session, experiment string, variants ...string) string {
+	variant, _ := s.Bucket(experiment, variants...)
+	return variant
+}
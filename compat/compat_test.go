@@ -0,0 +1,56 @@
+package compat
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+)
+
+func TestV1Calls(t *testing.T) {
+	manager := New()
+
+	// Case 1: v1 Defaults
+	if manager.Config.CleanerInterval != time.Minute || manager.Config.MaxLifetime != 24*time.Hour || manager.Cookie.Name != "sessionid" {
+		t.Errorf("Expected the v1 defaults, got %+v, %+v", manager.Config, manager.Cookie)
+	}
+
+	// Case 2: v1 Session Lifecycle
+	s, err := manager.SessionCreate("sessionid123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	s.Set("key1", "value1")
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: manager.Cookie.Name, Value: "sessionid123"})
+	if s, err := manager.SessionRead(req); err != nil || s.Get("key1") != "value1" {
+		t.Errorf("Expected the session read from the cookie, got %v", err)
+	}
+	if _, err := manager.SessionRefresh("sessionid123", "sessionid456"); err != nil || !manager.SessionExist("sessionid456") {
+		t.Errorf("Expected the session refreshed, got %v", err)
+	}
+	manager.SessionDestroy("sessionid456")
+	manager.GlobalCleaner()
+	if manager.SessionCount() != 0 {
+		t.Errorf("Expected no sessions, got %v", manager.SessionCount())
+	}
+
+	// Case 3: Changed Calls Mapped
+	s, _ = manager.SessionCreate("sessionid789")
+	SetLocale(s, "de-DE")
+	EnterSudo(s, time.Minute)
+	if s.Locale() != "de-DE" || !s.InSudo() {
+		t.Errorf("Expected locale and sudo window set")
+	}
+	if v := Bucket(s, "checkout", "control", "one-page"); v != "control" && v != "one-page" {
+		t.Errorf("Expected a variant, got %v", v)
+	}
+
+	// Case 4: Values Shared With v2
+	var v2 *session.SessionManager = manager
+	if !v2.SessionExist("sessionid789") {
+		t.Errorf("Expected the manager usable through v2")
+	}
+}
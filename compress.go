@@ -0,0 +1,77 @@
+package session
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// Encodings shorter than this are stored as they are by a CompressedCodec
+// created with a zero threshold
+const DefaultCompressionThreshold = 1024
+
+// CompressedCodec gzip-compresses what another Codec encodes once it is at
+// least threshold bytes long, for sessions carrying large values. Shorter
+// encodings are stored unchanged, and compressed ones are told apart by the
+// gzip header, so a store can switch to it with sessions already written.
+// To combine it with encryption compress first:
+// NewEncryptedCodec(NewCompressedCodec(nil, 0), key).
+type CompressedCodec struct {
+	codec     Codec
+	threshold int
+}
+
+// Codec compressing the output of codec, GobCodec if nil, from threshold
+// bytes, DefaultCompressionThreshold if zero
+func NewCompressedCodec(codec Codec, threshold int) *CompressedCodec {
+	if codec == nil {
+		codec = GobCodec{}
+	}
+	if threshold <= 0 {
+		threshold = DefaultCompressionThreshold
+	}
+	return &CompressedCodec{codec: codec, threshold: threshold}
+}
+
+func (cc *CompressedCodec) Encode(snap Snapshot) ([]byte, error) {
+	data, err := cc.codec.Encode(snap)
+	if err != nil || len(data) < cc.threshold {
+		return data, err
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if buf.Len() >= len(data) {
+		// Incompressible, e.g. already encrypted
+		return data, nil
+	}
+	return buf.Bytes(), nil
+}
+
+func (cc *CompressedCodec) Decode(data []byte) (Snapshot, error) {
+	if !gzipped(data) {
+		return cc.codec.Decode(data)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return Snapshot{}, err
+	}
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return cc.codec.Decode(plain)
+}
+
+// A gob encoding can't start with the gzip magic: its leading message
+// length would be followed by a byte count of 117
+func gzipped(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
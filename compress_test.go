@@ -0,0 +1,63 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompressedCodec(t *testing.T) {
+	cc := NewCompressedCodec(nil, 0)
+	profile := strings.Repeat("profile data ", 1000)
+	large := Snapshot{ID: "sessionid123", LastAccessed: time.Now(), Values: map[interface{}]interface{}{"profile": profile}}
+	small := Snapshot{ID: "sessionid456", LastAccessed: time.Now(), Values: map[interface{}]interface{}{"cart": 1}}
+
+	// Case 1: Large Encodings Compressed
+	plain, _ := GobCodec{}.Encode(large)
+	data, err := cc.Encode(large)
+	if err != nil || !gzipped(data) || len(data) >= len(plain)/4 {
+		t.Errorf("Expected compressed encoding, got %v of %v bytes, %v", len(data), len(plain), err)
+	}
+	if got, err := cc.Decode(data); err != nil || got.Values["profile"] != profile {
+		t.Errorf("Expected decoded snapshot, got %v", err)
+	}
+
+	// Case 2: Small Encodings Stored As They Are
+	data, _ = cc.Encode(small)
+	plain, _ = GobCodec{}.Encode(small)
+	if string(data) != string(plain) {
+		t.Errorf("Expected uncompressed encoding")
+	}
+	if got, err := cc.Decode(plain); err != nil || got.Values["cart"] != 1 {
+		t.Errorf("Expected uncompressed data decoded, got %+v, %v", got, err)
+	}
+
+	// Case 3: Corrupt Compressed Data
+	data, _ = cc.Encode(large)
+	if _, err := cc.Decode(data[:len(data)/2]); err == nil {
+		t.Errorf("Expected error for truncated data")
+	}
+
+	// Case 4: Combined With Encryption
+	ec, _ := NewEncryptedCodec(NewCompressedCodec(nil, 0), make([]byte, 32))
+	data, _ = ec.Encode(large)
+	if len(data) >= 1000 {
+		t.Errorf("Expected compressed ciphertext, got %v bytes", len(data))
+	}
+	if got, err := ec.Decode(data); err != nil || got.Values["profile"] != profile {
+		t.Errorf("Expected decoded snapshot, got %v", err)
+	}
+}
+
+func FuzzCompressedCodec_Decode(f *testing.F) {
+	cc := NewCompressedCodec(nil, 1)
+	seed, _ := cc.Encode(Snapshot{ID: "sessionid123", LastAccessed: time.Now(), Values: map[interface{}]interface{}{"profile": strings.Repeat("x", 100)}})
+	f.Add(seed)
+	f.Add([]byte{0x1f, 0x8b})
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Case 1: Malformed Data Never Panics
+		cc.Decode(data)
+	})
+}
@@ -0,0 +1,97 @@
+package session
+
+import (
+	"reflect"
+	"time"
+)
+
+// ConflictResolver merges two copies of the same session that were written
+// on different instances. It is called with the session locked and must not
+// use the manager.
+type ConflictResolver interface {
+	Resolve(local, remote Snapshot) Snapshot
+}
+
+// Adapter to use an ordinary function as a ConflictResolver
+type ConflictResolverFunc func(local, remote Snapshot) Snapshot
+
+func (f ConflictResolverFunc) Resolve(local, remote Snapshot) Snapshot {
+	return f(local, remote)
+}
+
+var (
+	// The most recently written copy replaces the other one, the default
+	LastWriteWins ConflictResolver = ConflictResolverFunc(lastWriteWins)
+
+	// Keys of both copies are kept, the most recently written copy wins for
+	// keys set in both. A key deleted on one instance while the session was
+	// written to on another one is kept.
+	PerKeyMerge ConflictResolver = ConflictResolverFunc(perKeyMerge)
+)
+
+func lastWriteWins(local, remote Snapshot) Snapshot {
+	if remote.UpdatedAt.After(local.UpdatedAt) {
+		return remote
+	}
+	return local
+}
+
+func perKeyMerge(local, remote Snapshot) Snapshot {
+	older, newer := remote, local
+	if remote.UpdatedAt.After(local.UpdatedAt) {
+		older, newer = local, remote
+	}
+
+	merged := newer
+	merged.Values = make(map[interface{}]interface{}, len(newer.Values)+len(older.Values))
+	for k, v := range older.Values {
+		merged.Values[k] = v
+	}
+	for k, v := range newer.Values {
+		merged.Values[k] = v
+	}
+	if older.LastAccessed.After(merged.LastAccessed) {
+		merged.LastAccessed = older.LastAccessed
+	}
+
+	return merged
+}
+
+func (sm *SessionManager) conflictResolver() ConflictResolver {
+	if sm.Config.ConflictResolver != nil {
+		return sm.Config.ConflictResolver
+	}
+	return LastWriteWins
+}
+
+func sameContent(a, b Snapshot) bool {
+	return a.UserID == b.UserID && reflect.DeepEqual(a.Values, b.Values)
+}
+
+// Resolve the local session s with a remote copy. A result that differs from
+// both copies counts as a new write, so it wins on the next repair.
+func (sm *SessionManager) reconcile(s *Session, remote Snapshot) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	merged := sm.conflictResolver().Resolve(s.snapshotLocked(), remote)
+
+	// Compared with the session itself, the resolver may modify its input
+	unchanged := merged.UserID == s.userId && reflect.DeepEqual(merged.Values, map[interface{}]interface{}(s.sd))
+	if unchanged && !merged.UpdatedAt.After(s.updatedAt) {
+		return
+	}
+	if !unchanged && !sameContent(merged, remote) {
+		latest := s.updatedAt
+		if remote.UpdatedAt.After(latest) {
+			latest = remote.UpdatedAt
+		}
+		merged.UpdatedAt = time.Now()
+		if !merged.UpdatedAt.After(latest) {
+			merged.UpdatedAt = latest.Add(time.Nanosecond)
+		}
+	}
+
+	merged.ID = s.sessionId
+	s.applyLocked(merged)
+}
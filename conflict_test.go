@@ -0,0 +1,87 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLastWriteWins(t *testing.T) {
+	now := time.Now()
+	older := Snapshot{UpdatedAt: now.Add(-time.Minute), Values: map[interface{}]interface{}{"cart": 1}}
+	newer := Snapshot{UpdatedAt: now, Values: map[interface{}]interface{}{"cart": 2}}
+
+	// Case 1: Remote Newer
+	if got := LastWriteWins.Resolve(older, newer); got.Values["cart"] != 2 {
+		t.Errorf("Expected the remote copy, got %v", got.Values)
+	}
+
+	// Case 2: Local Newer
+	if got := LastWriteWins.Resolve(newer, older); got.Values["cart"] != 2 {
+		t.Errorf("Expected the local copy, got %v", got.Values)
+	}
+}
+
+func TestPerKeyMerge(t *testing.T) {
+	now := time.Now()
+	local := Snapshot{UserID: "user1", UpdatedAt: now.Add(-time.Minute), LastAccessed: now, Values: map[interface{}]interface{}{"cart": 1, "theme": "dark"}}
+	remote := Snapshot{UserID: "user2", UpdatedAt: now, LastAccessed: now.Add(-time.Minute), Values: map[interface{}]interface{}{"cart": 2, "locale": "en"}}
+
+	got := PerKeyMerge.Resolve(local, remote)
+
+	// Case 1: Keys of Both Copies Kept
+	if got.Values["theme"] != "dark" || got.Values["locale"] != "en" {
+		t.Errorf("Expected keys of both copies, got %v", got.Values)
+	}
+
+	// Case 2: Newer Copy Wins Common Keys
+	if got.Values["cart"] != 2 || got.UserID != "user2" {
+		t.Errorf("Expected the newer copy to win, got %v", got)
+	}
+
+	// Case 3: Latest Access Kept
+	if !got.LastAccessed.Equal(now) {
+		t.Errorf("Expected the latest access, got %v", got.LastAccessed)
+	}
+
+	// Case 4: Inputs Untouched
+	if len(local.Values) != 2 || len(remote.Values) != 2 {
+		t.Errorf("Expected the copies to be unchanged")
+	}
+}
+
+func TestSessionManager_Reconcile(t *testing.T) {
+	sm := New()
+	s, _ := sm.SessionCreate("sessionid123")
+	s.Set("theme", "dark")
+	remote := Snapshot{ID: "sessionid123", LastAccessed: time.Now(), UpdatedAt: time.Now().Add(time.Second), Values: map[interface{}]interface{}{"cart": 1}}
+
+	// Case 1: Default Replaces the Older Copy
+	sm.ApplySnapshot(remote)
+	if s.Exist("theme") || s.Get("cart") != 1 {
+		t.Errorf("Expected last write to win, got %v", s.sd)
+	}
+
+	// Case 2: Per-Key Merge Counts as a New Write
+	sm.Config.ConflictResolver = PerKeyMerge
+	s.Set("theme", "dark")
+	before := s.snapshot().UpdatedAt
+	remote.Values = map[interface{}]interface{}{"locale": "en"}
+	remote.UpdatedAt = before.Add(time.Second)
+	sm.ApplySnapshot(remote)
+	if s.Get("theme") != "dark" || s.Get("cart") != 1 || s.Get("locale") != "en" {
+		t.Errorf("Expected merged keys, got %v", s.sd)
+	}
+	if !s.snapshot().UpdatedAt.After(remote.UpdatedAt) {
+		t.Errorf("Expected the merge to be newer than both copies")
+	}
+
+	// Case 3: Custom Resolver
+	sm.Config.ConflictResolver = ConflictResolverFunc(func(local, remote Snapshot) Snapshot {
+		local.Values["resolved"] = true
+		return local
+	})
+	sm.ApplySnapshot(remote)
+	if s.Get("resolved") != true {
+		t.Errorf("Expected the custom resolver to be used")
+	}
+}
@@ -0,0 +1,70 @@
+package session
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Contention on the session table lock, to verify whether locking changes
+// help a given workload. Wait times cover every acquisition of the lock, the
+// cleaner pause is how long a cleaner run held it exclusively.
+type ContentionStats struct {
+	LockAcquisitions  uint64        `json:"lock_acquisitions"`
+	LockWaitTotal     time.Duration `json:"lock_wait_total"`
+	LockWaitMax       time.Duration `json:"lock_wait_max"`
+	CleanerRuns       uint64        `json:"cleaner_runs"`
+	CleanerPauseTotal time.Duration `json:"cleaner_pause_total"`
+	CleanerPauseLast  time.Duration `json:"cleaner_pause_last"`
+}
+
+type contention struct {
+	acquisitions atomic.Uint64
+	waitTotal    atomic.Int64
+	waitMax      atomic.Int64
+	cleanerRuns  atomic.Uint64
+	pauseTotal   atomic.Int64
+	pauseLast    atomic.Int64
+}
+
+func (c *contention) recordWait(d time.Duration) {
+	c.acquisitions.Add(1)
+	c.waitTotal.Add(int64(d))
+	for {
+		max := c.waitMax.Load()
+		if int64(d) <= max || c.waitMax.CompareAndSwap(max, int64(d)) {
+			return
+		}
+	}
+}
+
+func (c *contention) recordCleanerPause(d time.Duration) {
+	c.cleanerRuns.Add(1)
+	c.pauseTotal.Add(int64(d))
+	c.pauseLast.Store(int64(d))
+}
+
+// Acquire the session table lock for writing, recording the wait
+func (sm *SessionManager) wlock() {
+	start := time.Now()
+	sm.lock.Lock()
+	sm.contention.recordWait(time.Since(start))
+}
+
+// Acquire the session table lock for reading, recording the wait
+func (sm *SessionManager) rlock() {
+	start := time.Now()
+	sm.lock.RLock()
+	sm.contention.recordWait(time.Since(start))
+}
+
+func (sm *SessionManager) ContentionStats() ContentionStats {
+	c := &sm.contention
+	return ContentionStats{
+		LockAcquisitions:  c.acquisitions.Load(),
+		LockWaitTotal:     time.Duration(c.waitTotal.Load()),
+		LockWaitMax:       time.Duration(c.waitMax.Load()),
+		CleanerRuns:       c.cleanerRuns.Load(),
+		CleanerPauseTotal: time.Duration(c.pauseTotal.Load()),
+		CleanerPauseLast:  time.Duration(c.pauseLast.Load()),
+	}
+}
@@ -0,0 +1,57 @@
+package session
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_ContentionStats(t *testing.T) {
+	sm := New()
+	sm.Config.MaxLifetime = time.Hour
+	before := sm.ContentionStats()
+
+	// Case 1: Lock Acquisitions Counted
+	sm.SessionCreate("sessionid123")
+	sm.SessionExist("sessionid123")
+	stats := sm.ContentionStats()
+	if stats.LockAcquisitions < before.LockAcquisitions+2 {
+		t.Errorf("Expected at least %v acquisitions, got %v", before.LockAcquisitions+2, stats.LockAcquisitions)
+	}
+
+	// Case 2: Cleaner Pause Recorded
+	sm.SessionCreate("sessionid456")
+	sm.stored("sessionid456").age(2 * time.Hour)
+	sm.GlobalCleaner()
+	stats = sm.ContentionStats()
+	if stats.CleanerRuns < before.CleanerRuns+1 || stats.CleanerPauseLast <= 0 || stats.CleanerPauseTotal < stats.CleanerPauseLast {
+		t.Errorf("Expected cleaner pause to be recorded, got %+v", stats)
+	}
+
+	// Case 3: Waits Recorded Under Contention
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sm.SessionUpdate("sessionid123")
+		}()
+	}
+	wg.Wait()
+	stats = sm.ContentionStats()
+	if stats.LockWaitMax <= 0 || stats.LockWaitTotal < stats.LockWaitMax {
+		t.Errorf("Expected lock waits to be recorded, got %+v", stats)
+	}
+
+	// Case 4: Served by Admin Handler
+	handler := sm.AdminHandler(AdminConfig{Tokens: map[string]AdminRole{"token": AdminReadOnly}})
+	req := httptest.NewRequest("GET", "/debug/contention", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), "lock_wait_max") {
+		t.Errorf("Expected contention stats, got %v", rec.Body.String())
+	}
+}
@@ -0,0 +1,191 @@
+package session
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// How the expiry of the session cookie is computed from Cookie.Lifetime
+type CookieExpiry int
+
+const (
+	// Expire Lifetime after the cookie was last written
+	CookieRolling CookieExpiry = iota
+	// Expire Lifetime after the session was created, however often the
+	// cookie is rewritten
+	CookieFixed
+	// Expire exactly when the server will expire the session, ignoring
+	// Lifetime
+	CookieSessionExpiry
+)
+
+// Name prefix making browsers enforce constraints on a cookie
+type CookiePrefix int
+
+const (
+	CookiePrefixNone CookiePrefix = iota
+	// "__Secure-": the cookie is only accepted with Secure set
+	CookiePrefixSecure
+	// "__Host-": also only for Path "/" without Domain, so the cookie is
+	// bound to the exact host that set it
+	CookiePrefixHost
+)
+
+var ErrCookiePrefix = errors.New("__Host- cookie prefix requires Path \"/\" and no Domain")
+
+func (p CookiePrefix) String() string {
+	switch p {
+	case CookiePrefixSecure:
+		return "__Secure-"
+	case CookiePrefixHost:
+		return "__Host-"
+	default:
+		return ""
+	}
+}
+
+// Validate reports settings the browser would reject the cookies for under
+// Prefix, ErrCookiePrefix for a Path or Domain conflicting with
+// CookiePrefixHost. Call it at startup: the cookies are written with the
+// Secure, Path and Domain the prefix requires either way, so a conflicting
+// setting would be silently ignored.
+func (c SessionCookie) Validate() error {
+	if c.Prefix == CookiePrefixHost && (c.Domain != "" || (c.Path != "" && c.Path != "/")) {
+		return ErrCookiePrefix
+	}
+	return nil
+}
+
+// Name the cookie called name is sent under, with Cookie.Prefix
+func (sm *SessionManager) cookieName(name string) string {
+	return sm.Cookie.Prefix.String() + name
+}
+
+// Path of the session cookies, "/" unless Cookie.Path is set
+func (sm *SessionManager) cookiePath() string {
+	if sm.Cookie.Path != "" && sm.Cookie.Prefix != CookiePrefixHost {
+		return sm.Cookie.Path
+	}
+	return "/"
+}
+
+// Cookie of the package, with the attributes of Cookie and the constraints
+// of Cookie.Prefix applied
+func (sm *SessionManager) newCookie(name, value string) *http.Cookie {
+	c := &http.Cookie{
+		Name:     sm.cookieName(name),
+		Value:    value,
+		Path:     sm.cookiePath(),
+		Domain:   sm.Cookie.Domain,
+		HttpOnly: sm.Cookie.HTTPOnly,
+		Secure:   sm.Cookie.Secure || sm.Cookie.Prefix != CookiePrefixNone,
+	}
+	if sm.Cookie.Prefix == CookiePrefixHost {
+		c.Domain = ""
+	}
+	return c
+}
+
+// Expiry of the session cookie, zero for a browser-session cookie
+func (sm *SessionManager) cookieExpires(s *Session) time.Time {
+	if sm.Cookie.BrowserSession {
+		return time.Time{}
+	}
+
+	if sm.Cookie.Expiry == CookieSessionExpiry {
+		// An unknown session is already expired as far as the server cares
+		remaining, _ := sm.SessionExpiresIn(s.ID())
+		return time.Now().Add(remaining)
+	}
+
+	if sm.Cookie.Lifetime <= 0 {
+		return time.Time{}
+	}
+
+	switch sm.Cookie.Expiry {
+	case CookieFixed:
+		return s.Metadata().CreatedAt.Add(sm.Cookie.Lifetime)
+	default:
+		return time.Now().Add(sm.Cookie.Lifetime)
+	}
+}
+
+// Cookie value carrying sid, sealed with Config.CookieKeys if set
+func (sm *SessionManager) sealID(sid string) (string, error) {
+	value := sm.signID(sid)
+	if len(sm.Config.CookieKeys) == 0 {
+		return url.QueryEscape(value), nil
+	}
+
+	sl, err := newSealer(sm.Config.CookieKeys)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := sl.seal([]byte(value))
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Session id carried by a session cookie value. A value that fails to
+// unseal under Config.CookieKeys carries none.
+func (sm *SessionManager) cookieID(value string) (string, error) {
+	if len(value) > sm.maxSessionIDLength() {
+		return "", ErrSessionIDTooLong
+	}
+
+	if len(sm.Config.CookieKeys) == 0 {
+		value, err := url.QueryUnescape(value)
+		if err != nil {
+			return "", err
+		}
+		return sm.verifyID(value)
+	}
+
+	sl, err := newSealer(sm.Config.CookieKeys)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return "", nil
+	}
+	plain, err := sl.open(sealed)
+	if err != nil {
+		return "", nil
+	}
+	return sm.verifyID(string(plain))
+}
+
+// Write the session cookie for s to the response. Nothing is written if
+// Config.CookieKeys are not valid AES keys.
+func (sm *SessionManager) SetCookie(w http.ResponseWriter, s *Session) {
+	value, err := sm.sealID(s.ID())
+	if err != nil {
+		return
+	}
+	cookie := sm.newCookie(sm.Cookie.Name, value)
+
+	if expires := sm.cookieExpires(s); !expires.IsZero() {
+		cookie.Expires = expires
+		cookie.MaxAge = int(time.Until(expires).Seconds())
+		if cookie.MaxAge <= 0 {
+			cookie.MaxAge = -1
+		}
+	}
+
+	http.SetCookie(w, cookie)
+}
+
+// Tell the client to drop the session cookie, e.g. on logout
+func (sm *SessionManager) ClearCookie(w http.ResponseWriter) {
+	cookie := sm.newCookie(sm.Cookie.Name, "")
+	cookie.Expires = time.Unix(0, 0)
+	cookie.MaxAge = -1
+
+	http.SetCookie(w, cookie)
+}
@@ -0,0 +1,240 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_SetCookie(t *testing.T) {
+	sm := New()
+	s, _ := sm.SessionCreate("session id/123")
+
+	// Case 1: Rolling Expiry
+	rec := httptest.NewRecorder()
+	sm.SetCookie(rec, s)
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Expected 1 cookie, got %v", len(cookies))
+	}
+	c := cookies[0]
+	if c.Name != "sessionid" || c.Value != "session+id%2F123" || !c.HttpOnly || c.Path != "/" {
+		t.Errorf("Expected escaped session cookie, got %v", c)
+	}
+	if d := time.Until(c.Expires); d < 23*time.Hour || c.MaxAge < 86399 {
+		t.Errorf("Expected expiry in 24h, got %v (max-age %v)", c.Expires, c.MaxAge)
+	}
+
+	// Case 2: Fixed Expiry Counts From Creation
+	sm.Cookie.Expiry = CookieFixed
+	sm.Cookie.Lifetime = time.Hour
+	s.meta.CreatedAt = time.Now().Add(-30 * time.Minute)
+	rec = httptest.NewRecorder()
+	sm.SetCookie(rec, s)
+	c = rec.Result().Cookies()[0]
+	if d := time.Until(c.Expires); d > 31*time.Minute || d < 29*time.Minute {
+		t.Errorf("Expected expiry in 30m, got %v", d)
+	}
+
+	// Case 3: Fixed Expiry Already Passed
+	s.meta.CreatedAt = time.Now().Add(-2 * time.Hour)
+	rec = httptest.NewRecorder()
+	sm.SetCookie(rec, s)
+	if c = rec.Result().Cookies()[0]; c.MaxAge != -1 {
+		t.Errorf("Expected cookie to be expired, got max-age %v", c.MaxAge)
+	}
+
+	// Case 4: Browser-Session Cookie
+	sm.Cookie.Lifetime = 0
+	rec = httptest.NewRecorder()
+	sm.SetCookie(rec, s)
+	if c = rec.Result().Cookies()[0]; !c.Expires.IsZero() || c.MaxAge != 0 {
+		t.Errorf("Expected no expiry, got %v (max-age %v)", c.Expires, c.MaxAge)
+	}
+
+	// Case 5: Pinned to Session Expiry
+	sm.Cookie.Expiry = CookieSessionExpiry
+	sm.Config.MaxLifetime = time.Hour
+	sm.stored("session id/123").age(15 * time.Minute)
+	rec = httptest.NewRecorder()
+	sm.SetCookie(rec, s)
+	c = rec.Result().Cookies()[0]
+	if d := time.Until(c.Expires); d > 46*time.Minute || d < 44*time.Minute {
+		t.Errorf("Expected expiry in 45m, got %v", d)
+	}
+
+	// Case 6: Pinned Expiry of Unknown Session
+	sm.SessionDestroy("session id/123")
+	rec = httptest.NewRecorder()
+	sm.SetCookie(rec, s)
+	if c = rec.Result().Cookies()[0]; c.MaxAge != -1 {
+		t.Errorf("Expected cookie to be expired, got max-age %v", c.MaxAge)
+	}
+
+	// Case 7: Browser-Session Toggle Overrides Expiry
+	sm.Cookie.BrowserSession = true
+	sm.Cookie.Lifetime = time.Hour
+	s, _ = sm.SessionCreate("sessionid123")
+	rec = httptest.NewRecorder()
+	sm.SetCookie(rec, s)
+	sm.SetAffinity(rec, s)
+	for _, c := range rec.Result().Cookies() {
+		if !c.Expires.IsZero() || c.MaxAge != 0 {
+			t.Errorf("Expected no expiry on %v, got %v (max-age %v)", c.Name, c.Expires, c.MaxAge)
+		}
+	}
+
+	// Case 8: Cookies Scoped to Path
+	sm.Cookie.Path = "/app"
+	rec = httptest.NewRecorder()
+	sm.SetCookie(rec, s)
+	sm.SetAffinity(rec, s)
+	sm.ClearCookie(rec)
+	for _, c := range rec.Result().Cookies() {
+		if c.Path != "/app" {
+			t.Errorf("Expected path /app on %v, got %v", c.Name, c.Path)
+		}
+	}
+
+	// Case 9: Deprecated Config.CookieLifetime Is Copied
+	smOld := New(SessionManagerConfig{CleanerInterval: time.Minute, CookieLifetime: 2 * time.Hour})
+	if smOld.Cookie.Lifetime != 2*time.Hour {
+		t.Errorf("Expected 2h, got %v", smOld.Cookie.Lifetime)
+	}
+}
+
+func TestSessionManager_ClearCookie(t *testing.T) {
+	sm := New()
+	rec := httptest.NewRecorder()
+	sm.ClearCookie(rec)
+
+	c := rec.Result().Cookies()[0]
+	if c.Name != "sessionid" || c.Value != "" || c.MaxAge != -1 {
+		t.Errorf("Expected expired empty cookie, got %v", c)
+	}
+}
+
+func TestSessionManager_CookiePrefix(t *testing.T) {
+	sm := New()
+	sm.Config.GuestKey = []byte("secret")
+	s, _ := sm.SessionCreate("sessionid123")
+
+	// Case 1: Host Prefix Forces Its Attributes
+	sm.Cookie.Prefix = CookiePrefixHost
+	rec := httptest.NewRecorder()
+	sm.SetCookie(rec, s)
+	sm.SetAffinity(rec, s)
+	sm.GuestSession(rec, httptest.NewRequest("GET", "/", nil))
+	sm.ClearCookie(rec)
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 4 {
+		t.Fatalf("Expected 4 cookies, got %v", cookies)
+	}
+	for _, c := range cookies {
+		if !strings.HasPrefix(c.Name, "__Host-") || !c.Secure || c.Path != "/" || c.Domain != "" {
+			t.Errorf("Expected __Host- cookie, got %v", c)
+		}
+	}
+
+	// Case 2: Prefixed Cookie Read Back
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(cookies[0])
+	if got, err := sm.SessionRead(req); err != nil || got != s {
+		t.Errorf("Expected session, got %v, error: %v", got, err)
+	}
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+	if got, _ := sm.SessionRead(req); got != nil {
+		t.Errorf("Expected unprefixed cookie ignored, got %v", got)
+	}
+
+	// Case 3: Conflicting Settings Reported
+	sm.Cookie.Domain = "example.com"
+	if err := sm.Cookie.Validate(); err != ErrCookiePrefix {
+		t.Errorf("Expected ErrCookiePrefix, got %v", err)
+	}
+	sm.Cookie.Domain = ""
+	sm.Cookie.Path = "/app"
+	if err := sm.Cookie.Validate(); err != ErrCookiePrefix {
+		t.Errorf("Expected ErrCookiePrefix, got %v", err)
+	}
+
+	// Case 4: Secure Prefix Keeps Path and Domain
+	sm.Cookie.Prefix = CookiePrefixSecure
+	sm.Cookie.Domain = "example.com"
+	if err := sm.Cookie.Validate(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	rec = httptest.NewRecorder()
+	sm.SetCookie(rec, s)
+	c := rec.Result().Cookies()[0]
+	if c.Name != "__Secure-sessionid" || !c.Secure || c.Path != "/app" || c.Domain != "example.com" {
+		t.Errorf("Expected __Secure- cookie on /app, got %v", c)
+	}
+}
+
+func TestSessionManager_CookieKeys(t *testing.T) {
+	sm := New()
+	oldKey, newKey := []byte("0123456789abcdef"), []byte("fedcba9876543210")
+	sm.Config.CookieKeys = [][]byte{oldKey}
+	s, _ := sm.SessionCreate("sessionid123")
+
+	read := func(c *http.Cookie) *Session {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(c)
+		got, _ := sm.SessionRead(req)
+		return got
+	}
+
+	// Case 1: Session Id Not on the Wire
+	rec := httptest.NewRecorder()
+	sm.SetCookie(rec, s)
+	c := rec.Result().Cookies()[0]
+	if strings.Contains(c.Value, "sessionid123") {
+		t.Fatalf("Expected sealed cookie value, got %v", c.Value)
+	}
+	if got := read(c); got != s {
+		t.Errorf("Expected session, got %v", got)
+	}
+
+	// Case 2: Values Sealed Twice Differ
+	rec = httptest.NewRecorder()
+	sm.SetCookie(rec, s)
+	if again := rec.Result().Cookies()[0]; again.Value == c.Value {
+		t.Errorf("Expected a fresh nonce per cookie")
+	}
+
+	// Case 3: Tampered and Plain Values Read as Missing
+	tampered := *c
+	tampered.Value = c.Value[:len(c.Value)-2] + "AA"
+	for _, bad := range []*http.Cookie{&tampered, {Name: sm.Cookie.Name, Value: "sessionid123"}} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(bad)
+		if got, err := sm.SessionRead(req); got != nil || err != nil {
+			t.Errorf("Expected missing session for %v, got %v, error: %v", bad.Value, got, err)
+		}
+		if _, err := sm.GetSessionIdFromCookie(req); err == nil {
+			t.Errorf("Expected error for %v", bad.Value)
+		}
+	}
+
+	// Case 4: Old Key Unseals After Rotation
+	sm.Config.CookieKeys = [][]byte{newKey, oldKey}
+	if got := read(c); got != s {
+		t.Errorf("Expected session under the old key, got %v", got)
+	}
+	sm.Config.CookieKeys = [][]byte{newKey}
+	if got := read(c); got != nil {
+		t.Errorf("Expected retired key rejected, got %v", got)
+	}
+
+	// Case 5: Invalid Keys Write No Cookie
+	sm.Config.CookieKeys = [][]byte{[]byte("short")}
+	rec = httptest.NewRecorder()
+	sm.SetCookie(rec, s)
+	if len(rec.Result().Cookies()) != 0 {
+		t.Errorf("Expected no cookie, got %v", rec.Result().Cookies())
+	}
+}
@@ -0,0 +1,44 @@
+package session
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+const csrfKey = "_sm.csrf"
+
+// Form field and request header carrying the CSRF token checked by
+// LoginHandler and LogoutHandler
+const (
+	CSRFField  = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// CSRF token of the session, created on first use. Embed it in forms as
+// CSRFField, or send it as the CSRFHeader header, for the handlers checking
+// it. An empty token is returned if one can't be stored.
+func (s *Session) CSRFToken() string {
+	if token, ok := s.Get(csrfKey).(string); ok {
+		return token
+	}
+
+	token, err := randomID()
+	if err != nil || s.set(csrfKey, token) != nil {
+		return ""
+	}
+	return token
+}
+
+// Whether r carries the CSRF token of s
+func (s *Session) checkCSRF(r *http.Request) bool {
+	token, ok := s.Get(csrfKey).(string)
+	if !ok || token == "" {
+		return false
+	}
+
+	sent := r.Header.Get(CSRFHeader)
+	if sent == "" {
+		sent = r.PostFormValue(CSRFField)
+	}
+	return subtle.ConstantTimeCompare([]byte(sent), []byte(token)) == 1
+}
@@ -0,0 +1,148 @@
+package session
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+)
+
+// Number of users shown in the dashboard's top users table
+const dashboardTopUsers = 10
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{"handle": AffinityHash}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="10">
+<title>Sessions</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.Count}} live sessions</h1>
+{{with .Inspect}}
+<h2>Session {{.Info.Handle}}</h2>
+<table>
+<tr><th>User</th><td>{{.Info.UserID}}</td></tr>
+<tr><th>Created</th><td>{{.Info.CreatedAt}}</td></tr>
+<tr><th>Last accessed</th><td>{{.Info.LastAccessed}}</td></tr>
+<tr><th>Expires</th><td>{{.Info.ExpiresAt}}</td></tr>
+<tr><th>Client</th><td>{{.Info.IP}} {{.Info.UserAgent}}</td></tr>
+<tr><th>Location</th><td>{{.Info.City}} {{.Info.Country}}</td></tr>
+</table>
+<table>
+<tr><th>Key</th><th>Type</th></tr>
+{{range .Keys}}<tr><td>{{.Key}}</td><td>{{.Type}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Top users</h2>
+<table>
+<tr><th>User</th><th>Sessions</th></tr>
+{{range .TopUsers}}<tr><td>{{.User}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+<h2>Recent events</h2>
+<table>
+<tr><th>Time</th><th>Event</th><th>Session</th></tr>
+{{range .Events}}<tr><td>{{.Time}}</td><td>{{.Type}}</td><td>{{with .SessionID}}<a href="?session={{handle .}}">{{handle .}}</a>{{end}}</td></tr>
+{{end}}</table>
+<h2>Sessions</h2>
+<table>
+<tr><th>Session</th><th>User</th><th>Created</th><th>Last accessed</th><th>Client</th></tr>
+{{range .Sessions}}<tr><td><a href="?session={{.Handle}}">{{.Handle}}</a></td><td>{{.UserID}}</td><td>{{.CreatedAt}}</td><td>{{.LastAccessed}}</td><td>{{.IP}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type dashboardUser struct {
+	User  string
+	Count int
+}
+
+type dashboardKey struct {
+	Key  string
+	Type string
+}
+
+type dashboardInspect struct {
+	Info SessionInfo
+	Keys []dashboardKey
+}
+
+type dashboardData struct {
+	Count    int
+	Sessions []SessionInfo
+	TopUsers []dashboardUser
+	Events   []Event
+	Inspect  *dashboardInspect
+}
+
+func topUsers(sessions []SessionInfo, n int) []dashboardUser {
+	counts := make(map[string]int)
+	for _, s := range sessions {
+		if s.UserID != "" {
+			counts[s.UserID]++
+		}
+	}
+
+	users := make([]dashboardUser, 0, len(counts))
+	for u, c := range counts {
+		users = append(users, dashboardUser{u, c})
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Count != users[j].Count {
+			return users[i].Count > users[j].Count
+		}
+		return users[i].User < users[j].User
+	})
+	if len(users) > n {
+		users = users[:n]
+	}
+
+	return users
+}
+
+// Session details for the inspector. Only key names and value types are
+// shown so the dashboard never renders session contents.
+func (sm *SessionManager) dashboardInspect(handle string) *dashboardInspect {
+	sm.rlock()
+	defer sm.lock.RUnlock()
+
+	s := sm.resolveHandle(handle)
+	if s == nil {
+		return nil
+	}
+
+	inspect := &dashboardInspect{Info: sm.adminSession(s)}
+	s.lock.RLock()
+	for k, v := range s.sd {
+		inspect.Keys = append(inspect.Keys, dashboardKey{fmt.Sprint(k), fmt.Sprintf("%T", v)})
+	}
+	s.lock.RUnlock()
+	sort.Slice(inspect.Keys, func(i, j int) bool {
+		return inspect.Keys[i].Key < inspect.Keys[j].Key
+	})
+
+	return inspect
+}
+
+func (sm *SessionManager) serveDashboard(w http.ResponseWriter, r *http.Request) {
+	sessions := sm.adminSessions()
+	data := dashboardData{
+		Count:    len(sessions),
+		Sessions: sessions,
+		TopUsers: topUsers(sessions, dashboardTopUsers),
+		Events:   sm.RecentEvents(),
+	}
+	if handle := r.URL.Query().Get("session"); handle != "" {
+		data.Inspect = sm.dashboardInspect(handle)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	dashboardTemplate.Execute(w, data)
+}
@@ -0,0 +1,75 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSessionManager_Dashboard(t *testing.T) {
+	sm := New()
+	s1, _ := sm.SessionCreate("sessionid123")
+	s2, _ := sm.SessionCreate("sessionid456")
+	sm.SessionCreate("sessionid789")
+	s1.SetUserID("alice")
+	s2.SetUserID("alice")
+	s1.Set("cart", []string{"secret-item"})
+
+	handler := sm.AdminHandler(AdminConfig{Tokens: map[string]AdminRole{"token": AdminReadOnly}})
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer token")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Case 1: Overview
+	rec := get("/dashboard")
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "3 live sessions") {
+		t.Errorf("Expected 3 live sessions, got code %v", rec.Code)
+	}
+	if !strings.Contains(body, "<td>alice</td><td>2</td>") {
+		t.Errorf("Expected alice with 2 sessions in top users")
+	}
+	if !strings.Contains(body, "created") {
+		t.Errorf("Expected recent events")
+	}
+
+	// Case 2: Inspector Shows Keys But Not Values
+	body = get("/dashboard?session=" + AffinityHash("sessionid123")).Body.String()
+	if !strings.Contains(body, "Session "+AffinityHash("sessionid123")) || !strings.Contains(body, "<td>cart</td><td>[]string</td>") {
+		t.Errorf("Expected inspector for sessionid123")
+	}
+	if strings.Contains(body, "secret-item") {
+		t.Errorf("Expected session values to not be rendered")
+	}
+	if strings.Contains(body, "sessionid") {
+		t.Errorf("Expected session ids to not be rendered")
+	}
+
+	// Case 3: Dashboard Requires Authentication
+	req := httptest.NewRequest("GET", "/dashboard", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %v", rec.Code)
+	}
+}
+
+func TestTopUsers(t *testing.T) {
+	sessions := []SessionInfo{{UserID: "bob"}, {UserID: "alice"}, {UserID: "alice"}, {UserID: ""}, {UserID: "carol"}}
+
+	// Case 1: Sorted by Count Then Name
+	users := topUsers(sessions, 10)
+	if len(users) != 3 || users[0].User != "alice" || users[1].User != "bob" || users[2].User != "carol" {
+		t.Errorf("Expected alice, bob, carol, got %v", users)
+	}
+
+	// Case 2: Limited to n
+	if users = topUsers(sessions, 1); len(users) != 1 || users[0].Count != 2 {
+		t.Errorf("Expected [alice 2], got %v", users)
+	}
+}
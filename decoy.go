@@ -0,0 +1,49 @@
+package session
+
+import "errors"
+
+// Seed the manager with decoy session ids. A request presenting one of them is
+// never matched to a session and triggers Config.OnDecoy instead, which makes
+// planted ids a cheap tripwire for scanners and credential stuffing.
+func (sm *SessionManager) AddDecoys(sids ...string) error {
+	unlock := sm.lockSid(sids...)
+	defer unlock()
+
+	for _, sid := range sids {
+		if sid == "" {
+			return errors.New("decoy session id is empty")
+		}
+		if sm.lookup(sid) != nil {
+			return errors.New("decoy session id is in use")
+		}
+	}
+
+	sm.markLock.Lock()
+	defer sm.markLock.Unlock()
+
+	if sm.decoys == nil {
+		sm.decoys = make(map[string]struct{})
+	}
+	for _, sid := range sids {
+		sm.decoys[sid] = struct{}{}
+	}
+
+	return nil
+}
+
+func (sm *SessionManager) RemoveDecoys(sids ...string) {
+	sm.markLock.Lock()
+	defer sm.markLock.Unlock()
+
+	for _, sid := range sids {
+		delete(sm.decoys, sid)
+	}
+}
+
+func (sm *SessionManager) IsDecoy(sid string) bool {
+	sm.markLock.RLock()
+	defer sm.markLock.RUnlock()
+
+	_, ok := sm.decoys[sid]
+	return ok
+}
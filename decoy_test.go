@@ -0,0 +1,55 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionManager_AddDecoys(t *testing.T) {
+	sm := New()
+	sm.SessionCreate("sessionid123")
+
+	var tripped []string
+	sm.Config.OnDecoy = func(sid string, r *http.Request) {
+		tripped = append(tripped, sid)
+	}
+
+	// Case 1: Add Decoys
+	if err := sm.AddDecoys("decoy1", "decoy2"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if !sm.IsDecoy("decoy1") || sm.IsDecoy("sessionid123") {
+		t.Errorf("Expected only decoy1 to be a decoy")
+	}
+
+	// Case 2: Decoy Presented by Client
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "decoy1"})
+	s, err := sm.SessionRead(req)
+	if err == nil || s != nil {
+		t.Errorf("Expected error and nil session, got %v, error: %v", s, err)
+	}
+	if len(tripped) != 1 || tripped[0] != "decoy1" {
+		t.Errorf("Expected [decoy1], got %v", tripped)
+	}
+
+	// Case 3: Decoy Cannot Become a Session
+	if _, err = sm.SessionCreate("decoy2"); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	if _, err = sm.SessionRefresh("sessionid123", "decoy2"); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+
+	// Case 4: Live Session Cannot Become a Decoy
+	if err = sm.AddDecoys("sessionid123"); err == nil || sm.IsDecoy("sessionid123") {
+		t.Errorf("Expected error, got %v", err)
+	}
+
+	// Case 5: Remove Decoys
+	sm.RemoveDecoys("decoy1")
+	if sm.IsDecoy("decoy1") {
+		t.Errorf("Expected decoy1 to be removed")
+	}
+}
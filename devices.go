@@ -0,0 +1,121 @@
+package session
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Lifetime of a trusted device when Config.TrustedDeviceLifetime is not set
+const DefaultTrustedDeviceLifetime = 30 * 24 * time.Hour
+
+// A device the user has chosen to trust, e.g. to skip MFA on later sign-ins
+type TrustedDevice struct {
+	ID        string
+	TrustedAt time.Time
+	ExpiresAt time.Time
+}
+
+type deviceDict = map[string]map[string]TrustedDevice
+
+func (sm *SessionManager) trustedDeviceLifetime() time.Duration {
+	if sm.Config.TrustedDeviceLifetime > 0 {
+		return sm.Config.TrustedDeviceLifetime
+	}
+	return DefaultTrustedDeviceLifetime
+}
+
+// Mark deviceId as trusted for user. Trusting an already trusted device
+// extends its lifetime.
+func (sm *SessionManager) TrustDevice(user, deviceId string) (TrustedDevice, error) {
+	if user == "" || deviceId == "" {
+		return TrustedDevice{}, errors.New("user and device id are required")
+	}
+
+	sm.deviceLock.Lock()
+	defer sm.deviceLock.Unlock()
+
+	if sm.devices == nil {
+		sm.devices = make(deviceDict)
+	}
+	if sm.devices[user] == nil {
+		sm.devices[user] = make(map[string]TrustedDevice)
+	}
+
+	now := time.Now()
+	d := TrustedDevice{
+		ID:        deviceId,
+		TrustedAt: now,
+		ExpiresAt: now.Add(sm.trustedDeviceLifetime()),
+	}
+	sm.devices[user][deviceId] = d
+
+	return d, nil
+}
+
+func (sm *SessionManager) IsTrustedDevice(user, deviceId string) bool {
+	sm.deviceLock.RLock()
+	defer sm.deviceLock.RUnlock()
+
+	d, ok := sm.devices[user][deviceId]
+	return ok && time.Now().Before(d.ExpiresAt)
+}
+
+// Trusted devices of user that have not expired, oldest first
+func (sm *SessionManager) TrustedDevices(user string) []TrustedDevice {
+	sm.deviceLock.RLock()
+	defer sm.deviceLock.RUnlock()
+
+	now := time.Now()
+	devices := make([]TrustedDevice, 0, len(sm.devices[user]))
+	for _, d := range sm.devices[user] {
+		if now.Before(d.ExpiresAt) {
+			devices = append(devices, d)
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].TrustedAt.Before(devices[j].TrustedAt)
+	})
+
+	return devices
+}
+
+func (sm *SessionManager) RevokeTrustedDevice(user, deviceId string) error {
+	sm.deviceLock.Lock()
+	defer sm.deviceLock.Unlock()
+
+	if _, ok := sm.devices[user][deviceId]; ok {
+		delete(sm.devices[user], deviceId)
+		if len(sm.devices[user]) == 0 {
+			delete(sm.devices, user)
+		}
+		return nil
+	}
+
+	return errors.New("trusted device not found")
+}
+
+// Revoke every trusted device of user, e.g. after a password change
+func (sm *SessionManager) RevokeTrustedDevices(user string) {
+	sm.deviceLock.Lock()
+	defer sm.deviceLock.Unlock()
+
+	delete(sm.devices, user)
+}
+
+func (sm *SessionManager) cleanTrustedDevices() {
+	sm.deviceLock.Lock()
+	defer sm.deviceLock.Unlock()
+
+	now := time.Now()
+	for user, devices := range sm.devices {
+		for id, d := range devices {
+			if now.After(d.ExpiresAt) {
+				delete(devices, id)
+			}
+		}
+		if len(devices) == 0 {
+			delete(sm.devices, user)
+		}
+	}
+}
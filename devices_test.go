@@ -0,0 +1,103 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionManager_TrustDevice(t *testing.T) {
+	sm := New()
+
+	// Case 1: Trust New Device
+	d, err := sm.TrustDevice("user1", "device1")
+	if err != nil || d.ID != "device1" {
+		t.Errorf("Expected device1, got %v, error: %v", d.ID, err)
+	}
+	if got := d.ExpiresAt.Sub(d.TrustedAt); got != DefaultTrustedDeviceLifetime {
+		t.Errorf("Expected %v lifetime, got %v", DefaultTrustedDeviceLifetime, got)
+	}
+
+	// Case 2: Device Is Trusted Only For Its User
+	if !sm.IsTrustedDevice("user1", "device1") {
+		t.Errorf("Expected device1 to be trusted for user1")
+	}
+	if sm.IsTrustedDevice("user2", "device1") {
+		t.Errorf("Expected device1 to not be trusted for user2")
+	}
+
+	// Case 3: Missing User or Device
+	if _, err = sm.TrustDevice("", "device1"); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	if _, err = sm.TrustDevice("user1", ""); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+
+	// Case 4: Custom Lifetime
+	sm.Config.TrustedDeviceLifetime = time.Hour
+	d, _ = sm.TrustDevice("user1", "device2")
+	if got := d.ExpiresAt.Sub(d.TrustedAt); got != time.Hour {
+		t.Errorf("Expected 1h lifetime, got %v", got)
+	}
+}
+
+func TestSessionManager_TrustedDevices(t *testing.T) {
+	sm := New()
+	sm.TrustDevice("user1", "device1")
+	sm.TrustDevice("user1", "device2")
+	sm.TrustDevice("user2", "device3")
+
+	// Case 1: List Devices of User
+	devices := sm.TrustedDevices("user1")
+	if len(devices) != 2 || devices[0].ID != "device1" || devices[1].ID != "device2" {
+		t.Errorf("Expected [device1 device2], got %v", devices)
+	}
+
+	// Case 2: Expired Devices Are Not Listed
+	d := sm.devices["user1"]["device1"]
+	d.ExpiresAt = time.Now().Add(-time.Second)
+	sm.devices["user1"]["device1"] = d
+	devices = sm.TrustedDevices("user1")
+	if len(devices) != 1 || devices[0].ID != "device2" {
+		t.Errorf("Expected [device2], got %v", devices)
+	}
+	if sm.IsTrustedDevice("user1", "device1") {
+		t.Errorf("Expected expired device1 to not be trusted")
+	}
+
+	// Case 3: Cleaner Drops Expired Devices
+	sm.GlobalCleaner()
+	if _, ok := sm.devices["user1"]["device1"]; ok {
+		t.Errorf("Expected device1 to be cleaned up")
+	}
+
+	// Case 4: Unknown User
+	if devices = sm.TrustedDevices("user3"); len(devices) != 0 {
+		t.Errorf("Expected no devices, got %v", devices)
+	}
+}
+
+func TestSessionManager_RevokeTrustedDevice(t *testing.T) {
+	sm := New()
+	sm.TrustDevice("user1", "device1")
+	sm.TrustDevice("user1", "device2")
+
+	// Case 1: Revoke Existing Device
+	if err := sm.RevokeTrustedDevice("user1", "device1"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if sm.IsTrustedDevice("user1", "device1") {
+		t.Errorf("Expected device1 to be revoked")
+	}
+
+	// Case 2: Revoke Non-Existent Device
+	if err := sm.RevokeTrustedDevice("user1", "device1"); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+
+	// Case 3: Revoke All Devices of User
+	sm.RevokeTrustedDevices("user1")
+	if devices := sm.TrustedDevices("user1"); len(devices) != 0 {
+		t.Errorf("Expected no devices, got %v", devices)
+	}
+}
@@ -0,0 +1,98 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+)
+
+// Snapshot of the manager written by InstallDiagnostics
+type Diagnostics struct {
+	Time               time.Time       `json:"time"`
+	Sessions           int             `json:"sessions"`
+	CleanerInterval    time.Duration   `json:"cleaner_interval"`
+	MaxLifetime        time.Duration   `json:"max_lifetime"`
+	IdleTimeout        time.Duration   `json:"idle_timeout"`
+	AbsoluteLifetime   time.Duration   `json:"absolute_lifetime"`
+	CleanerLastRun     time.Time       `json:"cleaner_last_run"`
+	CleanerLastExpired int64           `json:"cleaner_last_expired"`
+	Contention         ContentionStats `json:"contention"`
+}
+
+func (sm *SessionManager) Diagnostics() Diagnostics {
+	d := Diagnostics{
+		Time:               time.Now(),
+		Sessions:           sm.SessionCount(),
+		CleanerInterval:    sm.CleanerInterval(),
+		MaxLifetime:        sm.Config.MaxLifetime,
+		IdleTimeout:        sm.idleTimeout(),
+		AbsoluteLifetime:   sm.Config.AbsoluteLifetime,
+		CleanerLastExpired: sm.cleanerLastExpired.Load(),
+		Contention:         sm.ContentionStats(),
+	}
+	if last := sm.cleanerLastRun.Load(); last != 0 {
+		d.CleanerLastRun = time.Unix(0, last)
+	}
+
+	return d
+}
+
+func (sm *SessionManager) writeDiagnostics(path string) error {
+	data, err := json.MarshalIndent(sm.Diagnostics(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write through a temporary file so readers never see a partial dump
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".diagnostics-*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Write Diagnostics as JSON to path whenever the process receives one of
+// sigs, SIGUSR1 by default where the platform has it. Useful to debug
+// session leaks in production without attaching a debugger. Call stop to
+// remove the handler.
+func (sm *SessionManager) InstallDiagnostics(path string, sigs ...os.Signal) (stop func(), err error) {
+	if len(sigs) == 0 {
+		sigs = defaultDiagnosticsSignals
+	}
+	if len(sigs) == 0 {
+		return nil, errors.New("no diagnostics signal available on this platform")
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				sm.writeDiagnostics(path)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}, nil
+}
@@ -0,0 +1,8 @@
+//go:build windows || plan9 || js || wasip1
+
+package session
+
+import "os"
+
+// No user-defined signal on this platform, callers must pass their own
+var defaultDiagnosticsSignals []os.Signal
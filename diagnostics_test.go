@@ -0,0 +1,47 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_Diagnostics(t *testing.T) {
+	sm := New()
+	sm.SessionCreate("sessionid123")
+	sm.SessionCreate("sessionid456")
+	sm.Config.MaxLifetime = time.Hour
+	sm.Config.AbsoluteLifetime = 12 * time.Hour
+	sm.stored("sessionid456").age(2 * time.Hour)
+	sm.GlobalCleaner()
+
+	// Case 1: Stats and Cleaner State
+	d := sm.Diagnostics()
+	if d.Sessions != 1 || d.CleanerLastExpired != 1 || d.MaxLifetime != time.Hour {
+		t.Errorf("Expected 1 session and 1 expired, got %+v", d)
+	}
+	if d.IdleTimeout != time.Hour || d.AbsoluteLifetime != 12*time.Hour {
+		t.Errorf("Expected the idle timeout and absolute lifetime, got %+v", d)
+	}
+	if time.Since(d.CleanerLastRun) > time.Second || d.Contention.CleanerRuns == 0 {
+		t.Errorf("Expected recent cleaner run, got %+v", d)
+	}
+
+	// Case 2: Written to File
+	path := filepath.Join(t.TempDir(), "diag.json")
+	if err := sm.writeDiagnostics(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	var written Diagnostics
+	if err := json.Unmarshal(data, &written); err != nil || written.Sessions != 1 {
+		t.Errorf("Expected 1 session in file, got %+v, error: %v", written, err)
+	}
+
+	// Case 3: Unwritable Path
+	if err := sm.writeDiagnostics(filepath.Join(t.TempDir(), "missing", "diag.json")); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
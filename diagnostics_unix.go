@@ -0,0 +1,10 @@
+//go:build !windows && !plan9 && !js && !wasip1
+
+package session
+
+import (
+	"os"
+	"syscall"
+)
+
+var defaultDiagnosticsSignals = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,36 @@
+//go:build !windows && !plan9 && !js && !wasip1
+
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_InstallDiagnostics(t *testing.T) {
+	sm := New()
+	sm.SessionCreate("sessionid123")
+	path := filepath.Join(t.TempDir(), "diag.json")
+
+	stop, err := sm.InstallDiagnostics(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer stop()
+
+	// Case 1: SIGUSR1 Writes the File
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err = os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected diagnostics file to be written")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,113 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format int
+
+const (
+	FormatText Format = iota
+	FormatJSON
+)
+
+// Placeholder written by Dump instead of the values of keys not listed in
+// Config.DumpKeys
+const Redacted = "[REDACTED]"
+
+type dumpSession struct {
+	SessionInfo
+	Values map[string]string `json:"values"`
+}
+
+// Caller must hold sm.lock
+func (sm *SessionManager) dumpSession(s *Session) dumpSession {
+	d := dumpSession{SessionInfo: sm.adminSession(s), Values: make(map[string]string)}
+	if sm.Config.DumpSessionIDs {
+		d.ID = s.sessionId
+	}
+
+	shown := make(map[string]bool, len(sm.Config.DumpKeys))
+	for _, k := range sm.Config.DumpKeys {
+		shown[k] = true
+	}
+	for _, k := range sm.Config.RedactKeys {
+		shown[k] = false
+	}
+
+	s.lock.RLock()
+	for k, v := range s.sd {
+		key := fmt.Sprint(k)
+		if shown[key] {
+			d.Values[key] = fmt.Sprint(v)
+		} else {
+			d.Values[key] = Redacted
+		}
+	}
+	s.lock.RUnlock()
+
+	return d
+}
+
+// Write every session with its values to w. Sessions are identified by their
+// AffinityHash unless Config.DumpSessionIDs is set, and only the values of
+// keys in Config.DumpKeys are written, the others are replaced with Redacted.
+// The output is meant for diagnostics and is safe to produce from a signal
+// handler.
+func (sm *SessionManager) Dump(w io.Writer, format Format) error {
+	sm.rlock()
+	sessions := make([]dumpSession, 0, sm.store.Count())
+	sm.each(func(_ string, s *Session) {
+		sessions = append(sessions, sm.dumpSession(s))
+	})
+	sm.lock.RUnlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(sessions)
+	case FormatText:
+		for _, s := range sessions {
+			if _, err := io.WriteString(w, s.text()+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return errors.New("unknown dump format")
+}
+
+func (d dumpSession) text() string {
+	var b strings.Builder
+	if d.ID != "" {
+		fmt.Fprintf(&b, "id=%s ", strconv.Quote(d.ID))
+	}
+	fmt.Fprintf(&b, "handle=%s user=%s created=%s last_accessed=%s expires=%s ip=%s",
+		strconv.Quote(d.Handle), strconv.Quote(d.UserID),
+		d.CreatedAt.Format(time.RFC3339), d.LastAccessed.Format(time.RFC3339),
+		d.ExpiresAt.Format(time.RFC3339), strconv.Quote(d.IP))
+
+	keys := make([]string, 0, len(d.Values))
+	for k := range d.Values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%s", strconv.Quote(k), strconv.Quote(d.Values[k]))
+	}
+
+	return b.String()
+}
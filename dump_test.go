@@ -0,0 +1,60 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSessionManager_Dump(t *testing.T) {
+	sm := New()
+	sm.Config.DumpKeys = []string{"theme", "password"}
+	sm.Config.RedactKeys = []string{"password"}
+	s, _ := sm.SessionCreate("sessionid123")
+	s.SetUserID("alice")
+	s.Set("theme", "dark")
+	s.Set("password", "hunter2")
+	s.Set("token", "abc")
+
+	// Case 1: Text Format
+	var buf bytes.Buffer
+	if err := sm.Dump(&buf, FormatText); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `handle="`+AffinityHash("sessionid123")+`" user="alice"`) || !strings.Contains(out, `"theme"="dark"`) {
+		t.Errorf("Expected session in text dump, got %v", out)
+	}
+	if strings.Contains(out, "sessionid123") {
+		t.Errorf("Expected session id to be redacted, got %v", out)
+	}
+	if strings.Contains(out, "hunter2") || !strings.Contains(out, `"password"="[REDACTED]"`) || !strings.Contains(out, `"token"="[REDACTED]"`) {
+		t.Errorf("Expected password and token to be redacted, got %v", out)
+	}
+
+	// Case 2: JSON Format With Session IDs
+	sm.Config.DumpSessionIDs = true
+	buf.Reset()
+	if err := sm.Dump(&buf, FormatJSON); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var sessions []dumpSession
+	if err := json.Unmarshal(buf.Bytes(), &sessions); err != nil || len(sessions) != 1 {
+		t.Fatalf("Expected 1 session, got %v, error: %v", sessions, err)
+	}
+	if sessions[0].ID != "sessionid123" || sessions[0].Values["theme"] != "dark" || sessions[0].Values["password"] != Redacted {
+		t.Errorf("Expected redacted session, got %+v", sessions[0])
+	}
+
+	// Case 3: Empty Manager
+	buf.Reset()
+	if err := New().Dump(&buf, FormatText); err != nil || buf.Len() != 0 {
+		t.Errorf("Expected empty dump, got %v, error: %v", buf.String(), err)
+	}
+
+	// Case 4: Unknown Format
+	if err := sm.Dump(&buf, Format(42)); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
@@ -0,0 +1,135 @@
+// Package dynamostore keeps sessions in an AWS DynamoDB table, for
+// deployments such as Lambda that cannot keep sessions in process memory.
+// Every item carries an expiry attribute in the format DynamoDB TTL expects,
+// so expired sessions are deleted server-side once TTL is enabled on it:
+//
+//	aws dynamodb update-time-to-live --table-name sessions \
+//	    --time-to-live-specification Enabled=true,AttributeName=expires_at
+//
+// DynamoDB deletes expired items only eventually, so the store also hides
+// items past their expiry itself.
+package dynamostore
+
+import (
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+)
+
+// Table used when Options.Table is empty
+const DefaultTable = "sessions"
+
+// Attributes of the items written
+const (
+	KeyAttribute      = "sid"           // S, the partition key
+	DataAttribute     = "data"          // B, session encoded by the codec
+	AccessedAttribute = "last_accessed" // N, Unix seconds
+	TTLAttribute      = "expires_at"    // N, Unix seconds, the TTL attribute
+)
+
+// A session item, mapped onto the attributes above by the Client
+type Item struct {
+	ID           string
+	Data         []byte
+	LastAccessed int64
+	ExpiresAt    int64
+}
+
+// Client is the subset of DynamoDB operations the store needs, implemented
+// by a thin adapter over the AWS SDK
+type Client interface {
+	// GetItem with ConsistentRead, nil if there is no item
+	GetItem(table, sid string) (*Item, error)
+
+	PutItem(table string, item Item) error
+
+	DeleteItem(table, sid string) error
+
+	// Scan every page of table, calling fn for each item until it returns
+	// false
+	Scan(table string, fn func(item Item) bool) error
+}
+
+type Options struct {
+	// Table name, DefaultTable if empty
+	Table string
+
+	// Serialization of sessions, session.GobCodec if nil
+	Codec session.Codec
+}
+
+// Backend implements session.Backend on top of a Client
+type Backend struct {
+	client Client
+	table  string
+}
+
+// Store keeping sessions in DynamoDB through client. Pass it as
+// Config.Store.
+func New(client Client, opts Options) *session.BackendStore {
+	return session.NewBackendStore(NewBackend(client, opts.Table), opts.Codec)
+}
+
+func NewBackend(client Client, table string) *Backend {
+	if table == "" {
+		table = DefaultTable
+	}
+	return &Backend{client: client, table: table}
+}
+
+func (b *Backend) Load(sid string) ([]byte, error) {
+	item, err := b.client.GetItem(b.table, sid)
+	if err != nil || item == nil || !live(*item, time.Now()) {
+		return nil, err
+	}
+	return item.Data, nil
+}
+
+// The expiry is rounded up to the second, DynamoDB TTL's resolution
+func (b *Backend) Save(r session.Record) error {
+	expires := r.ExpiresAt.Unix()
+	if r.ExpiresAt.After(time.Unix(expires, 0)) {
+		expires++
+	}
+
+	return b.client.PutItem(b.table, Item{
+		ID:           r.ID,
+		Data:         r.Data,
+		LastAccessed: r.LastAccessed.Unix(),
+		ExpiresAt:    expires,
+	})
+}
+
+func (b *Backend) Remove(sid string) error {
+	return b.client.DeleteItem(b.table, sid)
+}
+
+// Expired items DynamoDB has not deleted yet are skipped
+func (b *Backend) Scan(fn func(sid string, data []byte) bool) error {
+	now := time.Now()
+	return b.client.Scan(b.table, func(item Item) bool {
+		if !live(item, now) {
+			return true
+		}
+		return fn(item.ID, item.Data)
+	})
+}
+
+// Scans the whole table, as DynamoDB keeps no exact item count
+func (b *Backend) Len() (int, error) {
+	n := 0
+	err := b.Scan(func(string, []byte) bool {
+		n++
+		return true
+	})
+	return n, err
+}
+
+// Items expire through DynamoDB TTL
+func (b *Backend) SelfExpiring() bool {
+	return true
+}
+
+func live(item Item, now time.Time) bool {
+	return now.Unix() < item.ExpiresAt
+}
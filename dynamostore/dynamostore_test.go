@@ -0,0 +1,139 @@
+package dynamostore
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+	"github.com/vpatel95/session-manager/v2/storetest"
+)
+
+// In-memory stand-in for DynamoDB that, like TTL, never deletes on its own
+type fakeDynamo struct {
+	lock   sync.Mutex
+	tables map[string]map[string]Item
+}
+
+func newFakeDynamo() *fakeDynamo {
+	return &fakeDynamo{tables: make(map[string]map[string]Item)}
+}
+
+func (f *fakeDynamo) GetItem(table, sid string) (*Item, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if item, ok := f.tables[table][sid]; ok {
+		return &item, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeDynamo) PutItem(table string, item Item) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.tables[table] == nil {
+		f.tables[table] = make(map[string]Item)
+	}
+	f.tables[table][item.ID] = item
+	return nil
+}
+
+func (f *fakeDynamo) DeleteItem(table, sid string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.tables[table], sid)
+	return nil
+}
+
+func (f *fakeDynamo) Scan(table string, fn func(item Item) bool) error {
+	f.lock.Lock()
+	items := make([]Item, 0, len(f.tables[table]))
+	for _, item := range f.tables[table] {
+		items = append(items, item)
+	}
+	f.lock.Unlock()
+
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	for _, item := range items {
+		if !fn(item) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeDynamo) item(table, sid string) Item {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.tables[table][sid]
+}
+
+func TestStore(t *testing.T) {
+	dynamo := newFakeDynamo()
+	sm := session.New(session.SessionManagerConfig{
+		CleanerInterval: time.Minute,
+		MaxLifetime:     time.Hour,
+		Store:           New(dynamo, Options{Table: "app-sessions"}),
+	})
+	defer sm.Close()
+
+	// Case 1: Item Written With TTL Attribute
+	s, err := sm.SessionCreate("sessionid123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	s.Set("key1", "value1")
+	item := dynamo.item("app-sessions", "sessionid123")
+	if ttl := item.ExpiresAt - item.LastAccessed; ttl < 3600 || ttl > 3601 || len(item.Data) == 0 {
+		t.Errorf("Expected item expiring an hour after last access, got %+v", item)
+	}
+
+	// Case 2: Session Read Back By Another Instance
+	other := session.New(session.SessionManagerConfig{MaxLifetime: time.Hour, Store: New(dynamo, Options{Table: "app-sessions"})})
+	defer other.Close()
+	if !other.SessionExist("sessionid123") || other.SessionCount() != 1 {
+		t.Errorf("Expected the session to be read back")
+	}
+
+	// Case 3: Expired Items Hidden Until TTL Deletes Them
+	dynamo.PutItem("app-sessions", Item{ID: "sessionid456", Data: []byte("x"), ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+	if sm.SessionExist("sessionid456") || sm.SessionCount() != 1 {
+		t.Errorf("Expected expired item to read as missing")
+	}
+
+	// Case 4: Destroy Deletes The Item
+	sm.SessionDestroy("sessionid123")
+	if item, _ := dynamo.GetItem("app-sessions", "sessionid123"); item != nil {
+		t.Errorf("Expected item to be deleted")
+	}
+}
+
+func TestBackend_Save(t *testing.T) {
+	dynamo := newFakeDynamo()
+	b := NewBackend(dynamo, "")
+
+	// Case 1: Expiry Rounded Up To The Second
+	expires := time.Unix(1700000000, 1)
+	b.Save(session.Record{ID: "a", Data: []byte("a"), ExpiresAt: expires})
+	if got := dynamo.item(DefaultTable, "a").ExpiresAt; got != 1700000001 {
+		t.Errorf("Expected 1700000001, got %v", got)
+	}
+
+	// Case 2: Whole Seconds Kept
+	b.Save(session.Record{ID: "b", Data: []byte("b"), ExpiresAt: time.Unix(1700000000, 0)})
+	if got := dynamo.item(DefaultTable, "b").ExpiresAt; got != 1700000000 {
+		t.Errorf("Expected 1700000000, got %v", got)
+	}
+}
+
+func TestEquivalence(t *testing.T) {
+	// Case 1: Same Behavior As MemoryStore
+	for seed := int64(1); seed <= 5; seed++ {
+		storetest.Equivalent(t, New(newFakeDynamo(), Options{Table: "app-sessions"}), seed, 200)
+	}
+}
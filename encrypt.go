@@ -0,0 +1,133 @@
+package session
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+)
+
+const encryptedVersion = 1
+
+// Length of the key id in front of encrypted data
+const keyIdLen = 4
+
+// EncryptedCodec encrypts what another Codec encodes with AES-GCM, so the
+// store only ever holds ciphertext. Use it as the Codec of a BackendStore or
+// of any store package.
+//
+// Data is encrypted with the first key and decrypted with whichever key it
+// was encrypted with. To rotate keys put the new key first: sessions are
+// encrypted with it on their next write, and the old key can be dropped
+// once MaxLifetime has passed.
+type EncryptedCodec struct {
+	codec Codec
+	*sealer
+}
+
+// AES-GCM with the first of a list of keys, opening data sealed with any of
+// them
+type sealer struct {
+	keys []encryptionKey
+}
+
+type encryptionKey struct {
+	id   [keyIdLen]byte
+	aead cipher.AEAD
+}
+
+// Codec encrypting the output of codec, GobCodec if nil, with keys of 16, 24
+// or 32 bytes for AES-128, AES-192 or AES-256
+func NewEncryptedCodec(codec Codec, keys ...[]byte) (*EncryptedCodec, error) {
+	if codec == nil {
+		codec = GobCodec{}
+	}
+
+	sl, err := newSealer(keys)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedCodec{codec: codec, sealer: sl}, nil
+}
+
+func newSealer(keys [][]byte) (*sealer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key is required")
+	}
+
+	sl := &sealer{}
+	for _, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		k := encryptionKey{aead: aead}
+		sum := sha256.Sum256(key)
+		copy(k.id[:], sum[:])
+		for _, other := range sl.keys {
+			if other.id == k.id {
+				return nil, errors.New("duplicate key")
+			}
+		}
+		sl.keys = append(sl.keys, k)
+	}
+
+	return sl, nil
+}
+
+func (ec *EncryptedCodec) Encode(snap Snapshot) ([]byte, error) {
+	plain, err := ec.codec.Encode(snap)
+	if err != nil {
+		return nil, err
+	}
+	return ec.seal(plain)
+}
+
+func (ec *EncryptedCodec) Decode(data []byte) (Snapshot, error) {
+	plain, err := ec.open(data)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return ec.codec.Decode(plain)
+}
+
+// Version, key id and nonce, followed by plain sealed with the first key.
+// The header is authenticated along with it.
+func (sl *sealer) seal(plain []byte) ([]byte, error) {
+	k := sl.keys[0]
+	header := make([]byte, 1+keyIdLen+k.aead.NonceSize())
+	header[0] = encryptedVersion
+	copy(header[1:], k.id[:])
+	if _, err := rand.Read(header[1+keyIdLen:]); err != nil {
+		return nil, err
+	}
+
+	return k.aead.Seal(header, header[1+keyIdLen:], plain, header[:1+keyIdLen]), nil
+}
+
+func (sl *sealer) open(data []byte) ([]byte, error) {
+	if len(data) < 1+keyIdLen || data[0] != encryptedVersion {
+		return nil, errors.New("not encrypted data")
+	}
+
+	for _, k := range sl.keys {
+		if !bytes.Equal(data[1:1+keyIdLen], k.id[:]) {
+			continue
+		}
+
+		n := 1 + keyIdLen + k.aead.NonceSize()
+		if len(data) < n {
+			return nil, errors.New("not encrypted data")
+		}
+		return k.aead.Open(nil, data[1+keyIdLen:n], data[n:], data[:1+keyIdLen])
+	}
+
+	return nil, errors.New("unknown encryption key")
+}
@@ -0,0 +1,99 @@
+package session
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestEncryptedCodec(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 32)
+	snap := Snapshot{ID: "sessionid123", UserID: "user1", LastAccessed: time.Now(), Values: map[interface{}]interface{}{"card": "4111111111111111"}}
+
+	// Case 1: Invalid Keys
+	if _, err := NewEncryptedCodec(nil); err == nil {
+		t.Errorf("Expected error without keys")
+	}
+	if _, err := NewEncryptedCodec(nil, []byte("short")); err == nil {
+		t.Errorf("Expected error for a short key")
+	}
+	if _, err := NewEncryptedCodec(nil, oldKey, oldKey); err == nil {
+		t.Errorf("Expected error for a duplicate key")
+	}
+
+	// Case 2: Round Trip Without Plaintext
+	old, _ := NewEncryptedCodec(nil, oldKey)
+	data, err := old.Encode(snap)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if bytes.Contains(data, []byte("4111111111111111")) || bytes.Contains(data, []byte("user1")) {
+		t.Errorf("Expected no plaintext in the encoding")
+	}
+	if got, err := old.Decode(data); err != nil || got.Values["card"] != "4111111111111111" {
+		t.Errorf("Expected decoded snapshot, got %+v, %v", got, err)
+	}
+
+	// Case 3: Rotated Key Still Decrypts Old Data
+	rotated, _ := NewEncryptedCodec(nil, newKey, oldKey)
+	if got, err := rotated.Decode(data); err != nil || got.UserID != "user1" {
+		t.Errorf("Expected old data decrypted, got %+v, %v", got, err)
+	}
+	fresh, _ := rotated.Encode(snap)
+	if _, err := old.Decode(fresh); err == nil {
+		t.Errorf("Expected new data encrypted with the new key")
+	}
+
+	// Case 4: Dropped Key
+	dropped, _ := NewEncryptedCodec(nil, newKey)
+	if _, err := dropped.Decode(data); err == nil {
+		t.Errorf("Expected error for data of a dropped key")
+	}
+
+	// Case 5: Tampered Data
+	data[len(data)-1] ^= 1
+	if _, err := old.Decode(data); err == nil {
+		t.Errorf("Expected error for tampered data")
+	}
+	if _, err := old.Decode([]byte("x")); err == nil {
+		t.Errorf("Expected error for short data")
+	}
+
+	// Case 6: Used By A Store
+	b := newMapBackend()
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: NewBackendStore(b, rotated)})
+	defer sm.Close()
+	s, _ := sm.SessionCreate("sessionid123")
+	s.Set("card", "4111111111111111")
+	if bytes.Contains(b.data["sessionid123"], []byte("4111111111111111")) {
+		t.Errorf("Expected the backend to hold ciphertext")
+	}
+	if got, ok := sm.session("sessionid123"); !ok || got.Get("card") != "4111111111111111" {
+		t.Errorf("Expected session decrypted, got %v", got)
+	}
+}
+
+func FuzzEncryptedCodec_Decode(f *testing.F) {
+	ec, _ := NewEncryptedCodec(nil, make([]byte, 32))
+	seed, _ := ec.Encode(Snapshot{ID: "sessionid123", LastAccessed: time.Now()})
+	f.Add(seed)
+	f.Add([]byte{encryptedVersion})
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Case 1: Malformed Data Never Panics
+		if _, err := ec.Decode(data); err != nil {
+			return
+		}
+
+		// Case 2: Any Altered Byte Rejected
+		for i := range data {
+			altered := append([]byte(nil), data...)
+			altered[i] ^= 0x80
+			if _, err := ec.Decode(altered); err == nil {
+				t.Errorf("Expected data altered at %v to be rejected", i)
+			}
+		}
+	})
+}
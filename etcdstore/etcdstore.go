@@ -0,0 +1,101 @@
+// Package etcdstore keeps sessions in etcd, so every pod of a Kubernetes
+// deployment shares them. Each key is written with a lease of the time left
+// before the session expires, so etcd deletes expired sessions itself. A
+// watch on the prefix reports sessions destroyed by another pod or expired
+// to every manager at once, as EventRemoved.
+package etcdstore
+
+import (
+	"strings"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+)
+
+// Key prefix used when Options.Prefix is empty
+const DefaultPrefix = "/sessions/"
+
+// Client is the subset of etcd operations the store needs, implemented by a
+// thin adapter over the etcd client
+type Client interface {
+	// Value of key, nil if it does not exist
+	Get(key string) ([]byte, error)
+
+	// Put key attached to a lease of ttl, e.g. granted with Lease.Grant, so
+	// etcd deletes it once the lease expires
+	Put(key string, value []byte, ttl time.Duration) error
+
+	Delete(key string) error
+
+	// Get with WithPrefix, calling fn for every key until it returns false
+	List(prefix string, fn func(key string, value []byte) bool) error
+
+	// Watch with WithPrefix, calling fn for every delete event, lease
+	// expiries included, until stop is called
+	WatchDeletes(prefix string, fn func(key string)) (stop func(), err error)
+}
+
+type Options struct {
+	// Prepended to session ids to build keys, DefaultPrefix if empty
+	Prefix string
+
+	// Serialization of sessions, session.GobCodec if nil
+	Codec session.Codec
+}
+
+// Backend implements session.Backend on top of a Client
+type Backend struct {
+	client Client
+	prefix string
+}
+
+// Store keeping sessions in etcd through client. Pass it as Config.Store.
+func New(client Client, opts Options) *session.BackendStore {
+	return session.NewBackendStore(NewBackend(client, opts.Prefix), opts.Codec)
+}
+
+func NewBackend(client Client, prefix string) *Backend {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &Backend{client: client, prefix: prefix}
+}
+
+func (b *Backend) Load(sid string) ([]byte, error) {
+	return b.client.Get(b.prefix + sid)
+}
+
+func (b *Backend) Save(r session.Record) error {
+	return b.client.Put(b.prefix+r.ID, r.Data, time.Until(r.ExpiresAt))
+}
+
+func (b *Backend) Remove(sid string) error {
+	return b.client.Delete(b.prefix + sid)
+}
+
+func (b *Backend) Scan(fn func(sid string, data []byte) bool) error {
+	return b.client.List(b.prefix, func(key string, value []byte) bool {
+		return fn(strings.TrimPrefix(key, b.prefix), value)
+	})
+}
+
+func (b *Backend) Len() (int, error) {
+	n := 0
+	err := b.client.List(b.prefix, func(string, []byte) bool {
+		n++
+		return true
+	})
+	return n, err
+}
+
+// Keys expire with their lease
+func (b *Backend) SelfExpiring() bool {
+	return true
+}
+
+// Report sessions deleted from etcd, by any pod or through their lease
+func (b *Backend) Watch(fn func(sid string)) (func(), error) {
+	return b.client.WatchDeletes(b.prefix, func(key string) {
+		fn(strings.TrimPrefix(key, b.prefix))
+	})
+}
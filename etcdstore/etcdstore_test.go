@@ -0,0 +1,186 @@
+package etcdstore
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+	"github.com/vpatel95/session-manager/v2/storetest"
+)
+
+// In-memory stand-in for etcd recording lease TTLs and fanning out deletes
+// to watchers synchronously
+type fakeEtcd struct {
+	lock     sync.Mutex
+	data     map[string][]byte
+	ttls     map[string]time.Duration
+	watchers map[int]func(key string)
+	next     int
+}
+
+func newFakeEtcd() *fakeEtcd {
+	return &fakeEtcd{data: make(map[string][]byte), ttls: make(map[string]time.Duration), watchers: make(map[int]func(string))}
+}
+
+func (f *fakeEtcd) Get(key string) ([]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.data[key], nil
+}
+
+func (f *fakeEtcd) Put(key string, value []byte, ttl time.Duration) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.data[key] = value
+	f.ttls[key] = ttl
+	return nil
+}
+
+func (f *fakeEtcd) Delete(key string) error {
+	f.lock.Lock()
+	_, ok := f.data[key]
+	delete(f.data, key)
+	delete(f.ttls, key)
+	var watchers []func(string)
+	if ok {
+		for _, w := range f.watchers {
+			watchers = append(watchers, w)
+		}
+	}
+	f.lock.Unlock()
+
+	for _, w := range watchers {
+		w(key)
+	}
+	return nil
+}
+
+func (f *fakeEtcd) List(prefix string, fn func(key string, value []byte) bool) error {
+	f.lock.Lock()
+	var keys []string
+	for key := range f.data {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = f.data[key]
+	}
+	f.lock.Unlock()
+
+	for i, key := range keys {
+		if !fn(key, values[i]) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeEtcd) WatchDeletes(prefix string, fn func(key string)) (func(), error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	id := f.next
+	f.next++
+	f.watchers[id] = func(key string) {
+		if strings.HasPrefix(key, prefix) {
+			fn(key)
+		}
+	}
+	return func() {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		delete(f.watchers, id)
+	}, nil
+}
+
+func (f *fakeEtcd) ttl(key string) time.Duration {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.ttls[key]
+}
+
+func TestStore(t *testing.T) {
+	etcd := newFakeEtcd()
+	var lock sync.Mutex
+	removed := make(map[string][]string)
+	newManager := func(name string) *session.SessionManager {
+		return session.New(session.SessionManagerConfig{
+			CleanerInterval: time.Minute,
+			MaxLifetime:     time.Hour,
+			Store:           New(etcd, Options{}),
+			OnEvent: func(e session.Event) {
+				if e.Type == session.EventRemoved {
+					lock.Lock()
+					removed[name] = append(removed[name], e.SessionID)
+					lock.Unlock()
+				}
+			},
+		})
+	}
+	a, b := newManager("a"), newManager("b")
+	defer a.Close()
+	defer b.Close()
+
+	// Case 1: Key Written With A Lease
+	s, err := a.SessionCreate("sessionid123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	s.Set("key1", "value1")
+	if ttl := etcd.ttl(DefaultPrefix + "sessionid123"); ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Errorf("Expected lease of about an hour, got %v", ttl)
+	}
+
+	// Case 2: Shared By Every Pod
+	if !b.SessionExist("sessionid123") || b.SessionCount() != 1 {
+		t.Errorf("Expected the session on the other pod")
+	}
+
+	// Case 3: Destroy Reported To The Other Pods Only
+	if err := b.SessionDestroy("sessionid123"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	lock.Lock()
+	if len(removed["a"]) != 1 || removed["a"][0] != "sessionid123" || len(removed["b"]) != 0 {
+		t.Errorf("Expected sessionid123 reported to a only, got %v", removed)
+	}
+	lock.Unlock()
+	if a.SessionExist("sessionid123") {
+		t.Errorf("Expected sessionid123 not to exist")
+	}
+
+	// Case 4: Lease Expiry Reported To Every Pod
+	a.SessionCreate("sessionid456")
+	etcd.Delete(DefaultPrefix + "sessionid456")
+	lock.Lock()
+	if len(removed["a"]) != 2 || len(removed["b"]) != 1 {
+		t.Errorf("Expected the expiry reported to both pods, got %v", removed)
+	}
+	lock.Unlock()
+
+	// Case 5: Watch Stopped On Close
+	a.Close()
+	b.Close()
+	etcd.lock.Lock()
+	watchers := len(etcd.watchers)
+	etcd.lock.Unlock()
+	if watchers != 0 {
+		t.Errorf("Expected no watchers, got %v", watchers)
+	}
+}
+
+func TestEquivalence(t *testing.T) {
+	// Case 1: Same Behavior As MemoryStore
+	for seed := int64(1); seed <= 5; seed++ {
+		storetest.Equivalent(t, New(newFakeEtcd(), Options{}), seed, 200)
+	}
+}
@@ -0,0 +1,61 @@
+package session
+
+import "time"
+
+// Number of events kept for RecentEvents
+const recentEventsSize = 100
+
+type EventType string
+
+const (
+	EventCreated   EventType = "created"
+	EventRefreshed EventType = "refreshed"
+	EventDestroyed EventType = "destroyed"
+	EventExpired   EventType = "expired"
+	EventAdopted   EventType = "adopted" // fetched from a peer, see Config.Peers
+	EventRemoved   EventType = "removed" // removed from the store by another instance, see Watcher
+)
+
+// A session lifecycle event. PreviousID is set for EventRefreshed, RequestID
+// to the request that last used the session through Middleware. Info
+// describes the session as of the event, except for EventRemoved, where
+// only the id is known.
+type Event struct {
+	Type       EventType    `json:"type"`
+	SessionID  string       `json:"session_id"`
+	PreviousID string       `json:"previous_id,omitempty"`
+	RequestID  string       `json:"request_id,omitempty"`
+	Time       time.Time    `json:"time"`
+	Info       *SessionInfo `json:"info,omitempty"`
+}
+
+// Record e and pass it to Config.OnEvent. Must not be called with sm.lock held.
+func (sm *SessionManager) emit(e Event) {
+	e.Time = time.Now()
+
+	sm.eventLock.Lock()
+	if len(sm.events) < recentEventsSize {
+		sm.events = append(sm.events, e)
+	} else {
+		sm.events[sm.eventPos] = e
+	}
+	sm.eventPos = (sm.eventPos + 1) % recentEventsSize
+	sm.eventLock.Unlock()
+
+	if sm.Config.OnEvent != nil {
+		sm.Config.OnEvent(e)
+	}
+}
+
+// Most recent session events, newest first
+func (sm *SessionManager) RecentEvents() []Event {
+	sm.eventLock.Lock()
+	defer sm.eventLock.Unlock()
+
+	events := make([]Event, 0, len(sm.events))
+	for i := 1; i <= len(sm.events); i++ {
+		events = append(events, sm.events[(sm.eventPos-i+len(sm.events))%len(sm.events)])
+	}
+
+	return events
+}
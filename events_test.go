@@ -0,0 +1,58 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_RecentEvents(t *testing.T) {
+	sm := New()
+	var hooked []Event
+	sm.Config.OnEvent = func(e Event) {
+		hooked = append(hooked, e)
+	}
+
+	// Case 1: Lifecycle Events Recorded Newest First
+	sm.SessionCreate("sessionid123")
+	sm.SessionRefresh("sessionid123", "sessionid456")
+	sm.SessionDestroy("sessionid456")
+
+	events := sm.RecentEvents()
+	if len(events) != 3 || events[0].Type != EventDestroyed || events[1].Type != EventRefreshed || events[2].Type != EventCreated {
+		t.Fatalf("Expected destroyed, refreshed, created, got %v", events)
+	}
+	if events[1].SessionID != "sessionid456" || events[1].PreviousID != "sessionid123" {
+		t.Errorf("Expected refresh from sessionid123 to sessionid456, got %v", events[1])
+	}
+	if len(hooked) != 3 {
+		t.Errorf("Expected 3 hooked events, got %v", hooked)
+	}
+
+	// Case 2: Expiry Recorded
+	sm.Config.MaxLifetime = time.Hour
+	sm.SessionCreate("sessionid789")
+	sm.stored("sessionid789").age(2 * time.Hour)
+	sm.GlobalCleaner()
+	if events = sm.RecentEvents(); events[0].Type != EventExpired || events[0].SessionID != "sessionid789" {
+		t.Errorf("Expected expired sessionid789, got %v", events[0])
+	}
+
+	// Case 3: Failed Operations Are Not Recorded
+	sm.SessionDestroy("nonexistent")
+	if events = sm.RecentEvents(); len(events) != 5 {
+		t.Errorf("Expected 5 events, got %v", len(events))
+	}
+
+	// Case 4: Buffer Is Bounded
+	for i := 0; i < recentEventsSize+10; i++ {
+		sm.SessionCreate(fmt.Sprintf("session%d", i))
+	}
+	events = sm.RecentEvents()
+	if len(events) != recentEventsSize {
+		t.Errorf("Expected %v events, got %v", recentEventsSize, len(events))
+	}
+	if want := fmt.Sprintf("session%d", recentEventsSize+9); events[0].SessionID != want {
+		t.Errorf("Expected %v, got %v", want, events[0].SessionID)
+	}
+}
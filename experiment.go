@@ -0,0 +1,53 @@
+package session
+
+import "hash/fnv"
+
+// Prefix of the keys holding experiment variants, followed by the
+// experiment name
+const bucketKeyPrefix = "_sm.bucket."
+
+// Variant of experiment for this session. The variant is derived from the
+// guest id, or the session id for sessions that did not start as a guest, so
+// it is stable even before it is stored, and persisted in the session on
+// first use. Guest sessions only derive it, so bucketing doesn't promote
+// them, and keep it once promoted. Config.OnBucket is called when a variant
+// is first stored, e.g. to export it to analytics. A stored variant that is
+// no longer among variants is reassigned. The variant is returned even if
+// storing it failed, e.g. with ErrFrozen.
+func (s *Session) Bucket(experiment string, variants ...string) (string, error) {
+	if len(variants) == 0 {
+		return "", nil
+	}
+
+	key := bucketKeyPrefix + experiment
+	if v, ok := s.Get(key).(string); ok {
+		for _, variant := range variants {
+			if v == variant {
+				return v, nil
+			}
+		}
+	}
+
+	s.lock.RLock()
+	id := s.guestId
+	if id == "" {
+		id = s.sessionId
+	}
+	s.lock.RUnlock()
+
+	h := fnv.New32a()
+	h.Write([]byte(experiment + "\x00" + id))
+	variant := variants[h.Sum32()%uint32(len(variants))]
+
+	if s.IsGuest() {
+		return variant, nil
+	}
+	if err := s.set(key, variant); err != nil {
+		return variant, err
+	}
+	if s.manager != nil && s.manager.Config.OnBucket != nil {
+		s.manager.Config.OnBucket(s, experiment, variant)
+	}
+
+	return variant, nil
+}
@@ -0,0 +1,82 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSession_Bucket(t *testing.T) {
+	var assigned []string
+	sm := New()
+	sm.Config.OnBucket = func(s *Session, experiment, variant string) {
+		assigned = append(assigned, experiment+"="+variant)
+	}
+	s, _ := sm.SessionCreate("sessionid123")
+
+	// Case 1: Assigned Once and Persisted
+	v, err := s.Bucket("checkout", "control", "one-page")
+	if err != nil || (v != "control" && v != "one-page") {
+		t.Fatalf("Expected a variant, got %v, %v", v, err)
+	}
+	if again, _ := s.Bucket("checkout", "control", "one-page"); again != v || len(assigned) != 1 {
+		t.Errorf("Expected %v to be reported once, got %v (%v)", v, again, assigned)
+	}
+	if s.Get(bucketKeyPrefix+"checkout") != v {
+		t.Errorf("Expected the variant to be stored in the session")
+	}
+
+	// Case 2: Deterministic Per Session
+	other, _ := New().SessionCreate("sessionid123")
+	if got, _ := other.Bucket("checkout", "control", "one-page"); got != v {
+		t.Errorf("Expected the same variant for the same session id, got %v", got)
+	}
+
+	// Case 3: Spread Across Variants
+	seen := map[string]bool{}
+	for _, sid := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		s, _ := sm.SessionCreate(sid)
+		variant, _ := s.Bucket("spread", "x", "y")
+		seen[variant] = true
+	}
+	if len(seen) != 2 {
+		t.Errorf("Expected both variants to be used, got %v", seen)
+	}
+
+	// Case 4: Removed Variant Is Reassigned
+	s.set(bucketKeyPrefix+"checkout", "retired")
+	if got, _ := s.Bucket("checkout", "control", "one-page"); got != v {
+		t.Errorf("Expected reassignment to %v, got %v", v, got)
+	}
+
+	// Case 5: No Variants
+	if got, _ := s.Bucket("empty"); got != "" {
+		t.Errorf("Expected no variant, got %v", got)
+	}
+
+	// Case 6: Guests Bucketed Without Promotion
+	sm.Config.GuestKey = []byte("secret")
+	guest, _ := sm.GuestSession(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	count, reported := sm.SessionCount(), len(assigned)
+	gv, err := guest.Bucket("checkout", "control", "one-page")
+	if err != nil || !guest.IsGuest() || sm.SessionCount() != count || len(assigned) != reported {
+		t.Errorf("Expected the guest session kept, got %v, %v", guest.IsGuest(), err)
+	}
+
+	// Case 7: Same Variant Once Promoted
+	guest.Set("cart", 1)
+	if got, _ := guest.Bucket("checkout", "control", "one-page"); got != gv || guest.Get(bucketKeyPrefix+"checkout") != gv {
+		t.Errorf("Expected %v kept after promotion, got %v", gv, got)
+	}
+
+	// Case 8: Store Errors Returned
+	fresh, _ := sm.SessionCreate("sessionid456")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sm.Freeze(ctx)
+	reported = len(assigned)
+	if got, err := fresh.Bucket("checkout", "control", "one-page"); got == "" || !errors.Is(err, ErrFrozen) || len(assigned) != reported {
+		t.Errorf("Expected the variant with ErrFrozen and no report, got %v, %v", got, err)
+	}
+}
@@ -0,0 +1,62 @@
+package session
+
+import "time"
+
+// Lifetime of cached feature flags when Config.FlagTTL is not set
+const DefaultFlagTTL = time.Minute
+
+type flagEntry struct {
+	value      interface{}
+	expires    time.Time
+	generation uint64
+}
+
+func (sm *SessionManager) flagTTL() time.Duration {
+	if sm != nil && sm.Config.FlagTTL > 0 {
+		return sm.Config.FlagTTL
+	}
+	return DefaultFlagTTL
+}
+
+func (sm *SessionManager) flagGeneration() uint64 {
+	if sm == nil {
+		return 0
+	}
+	return sm.flagGen.Load()
+}
+
+// Value of feature flag name for this session. The value is cached in the
+// session for Config.FlagTTL, eval is only called to fill the cache. Errors
+// of eval are returned and not cached.
+func (s *Session) Flag(name string, eval func() (interface{}, error)) (interface{}, error) {
+	now := time.Now()
+	generation := s.manager.flagGeneration()
+
+	s.lock.RLock()
+	e, ok := s.flags[name]
+	s.lock.RUnlock()
+	if ok && e.generation == generation && now.Before(e.expires) {
+		return e.value, nil
+	}
+
+	// Evaluated outside the lock, the flag service may be slow
+	v, err := eval()
+	if err != nil {
+		return nil, err
+	}
+
+	s.lock.Lock()
+	if s.flags == nil {
+		s.flags = make(map[string]flagEntry)
+	}
+	s.flags[name] = flagEntry{value: v, expires: now.Add(s.manager.flagTTL()), generation: generation}
+	s.lock.Unlock()
+
+	return v, nil
+}
+
+// Drop the cached feature flags of every session, e.g. after the flag
+// configuration changed
+func (sm *SessionManager) InvalidateFlags() {
+	sm.flagGen.Add(1)
+}
@@ -0,0 +1,59 @@
+package session
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSession_Flag(t *testing.T) {
+	sm := New()
+	s, _ := sm.SessionCreate("sessionid123")
+
+	calls := 0
+	eval := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+
+	// Case 1: Evaluated Once Then Cached
+	v1, _ := s.Flag("new-checkout", eval)
+	v2, _ := s.Flag("new-checkout", eval)
+	if v1 != 1 || v2 != 1 || calls != 1 {
+		t.Errorf("Expected a single evaluation, got %v, %v after %v calls", v1, v2, calls)
+	}
+
+	// Case 2: Flags Cached Separately
+	if v, _ := s.Flag("dark-mode", eval); v != 2 {
+		t.Errorf("Expected dark-mode to be evaluated, got %v", v)
+	}
+
+	// Case 3: Invalidation
+	sm.InvalidateFlags()
+	if v, _ := s.Flag("new-checkout", eval); v != 3 {
+		t.Errorf("Expected re-evaluation after InvalidateFlags, got %v", v)
+	}
+
+	// Case 4: TTL
+	sm.Config.FlagTTL = time.Millisecond
+	s.Flag("ttl", eval)
+	time.Sleep(2 * time.Millisecond)
+	if v, _ := s.Flag("ttl", eval); v != 5 {
+		t.Errorf("Expected re-evaluation after the TTL, got %v", v)
+	}
+
+	// Case 5: Errors Are Not Cached
+	failing := func() (interface{}, error) { return nil, errors.New("flag service down") }
+	if _, err := s.Flag("broken", failing); err == nil {
+		t.Errorf("Expected the eval error")
+	}
+	if v, _ := s.Flag("broken", eval); v != 6 {
+		t.Errorf("Expected re-evaluation after an error, got %v", v)
+	}
+
+	// Case 6: Session Without Manager
+	bare := &Session{sd: make(dict)}
+	if v, err := bare.Flag("new-checkout", eval); err != nil || v != 7 {
+		t.Errorf("Expected evaluation without a manager, got %v, error: %v", v, err)
+	}
+}
@@ -0,0 +1,34 @@
+package session
+
+import (
+	"context"
+	"errors"
+)
+
+// Returned by writes while the manager is frozen, see Freeze
+var ErrFrozen = errors.New("sessions are read-only during maintenance")
+
+// Make all sessions read-only until ctx is done, e.g. for a storage
+// migration time-boxed with context.WithTimeout. Meanwhile writes through a
+// session and creating, refreshing, touching or destroying sessions return
+// ErrFrozen, and the cleaner skips its runs. Reads keep working. Freezing
+// again while frozen keeps the manager frozen until every ctx is done.
+func (sm *SessionManager) Freeze(ctx context.Context) {
+	sm.freezes.Add(1)
+	go func() {
+		<-ctx.Done()
+		sm.freezes.Add(-1)
+	}()
+}
+
+func (sm *SessionManager) Frozen() bool {
+	return sm.freezes.Load() > 0
+}
+
+// ErrFrozen if writes to s are refused
+func (s *Session) frozen() error {
+	if s.manager != nil && s.manager.Frozen() {
+		return ErrFrozen
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_Freeze(t *testing.T) {
+	sm := New()
+	sm.Config.ValueClasses = map[string]time.Duration{"auth": time.Hour}
+	s, _ := sm.SessionCreate("sessionid123")
+	s.Set("key1", "value1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sm.Freeze(ctx)
+
+	// Case 1: Writes Refused
+	if err := s.Set("key1", "value2"); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+	if err := s.SetIn("auth", "user_id", "user1"); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+	if err := s.SetLocale("de-DE"); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+	if err := s.Delete("key1"); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+	if err := s.Update(func(tx Tx) error { return tx.Set("key2", 1) }); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+	s.SetUserID("user1")
+	if s.UserID() != "" || s.Get("key1") != "value1" || s.Exist("user_id") {
+		t.Errorf("Expected the session unchanged")
+	}
+
+	// Case 2: Lifecycle Refused
+	if _, err := sm.SessionCreate("sessionid456"); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+	if _, err := sm.SessionRefresh("sessionid123", "sessionid789"); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+	if err := sm.SessionDestroy("sessionid123"); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+	if err := sm.SessionUpdate("sessionid123"); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+
+	// Case 3: Cleaner Paused
+	s.age(48 * time.Hour)
+	sm.GlobalCleaner()
+	if !sm.SessionExist("sessionid123") {
+		t.Errorf("Expected the cleaner to skip its run")
+	}
+	s.age(0)
+
+	// Case 4: Resumed Once Every Window Ends
+	other, stop := context.WithCancel(context.Background())
+	sm.Freeze(other)
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	if !sm.Frozen() {
+		t.Errorf("Expected the manager to stay frozen")
+	}
+	stop()
+	deadline := time.Now().Add(time.Second)
+	for sm.Frozen() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := s.Set("key1", "value2"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
@@ -1,3 +1,3 @@
-module github.com/vpatel95/session-manager
+module github.com/vpatel95/session-manager/v2
 
 go 1.20
@@ -0,0 +1,157 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cookie carrying the guest token when Config.GuestCookie is not set
+const DefaultGuestCookie = "guest"
+
+func (sm *SessionManager) guestCookie() string {
+	if sm.Config.GuestCookie != "" {
+		return sm.Config.GuestCookie
+	}
+	return DefaultGuestCookie
+}
+
+func (sm *SessionManager) guestSignature(payload string) []byte {
+	mac := hmac.New(sha256.New, sm.Config.GuestKey)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// Token of the form <id>.<issued>.<signature>
+func (sm *SessionManager) issueGuestToken(id string, issued time.Time) string {
+	payload := id + "." + strconv.FormatInt(issued.Unix(), 10)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(sm.guestSignature(payload))
+}
+
+// How long guest tokens stay valid: the idle timeout, capped by
+// Config.AbsoluteLifetime like the lifetime of a session
+func (sm *SessionManager) guestLifetime() time.Duration {
+	now := time.Now()
+	return sm.expiry(now, now).Sub(now)
+}
+
+// Guest id of a valid, unexpired token
+func (sm *SessionManager) verifyGuestToken(token string) (string, bool) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return "", false
+	}
+	payload := token[:i]
+
+	sig, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil || !hmac.Equal(sig, sm.guestSignature(payload)) {
+		return "", false
+	}
+
+	id, issuedAt, ok := strings.Cut(payload, ".")
+	if !ok {
+		return "", false
+	}
+	issued, err := strconv.ParseInt(issuedAt, 10, 64)
+	if err != nil || time.Since(time.Unix(issued, 0)) > sm.guestLifetime() {
+		return "", false
+	}
+
+	return id, true
+}
+
+// GuestSession returns the session of the request, or a guest session if it
+// has none. A guest session lives only in a signed guest token cookie, so
+// anonymous traffic such as crawlers never reaches the session table. The
+// first write to it, including SetUserID, promotes it to a full session and
+// writes the session cookie on w, which must happen before the response
+// header is written.
+func (sm *SessionManager) GuestSession(w http.ResponseWriter, r *http.Request) (*Session, error) {
+	if len(sm.Config.GuestKey) == 0 {
+		return nil, errors.New("guest key is not configured")
+	}
+
+	s, err := sm.SessionRead(r)
+	if errors.Is(err, ErrImpossibleTravel) || errors.Is(err, ErrSessionRisk) {
+		return nil, err
+	}
+	if s != nil {
+		return s, nil
+	}
+
+	var guestId string
+	if c, err := r.Cookie(sm.cookieName(sm.guestCookie())); err == nil {
+		guestId, _ = sm.verifyGuestToken(c.Value)
+	}
+	if guestId == "" {
+		if guestId, err = randomID(); err != nil {
+			return nil, err
+		}
+		cookie := sm.newCookie(sm.guestCookie(), sm.issueGuestToken(guestId, time.Now()))
+		if !sm.Cookie.BrowserSession {
+			cookie.MaxAge = int(sm.guestLifetime().Seconds())
+		}
+		http.SetCookie(w, cookie)
+	}
+
+	s = sm.newSession("")
+	s.guestId = guestId
+	s.requestId = sm.requestID(r)
+	s.promote = sm.promoter(w, r, s)
+
+	return s, nil
+}
+
+// Promote the guest session s into the session table once, recording the
+// client of r that carried its guest token like SessionStart does
+func (sm *SessionManager) promoter(w http.ResponseWriter, r *http.Request, s *Session) func() error {
+	var promoted bool
+	var err error
+
+	return func() error {
+		s.promoteLock.Lock()
+		defer s.promoteLock.Unlock()
+
+		if promoted {
+			return err
+		}
+		promoted = true
+
+		if _, err = sm.createFromRequest("", s, r); err != nil {
+			return err
+		}
+		sm.SetCookie(w, s)
+
+		return nil
+	}
+}
+
+// Whether s is a guest session that has not been promoted yet
+func (s *Session) IsGuest() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.guestId != "" && s.sessionId == ""
+}
+
+// Stable id of the guest token s was created from, empty for sessions that
+// did not start as a guest
+func (s *Session) GuestID() string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.guestId
+}
+
+// Promote a guest session before its first write, no-op otherwise
+func (s *Session) promoteGuest() error {
+	if s.promote == nil {
+		return nil
+	}
+	return s.promote()
+}
@@ -0,0 +1,157 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_GuestSession(t *testing.T) {
+	sm := New()
+	req := httptest.NewRequest("GET", "/", nil)
+
+	// Case 1: Guest Key Required
+	if _, err := sm.GuestSession(httptest.NewRecorder(), req); err == nil {
+		t.Errorf("Expected error without a guest key")
+	}
+	sm.Config.GuestKey = []byte("secret")
+
+	// Case 2: Guest Token Issued Without Server State
+	rec := httptest.NewRecorder()
+	s, err := sm.GuestSession(rec, req)
+	if err != nil || !s.IsGuest() || s.GuestID() == "" {
+		t.Fatalf("Expected guest session, got %v, error: %v", s, err)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != DefaultGuestCookie {
+		t.Fatalf("Expected guest cookie, got %v", cookies)
+	}
+	if sm.SessionCount() != 0 {
+		t.Errorf("Expected no sessions, got %v", sm.SessionCount())
+	}
+
+	// Case 3: Reads Don't Promote
+	if s.Get("cart") != nil || s.Delete("cart") != nil || !s.IsGuest() {
+		t.Errorf("Expected reads to keep the guest session")
+	}
+
+	// Case 4: Valid Token Keeps the Guest ID
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	again, _ := sm.GuestSession(rec, req)
+	if again.GuestID() != s.GuestID() || rec.Header().Get("Set-Cookie") != "" {
+		t.Errorf("Expected guest id %v without a new cookie, got %v", s.GuestID(), again.GuestID())
+	}
+
+	// Case 5: First Write Promotes
+	if err := again.Set("cart", 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if again.IsGuest() || again.ID() == "" || !sm.SessionExist(again.ID()) {
+		t.Errorf("Expected promoted session, got %v", again.ID())
+	}
+	cookies = rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != sm.Cookie.Name || cookies[0].Value != again.ID() {
+		t.Errorf("Expected session cookie, got %v", cookies)
+	}
+
+	// Case 6: Promotion Happens Once
+	sid := again.ID()
+	again.SetUserID("user1")
+	if again.ID() != sid || sm.SessionCount() != 1 {
+		t.Errorf("Expected a single promotion, got %v sessions", sm.SessionCount())
+	}
+
+	// Case 7: Existing Session Returned As Is
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: sid})
+	if got, _ := sm.GuestSession(httptest.NewRecorder(), req); got != again {
+		t.Errorf("Expected the promoted session, got %v", got)
+	}
+
+	// Case 8: Promotion Records the Client and Binds It
+	sm.Config.BindIP = true
+	req = httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("User-Agent", "browser")
+	guest, _ := sm.GuestSession(httptest.NewRecorder(), req)
+	guest.Set("cart", 1)
+	if meta := guest.Metadata(); meta.IP != "10.0.0.1" || meta.UserAgent != "browser" {
+		t.Errorf("Expected the client recorded, got %+v", meta)
+	}
+	req = httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: guest.ID()})
+	if _, err := sm.SessionRead(req); !errors.Is(err, ErrIPMismatch) {
+		t.Errorf("Expected ErrIPMismatch, got %v", err)
+	}
+}
+
+func TestSessionManager_VerifyGuestToken(t *testing.T) {
+	sm := New()
+	sm.Config.GuestKey = []byte("secret")
+	token := sm.issueGuestToken("guest1", time.Now())
+
+	// Case 1: Valid Token
+	if id, ok := sm.verifyGuestToken(token); !ok || id != "guest1" {
+		t.Errorf("Expected guest1, got %v", id)
+	}
+
+	// Case 2: Tampered Token
+	if _, ok := sm.verifyGuestToken("guest2" + token[len("guest1"):]); ok {
+		t.Errorf("Expected tampered token to be rejected")
+	}
+
+	// Case 3: Other Key
+	other := New()
+	other.Config.GuestKey = []byte("other")
+	if _, ok := other.verifyGuestToken(token); ok {
+		t.Errorf("Expected token signed with another key to be rejected")
+	}
+
+	// Case 4: Expired Token
+	if _, ok := sm.verifyGuestToken(sm.issueGuestToken("guest1", time.Now().Add(-48*time.Hour))); ok {
+		t.Errorf("Expected expired token to be rejected")
+	}
+
+	// Case 5: Malformed Token
+	for _, token := range []string{"", "guest1", "guest1.abc", "guest1.123.%%"} {
+		if _, ok := sm.verifyGuestToken(token); ok {
+			t.Errorf("Expected %q to be rejected", token)
+		}
+	}
+
+	// Case 6: Absolute Lifetime Caps the Token
+	sm.Config.AbsoluteLifetime = time.Hour
+	if _, ok := sm.verifyGuestToken(sm.issueGuestToken("guest1", time.Now().Add(-2*time.Hour))); ok {
+		t.Errorf("Expected token older than the absolute lifetime to be rejected")
+	}
+}
+
+func TestSessionManager_GuestSessionIdleTimeout(t *testing.T) {
+	sm := New(SessionManagerConfig{IdleTimeout: 30 * time.Minute, GuestKey: []byte("secret")})
+	defer sm.Close()
+
+	// Case 1: Token Valid For the Idle Timeout
+	rec := httptest.NewRecorder()
+	s, err := sm.GuestSession(rec, httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].MaxAge != 30*60 {
+		t.Fatalf("Expected guest cookie living 30 minutes, got %v", cookies)
+	}
+
+	// Case 2: Guest ID Kept Across Requests
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	again, _ := sm.GuestSession(rec, req)
+	if again.GuestID() != s.GuestID() || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("Expected the guest id kept, got %v and %v", s.GuestID(), again.GuestID())
+	}
+}
@@ -0,0 +1,47 @@
+package session
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type keepAliveResponse struct {
+	ExpiresIn int64     `json:"expires_in"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// KeepAliveHandler touches the session of the request and responds with its
+// new expiry, for front-ends implementing "stay signed in" prompts.
+func (sm *SessionManager) KeepAliveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Read first so decoy, travel and risk checks apply to keep-alives
+		s, err := sm.SessionRead(r)
+		if err != nil || s == nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		sid := s.ID()
+		if sm.SessionUpdate(sid) != nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		expiresIn, err := sm.SessionExpiresIn(sid)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		if sm.Config.RollingCookies {
+			sm.SetCookie(w, s)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(keepAliveResponse{
+			ExpiresIn: int64(expiresIn.Seconds()),
+			ExpiresAt: time.Now().Add(expiresIn).UTC(),
+		})
+	})
+}
@@ -0,0 +1,79 @@
+package session
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_KeepAliveHandler(t *testing.T) {
+	sm := New()
+	sm.Config.MaxLifetime = 1 * time.Hour
+	sm.SessionCreate("sessionid123")
+	sm.stored("sessionid123").age(30 * time.Minute)
+	handler := sm.KeepAliveHandler()
+
+	// Case 1: Existing Session Is Touched
+	req := httptest.NewRequest("POST", "/keepalive", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %v", rec.Code)
+	}
+	var resp keepAliveResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Expected JSON body, error: %v", err)
+	}
+	if resp.ExpiresIn < 3599 || resp.ExpiresIn > 3600 {
+		t.Errorf("Expected about 3600, got %v", resp.ExpiresIn)
+	}
+	if time.Until(resp.ExpiresAt) < 59*time.Minute {
+		t.Errorf("Expected expiry about 1h from now, got %v", resp.ExpiresAt)
+	}
+
+	// Case 2: Rolling Cookie Re-Issued
+	sm.Config.RollingCookies = true
+	req = httptest.NewRequest("POST", "/keepalive", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if cookies := rec.Result().Cookies(); len(cookies) != 1 || cookies[0].Value != "sessionid123" {
+		t.Errorf("Expected re-issued cookie, got %v", cookies)
+	}
+
+	// Case 3: Non-Existent Session
+	req = httptest.NewRequest("POST", "/keepalive", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid456"})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %v", rec.Code)
+	}
+
+	// Case 4: No Session ID
+	req = httptest.NewRequest("POST", "/keepalive", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %v", rec.Code)
+	}
+	// Case 5: Decoy Alert Raised
+	var alerted string
+	sm.Config.OnDecoy = func(sid string, r *http.Request) { alerted = sid }
+	sm.AddDecoys("decoy123")
+	req = httptest.NewRequest("POST", "/keepalive", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "decoy123"})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized || alerted != "decoy123" {
+		t.Errorf("Expected 401 and a decoy alert, got %v and %v", rec.Code, alerted)
+	}
+}
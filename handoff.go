@@ -0,0 +1,114 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Start of a Handoff stream, carrying its format version
+const handoffMagic = "SMHANDOFF1"
+
+// Largest encoded session Adopt accepts, so a corrupt length can't make it
+// allocate without bound
+const maxHandoffRecord = 64 << 20
+
+var ErrHandoffCorrupt = errors.New("handoff stream is incomplete or corrupt")
+
+// Handoff streams every session to w for Adopt in the process taking over,
+// e.g. over a pipe or socket during a blue/green deploy. The stream ends
+// with the number of sessions and a SHA-256 over them, so Adopt can tell a
+// complete stream from a cut one. Freeze the manager first so no write
+// made after a session was streamed is lost. Values are gob encoded like in
+// a BackendStore, so custom types must be registered with RegisterType in
+// both processes. Returns the number of sessions written.
+func (sm *SessionManager) Handoff(w io.Writer) (int, error) {
+	sm.rlock()
+	snaps := make([]Snapshot, 0, sm.store.Count())
+	sm.each(func(_ string, s *Session) {
+		snaps = append(snaps, s.snapshot())
+	})
+	sm.lock.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	sum := sha256.New()
+	var buf [binary.MaxVarintLen64]byte
+
+	// bufio.Writer keeps the first write error for Flush to report
+	bw.WriteString(handoffMagic)
+	for _, snap := range snaps {
+		data, err := GobCodec{}.Encode(snap)
+		if err != nil {
+			return 0, err
+		}
+		sum.Write(data)
+		bw.Write(buf[:binary.PutUvarint(buf[:], uint64(len(data)))])
+		bw.Write(data)
+	}
+
+	// A zero length marks the end, followed by the count and checksum
+	bw.Write(buf[:binary.PutUvarint(buf[:], 0)])
+	bw.Write(buf[:binary.PutUvarint(buf[:], uint64(len(snaps)))])
+	bw.Write(sum.Sum(nil))
+	if err := bw.Flush(); err != nil {
+		return 0, err
+	}
+
+	return len(snaps), nil
+}
+
+// Adopt reads a stream written by Handoff and stores its sessions like
+// ApplySnapshot, merging them with local copies through
+// Config.ConflictResolver and skipping expired ones. Nothing is stored
+// unless the whole stream arrived intact, otherwise ErrHandoffCorrupt is
+// returned. Returns the number of sessions in the stream.
+func (sm *SessionManager) Adopt(r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(handoffMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != handoffMagic {
+		return 0, ErrHandoffCorrupt
+	}
+
+	sum := sha256.New()
+	var snaps []Snapshot
+	for {
+		n, err := binary.ReadUvarint(br)
+		if err != nil || n > maxHandoffRecord {
+			return 0, ErrHandoffCorrupt
+		}
+		if n == 0 {
+			break
+		}
+
+		data := make([]byte, n)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return 0, ErrHandoffCorrupt
+		}
+		sum.Write(data)
+
+		snap, err := GobCodec{}.Decode(data)
+		if err != nil {
+			return 0, err
+		}
+		snaps = append(snaps, snap)
+	}
+
+	count, err := binary.ReadUvarint(br)
+	if err != nil || count != uint64(len(snaps)) {
+		return 0, ErrHandoffCorrupt
+	}
+	checksum := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(br, checksum); err != nil || !bytes.Equal(checksum, sum.Sum(nil)) {
+		return 0, ErrHandoffCorrupt
+	}
+
+	for _, snap := range snaps {
+		sm.ApplySnapshot(snap)
+	}
+
+	return len(snaps), nil
+}
@@ -0,0 +1,60 @@
+package session
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_Handoff(t *testing.T) {
+	old := New(SessionManagerConfig{MaxLifetime: time.Hour})
+	defer old.Close()
+	s, _ := old.SessionCreate("sessionid123")
+	s.Set("cart", 3)
+	s.SetUserID("user1")
+	old.SessionCreate("sessionid456")
+
+	var stream bytes.Buffer
+	if n, err := old.Handoff(&stream); err != nil || n != 2 {
+		t.Fatalf("Expected 2 sessions written, got %v, error: %v", n, err)
+	}
+
+	// Case 1: Truncated Stream Adopts Nothing
+	next := New(SessionManagerConfig{MaxLifetime: time.Hour})
+	defer next.Close()
+	for _, cut := range []int{0, 5, stream.Len() / 2, stream.Len() - 1} {
+		if _, err := next.Adopt(bytes.NewReader(stream.Bytes()[:cut])); err != ErrHandoffCorrupt {
+			t.Errorf("Expected ErrHandoffCorrupt at %v bytes, got %v", cut, err)
+		}
+	}
+	if next.SessionCount() != 0 {
+		t.Errorf("Expected no sessions adopted, got %v", next.SessionCount())
+	}
+
+	// Case 2: Tampered Stream Rejected
+	tampered := append([]byte(nil), stream.Bytes()...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := next.Adopt(bytes.NewReader(tampered)); err != ErrHandoffCorrupt {
+		t.Errorf("Expected ErrHandoffCorrupt, got %v", err)
+	}
+
+	// Case 3: Complete Stream Adopted
+	if n, err := next.Adopt(bytes.NewReader(stream.Bytes())); err != nil || n != 2 {
+		t.Fatalf("Expected 2 sessions adopted, got %v, error: %v", n, err)
+	}
+	got, ok := next.session("sessionid123")
+	if !ok || got.Get("cart") != 3 || got.UserID() != "user1" || !next.SessionExist("sessionid456") {
+		t.Errorf("Expected sessions with their state, got %v", got)
+	}
+
+	// Case 4: Expired Sessions Skipped
+	old.stored("sessionid456").age(2 * time.Hour)
+	stream.Reset()
+	old.Handoff(&stream)
+	fresh := New(SessionManagerConfig{MaxLifetime: time.Hour})
+	defer fresh.Close()
+	fresh.Adopt(&stream)
+	if fresh.SessionCount() != 1 || fresh.SessionExist("sessionid456") {
+		t.Errorf("Expected only the live session adopted, got %v", fresh.SessionCount())
+	}
+}
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+)
+
+// Generated ids tried by SessionStart, SessionRegenerate and guest promotion
+// before giving up
+const maxIDAttempts = 5
+
+var ErrIDCollision = errors.New("generated session ids are all in use")
+
+var errIDInUse = errors.New("session id is in use")
+
+// IDGenerator makes the ids of sessions created by the manager itself, e.g.
+// UUIDv4, NanoID or prefixed ids. Ids must be unguessable as they are
+// credentials. Set Config.IDGenerator to replace DefaultIDGenerator.
+type IDGenerator interface {
+	NewID() (string, error)
+}
+
+// Adapter to use an ordinary function as an IDGenerator
+type IDGeneratorFunc func() (string, error)
+
+func (f IDGeneratorFunc) NewID() (string, error) {
+	return f()
+}
+
+// Random URL safe ids with 256 bits of entropy from crypto/rand
+var DefaultIDGenerator IDGenerator = IDGeneratorFunc(randomID)
+
+// Random URL safe identifier with 256 bits of entropy
+func randomID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (sm *SessionManager) idGenerator() IDGenerator {
+	if sm.Config.IDGenerator != nil {
+		return sm.Config.IDGenerator
+	}
+	return DefaultIDGenerator
+}
+
+// Generate an id no session or decoy uses, returned with its lock held.
+// Duplicates are regenerated up to maxIDAttempts times.
+func (sm *SessionManager) claimID() (string, func(), error) {
+	for i := 0; i < maxIDAttempts; i++ {
+		sid, err := sm.idGenerator().NewID()
+		if err != nil {
+			return "", nil, err
+		}
+		if sid == "" {
+			return "", nil, errors.New("generated session id is empty")
+		}
+
+		unlock := sm.lockSid(sid)
+		s, err := sm.store.Get(sid)
+		if err != nil {
+			unlock()
+			return "", nil, err
+		}
+		if s == nil && !sm.IsDecoy(sid) {
+			return sid, unlock, nil
+		}
+		unlock()
+	}
+
+	return "", nil, ErrIDCollision
+}
@@ -0,0 +1,56 @@
+package session
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionManager_IDGenerator(t *testing.T) {
+	sm := New()
+	ids := []string{"taken", "decoy", "app_1", "taken"}
+	sm.Config.IDGenerator = IDGeneratorFunc(func() (string, error) {
+		sid := ids[0]
+		ids = ids[1:]
+		return sid, nil
+	})
+	sm.SessionCreate("taken")
+	sm.AddDecoys("decoy")
+
+	// Case 1: Duplicates and Decoys Are Regenerated
+	s, err := sm.SessionStart(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if err != nil || s.ID() != "app_1" {
+		t.Fatalf("Expected app_1, got %v, error: %v", s, err)
+	}
+	if s, _ := sm.session("taken"); s == nil {
+		t.Errorf("Expected the existing session to be kept")
+	}
+
+	// Case 2: Giving Up on Persistent Collisions
+	sm.Config.IDGenerator = IDGeneratorFunc(func() (string, error) { return "taken", nil })
+	if _, err := sm.SessionStart(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)); err != ErrIDCollision {
+		t.Errorf("Expected ErrIDCollision, got %v", err)
+	}
+
+	// Case 3: Generator Errors Returned
+	failure := errors.New("no entropy")
+	sm.Config.IDGenerator = IDGeneratorFunc(func() (string, error) { return "", failure })
+	if _, err := sm.SessionStart(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)); err != failure {
+		t.Errorf("Expected generator error, got %v", err)
+	}
+
+	// Case 4: Guests Promoted Under Generated Ids
+	sm.Config.IDGenerator = IDGeneratorFunc(func() (string, error) { return "guest_1", nil })
+	sm.Config.GuestKey = []byte("secret")
+	g, _ := sm.GuestSession(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if err := g.Set("cart", 1); err != nil || g.ID() != "guest_1" {
+		t.Errorf("Expected guest_1, got %v, error: %v", g.ID(), err)
+	}
+
+	// Case 5: Default Generator
+	sm.Config.IDGenerator = nil
+	s, err = sm.SessionStart(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if err != nil || len(s.ID()) != 43 {
+		t.Errorf("Expected a 256 bit id, got %v, error: %v", s.ID(), err)
+	}
+}
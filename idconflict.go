@@ -0,0 +1,49 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+)
+
+var ErrIDConflict = errors.New("session cookie and header name different sessions")
+
+// Which session id GetSessionId uses when the cookie and the session header
+// of a request name different sessions
+type IDConflictPolicy int
+
+const (
+	// The cookie id wins, as when the header is disabled
+	PreferCookie IDConflictPolicy = iota
+	// The header id wins, e.g. for API clients behind a shared browser
+	PreferHeader
+	// Neither is used and GetSessionId fails with ErrIDConflict
+	RejectConflict
+)
+
+// Session id of a request whose cookie carries sid, after checking it
+// against the session header. A header that fails to verify disagrees
+// with any cookie.
+func (sm *SessionManager) resolveIDConflict(r *http.Request, sid string) (string, error) {
+	values := r.Header[sm.Config.SessionHeader]
+	if len(values) == 0 || values[0] == "" {
+		return sid, nil
+	}
+
+	headerSid, err := sm.headerID(values[0])
+	if err == nil && headerSid == sid {
+		return sid, nil
+	}
+
+	if sm.Config.OnIDConflict != nil {
+		sm.Config.OnIDConflict(r)
+	}
+
+	switch sm.Config.IDConflictPolicy {
+	case PreferHeader:
+		return headerSid, err
+	case RejectConflict:
+		return "", ErrIDConflict
+	default:
+		return sid, nil
+	}
+}
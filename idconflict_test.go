@@ -0,0 +1,56 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionManager_IDConflictPolicy(t *testing.T) {
+	sm := New()
+	sm.Config.EnableHttpHeader = true
+	sm.Config.SessionHeader = "Session-Id"
+
+	var conflicts int
+	sm.Config.OnIDConflict = func(r *http.Request) { conflicts++ }
+
+	request := func(cookie, header string) *http.Request {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: cookie})
+		req.Header.Set("Session-Id", header)
+		return req
+	}
+
+	// Case 1: Agreeing Cookie and Header
+	if sid, err := sm.GetSessionId(request("sessionid123", "sessionid123")); err != nil || sid != "sessionid123" || conflicts != 0 {
+		t.Errorf("Expected sessionid123 without conflict, got %v, error: %v, conflicts: %v", sid, err, conflicts)
+	}
+
+	// Case 2: Cookie Preferred by Default
+	if sid, err := sm.GetSessionId(request("sessionid123", "sessionid456")); err != nil || sid != "sessionid123" || conflicts != 1 {
+		t.Errorf("Expected sessionid123 with conflict, got %v, error: %v, conflicts: %v", sid, err, conflicts)
+	}
+
+	// Case 3: Header Preferred
+	sm.Config.IDConflictPolicy = PreferHeader
+	if sid, err := sm.GetSessionId(request("sessionid123", "sessionid456")); err != nil || sid != "sessionid456" || conflicts != 2 {
+		t.Errorf("Expected sessionid456, got %v, error: %v, conflicts: %v", sid, err, conflicts)
+	}
+
+	// Case 4: Conflict Rejected
+	sm.Config.IDConflictPolicy = RejectConflict
+	sm.SessionCreate("sessionid123")
+	if s, err := sm.SessionRead(request("sessionid123", "sessionid456")); err != ErrIDConflict || s != nil {
+		t.Errorf("Expected ErrIDConflict, got %v, %v", s, err)
+	}
+
+	// Case 5: Unverifiable Header Conflicts
+	sm.Config.SessionIDKeys = [][]byte{[]byte("key")}
+	signed := sm.signID("sessionid123")
+	if _, err := sm.GetSessionId(request(signed, "sessionid123")); err != ErrIDConflict {
+		t.Errorf("Expected ErrIDConflict, got %v", err)
+	}
+	if sid, err := sm.GetSessionId(request(signed, signed)); err != nil || sid != "sessionid123" {
+		t.Errorf("Expected sessionid123, got %v, error: %v", sid, err)
+	}
+}
@@ -0,0 +1,141 @@
+package session
+
+import (
+	"sort"
+	"time"
+)
+
+const tagsKey = "_sm.tags"
+
+// A session as seen by integrations: listings, events, the admin API, dumps
+// and metrics all describe sessions with it. ID is left empty wherever
+// session ids must not leak, e.g. the admin API, which identifies sessions
+// by Handle instead.
+type SessionInfo struct {
+	ID           string    `json:"id,omitempty"`
+	Handle       string    `json:"handle"`
+	UserID       string    `json:"user_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastAccessed time.Time `json:"last_accessed"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	IP           string    `json:"ip,omitempty"`
+	UserAgent    string    `json:"user_agent,omitempty"`
+	Country      string    `json:"country,omitempty"`
+	City         string    `json:"city,omitempty"`
+	Size         int       `json:"size"` // values set by the application
+	Tags         []string  `json:"tags,omitempty"`
+}
+
+// Label the session, e.g. "api-client" or "beta", for listings and reports.
+// Tags are kept sorted and without duplicates.
+func (s *Session) Tag(tags ...string) error {
+	set := make(map[string]bool)
+	for _, t := range append(s.Tags(), tags...) {
+		set[t] = true
+	}
+	return s.setTags(set)
+}
+
+func (s *Session) Untag(tags ...string) error {
+	set := make(map[string]bool)
+	for _, t := range s.Tags() {
+		set[t] = true
+	}
+	for _, t := range tags {
+		delete(set, t)
+	}
+	return s.setTags(set)
+}
+
+func (s *Session) setTags(set map[string]bool) error {
+	if len(set) == 0 {
+		return s.delete(tagsKey)
+	}
+	tags := make([]string, 0, len(set))
+	for t := range set {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	return s.set(tagsKey, tags)
+}
+
+// Tags of the session, sorted
+func (s *Session) Tags() []string {
+	switch v := s.Get(tagsKey).(type) {
+	case []string:
+		return append([]string(nil), v...)
+	case []interface{}:
+		// As restored by JSONCodec and MessagePackCodec
+		tags := make([]string, 0, len(v))
+		for _, t := range v {
+			if t, ok := t.(string); ok {
+				tags = append(tags, t)
+			}
+		}
+		return tags
+	}
+	return nil
+}
+
+func (sm *SessionManager) sessionInfo(s *Session) SessionInfo {
+	tags := s.Tags()
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	size := 0
+	for k := range s.sd {
+		if !isReservedKey(k) {
+			size++
+		}
+	}
+
+	return SessionInfo{
+		ID:           s.sessionId,
+		Handle:       AffinityHash(s.sessionId),
+		UserID:       s.userId,
+		CreatedAt:    s.meta.CreatedAt,
+		LastAccessed: s.lastAccessed,
+		ExpiresAt:    sm.expiry(s.lastAccessed, s.meta.CreatedAt),
+		IP:           s.meta.IP,
+		UserAgent:    s.meta.UserAgent,
+		Country:      s.meta.Location.Country,
+		City:         s.meta.Location.City,
+		Size:         size,
+		Tags:         tags,
+	}
+}
+
+// For events, which are emitted for sessions no longer in the table too
+func (sm *SessionManager) eventInfo(s *Session) *SessionInfo {
+	info := sm.sessionInfo(s)
+	return &info
+}
+
+// Every session, oldest first
+func (sm *SessionManager) Sessions() []SessionInfo {
+	sm.rlock()
+	defer sm.lock.RUnlock()
+
+	list := make([]SessionInfo, 0, sm.store.Count())
+	sm.each(func(_ string, s *Session) {
+		list = append(list, sm.sessionInfo(s))
+	})
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+
+	return list
+}
+
+// Session sid, false if there is none
+func (sm *SessionManager) SessionInfo(sid string) (SessionInfo, bool) {
+	sm.rlock()
+	defer sm.lock.RUnlock()
+
+	s := sm.lookup(sid)
+	if s == nil {
+		return SessionInfo{}, false
+	}
+	return sm.sessionInfo(s), true
+}
@@ -0,0 +1,95 @@
+package session
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSession_Tags(t *testing.T) {
+	sm := New()
+	s, _ := sm.SessionCreate("sessionid123")
+
+	// Case 1: Tags Sorted Without Duplicates
+	s.Tag("beta", "api-client")
+	s.Tag("beta")
+	if tags := s.Tags(); !reflect.DeepEqual(tags, []string{"api-client", "beta"}) {
+		t.Errorf("Expected [api-client beta], got %v", tags)
+	}
+
+	// Case 2: Untag
+	s.Untag("beta", "unknown")
+	if tags := s.Tags(); !reflect.DeepEqual(tags, []string{"api-client"}) {
+		t.Errorf("Expected [api-client], got %v", tags)
+	}
+	s.Untag("api-client")
+	if tags := s.Tags(); tags != nil || s.Get(tagsKey) != nil {
+		t.Errorf("Expected no tags left, got %v", tags)
+	}
+
+	// Case 3: Tags Restored by JSONCodec
+	sm = New(SessionManagerConfig{MaxLifetime: time.Hour, Store: NewBackendStore(newMapBackend(), JSONCodec{})})
+	defer sm.Close()
+	s, _ = sm.SessionCreate("sessionid123")
+	s.Tag("beta")
+	if s, ok := sm.session("sessionid123"); !ok || !reflect.DeepEqual(s.Tags(), []string{"beta"}) {
+		t.Errorf("Expected [beta] after a round trip")
+	}
+}
+
+func TestSessionManager_Sessions(t *testing.T) {
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	s, _ := sm.SessionCreateFromRequest("sessionid123", req)
+	s.Set("key1", "value1")
+	s.Set("key2", "value2")
+	s.SetUserID("user1")
+	s.Tag("beta")
+	sm.stored(s.ID()).age(time.Minute)
+	sm.SessionCreate("sessionid456")
+
+	// Case 1: Every Session, Oldest First
+	list := sm.Sessions()
+	if len(list) != 2 || list[0].ID != s.ID() || list[1].ID != "sessionid456" {
+		t.Fatalf("Expected both sessions oldest first, got %v", list)
+	}
+	info := list[0]
+	if info.UserID != "user1" || info.UserAgent != "Mozilla/5.0" || info.Handle != AffinityHash(s.ID()) || !reflect.DeepEqual(info.Tags, []string{"beta"}) {
+		t.Errorf("Expected session details, got %+v", info)
+	}
+
+	// Case 2: Size Counts Only Application Values
+	if info.Size != 2 {
+		t.Errorf("Expected size 2, got %v", info.Size)
+	}
+
+	// Case 3: Expiry From the Last Access
+	if want := info.LastAccessed.Add(time.Hour); !info.ExpiresAt.Equal(want) {
+		t.Errorf("Expected expiry %v, got %v", want, info.ExpiresAt)
+	}
+
+	// Case 4: Single Session
+	if got, ok := sm.SessionInfo("sessionid456"); !ok || got.ID != "sessionid456" || got.Size != 0 {
+		t.Errorf("Expected sessionid456, got %+v", got)
+	}
+	if _, ok := sm.SessionInfo("unknown"); ok {
+		t.Errorf("Expected no info for an unknown session")
+	}
+
+	// Case 5: Events Carry the Info
+	sm.SessionDestroy("sessionid456")
+	if e := sm.RecentEvents()[0]; e.Type != EventDestroyed || e.Info == nil || e.Info.ID != "sessionid456" {
+		t.Errorf("Expected destroyed event with info, got %+v", e)
+	}
+
+	// Case 6: No Id in JSON When Cleared
+	info.ID = ""
+	data, _ := json.Marshal(info)
+	if strings.Contains(string(data), `"id"`) || !strings.Contains(string(data), `"handle"`) {
+		t.Errorf("Expected handle but no id, got %s", data)
+	}
+}
@@ -0,0 +1,51 @@
+package session
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Upper bound of distinct interned keys, so applications generating
+// dynamic key names can't grow the table without limit
+const maxInternedKeys = 4096
+
+type interner struct {
+	keys  sync.Map
+	count atomic.Int64
+}
+
+// Canonical copy of k. Sessions storing the same key then share one backing
+// string instead of holding a copy each.
+func (in *interner) intern(k string) string {
+	if v, ok := in.keys.Load(k); ok {
+		return v.(string)
+	}
+
+	if in.count.Load() >= maxInternedKeys {
+		return k
+	}
+
+	v, loaded := in.keys.LoadOrStore(k, k)
+	if !loaded {
+		in.count.Add(1)
+	}
+
+	return v.(string)
+}
+
+// Number of distinct keys interned by the manager
+func (sm *SessionManager) InternedKeys() int {
+	return int(sm.interner.count.Load())
+}
+
+func (s *Session) internKey(key interface{}) interface{} {
+	if s.manager == nil || !s.manager.Config.InternKeys {
+		return key
+	}
+
+	if k, ok := key.(string); ok {
+		return s.manager.interner.intern(k)
+	}
+
+	return key
+}
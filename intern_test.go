@@ -0,0 +1,64 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func stringData(s string) uintptr {
+	return uintptr(unsafe.Pointer(unsafe.StringData(s)))
+}
+
+func TestSession_InternKeys(t *testing.T) {
+	sm := New()
+	sm.Config.InternKeys = true
+	s1, _ := sm.SessionCreate("sessionid123")
+	s2, _ := sm.SessionCreate("sessionid456")
+
+	// Case 1: Equal Keys Share Backing Memory
+	s1.Set(strings.Repeat("k", 8), 1)
+	s2.Set(strings.Repeat("k", 8), 2)
+
+	var k1, k2 string
+	for k := range s1.sd {
+		k1 = k.(string)
+	}
+	for k := range s2.sd {
+		k2 = k.(string)
+	}
+	if stringData(k1) != stringData(k2) {
+		t.Errorf("Expected keys to share backing memory")
+	}
+	if n := sm.InternedKeys(); n != 1 {
+		t.Errorf("Expected 1 interned key, got %v", n)
+	}
+
+	// Case 2: Values Still Readable by Any Equal Key
+	if val := s2.Get(strings.Repeat("k", 8)); val != 2 {
+		t.Errorf("Expected 2, got %v", val)
+	}
+
+	// Case 3: Non-String Keys Untouched
+	s1.Set(42, "answer")
+	if val := s1.Get(42); val != "answer" || sm.InternedKeys() != 1 {
+		t.Errorf("Expected answer and 1 interned key, got %v and %v", val, sm.InternedKeys())
+	}
+
+	// Case 4: Table Is Bounded
+	for i := 0; i < maxInternedKeys+10; i++ {
+		s1.Set(fmt.Sprintf("dynamic%d", i), i)
+	}
+	if n := sm.InternedKeys(); n != maxInternedKeys {
+		t.Errorf("Expected %v interned keys, got %v", maxInternedKeys, n)
+	}
+
+	// Case 5: Interning Disabled
+	smOff := New()
+	sOff, _ := smOff.SessionCreate("sessionid123")
+	sOff.Set("key", 1)
+	if n := smOff.InternedKeys(); n != 0 {
+		t.Errorf("Expected 0 interned keys, got %v", n)
+	}
+}
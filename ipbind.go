@@ -0,0 +1,71 @@
+package session
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var ErrIPMismatch = errors.New("session used from another IP address")
+
+// Whether ip is one of Config.TrustedProxies, given as addresses or CIDR
+// ranges
+func (sm *SessionManager) trustedProxy(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+
+	for _, p := range sm.Config.TrustedProxies {
+		if _, network, err := net.ParseCIDR(p); err == nil {
+			if network.Contains(addr) {
+				return true
+			}
+		} else if proxy := net.ParseIP(p); proxy != nil && proxy.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Client IP of the request. Requests from Config.TrustedProxies are
+// attributed to the last address in X-Forwarded-For not itself a trusted
+// proxy, as the earlier ones are set by the client and can't be trusted.
+func (sm *SessionManager) clientIP(r *http.Request) string {
+	ip := remoteIP(r)
+	if len(sm.Config.TrustedProxies) == 0 || !sm.trustedProxy(ip) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		ip = hop
+		if !sm.trustedProxy(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// Reject r if Config.BindIP is set and it comes from another IP than the
+// one s was created from. Sessions not created from a request are not
+// bound.
+func (sm *SessionManager) checkIP(s *Session, r *http.Request) error {
+	if !sm.Config.BindIP {
+		return nil
+	}
+
+	s.lock.RLock()
+	bound := s.meta.IP
+	s.lock.RUnlock()
+
+	if bound != "" && bound != sm.clientIP(r) {
+		return ErrIPMismatch
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionManager_BindIP(t *testing.T) {
+	sm := New()
+	sm.Config.BindIP = true
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	sm.SessionCreateFromRequest("sessionid123", req)
+	sm.SessionCreate("sessionid456")
+
+	read := func(sid, remote, forwarded string) (*Session, error) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remote
+		if forwarded != "" {
+			req.Header.Set("X-Forwarded-For", forwarded)
+		}
+		req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: sid})
+		return sm.SessionRead(req)
+	}
+
+	// Case 1: Same IP Accepted
+	if s, err := read("sessionid123", "192.0.2.1:5678", ""); err != nil || s == nil {
+		t.Errorf("Expected session, got %v, error: %v", s, err)
+	}
+
+	// Case 2: Other IP Rejected
+	if s, err := read("sessionid123", "198.51.100.1:1234", ""); err != ErrIPMismatch || s != nil {
+		t.Errorf("Expected ErrIPMismatch, got %v, %v", s, err)
+	}
+
+	// Case 3: Forwarded Header Ignored From Untrusted Peers
+	if _, err := read("sessionid123", "198.51.100.1:1234", "192.0.2.1"); err != ErrIPMismatch {
+		t.Errorf("Expected ErrIPMismatch, got %v", err)
+	}
+
+	// Case 4: Client Behind Trusted Proxies
+	sm.Config.TrustedProxies = []string{"10.0.0.0/8", "203.0.113.7"}
+	if s, err := read("sessionid123", "10.1.2.3:1234", "6.6.6.6, 192.0.2.1, 203.0.113.7"); err != nil || s == nil {
+		t.Errorf("Expected session behind proxies, got %v, error: %v", s, err)
+	}
+	if _, err := read("sessionid123", "10.1.2.3:1234", "192.0.2.1, 6.6.6.6"); err != ErrIPMismatch {
+		t.Errorf("Expected spoofed hop ignored, got %v", err)
+	}
+
+	// Case 5: Sessions Without a Recorded IP Are Not Bound
+	if s, err := read("sessionid456", "198.51.100.1:1234", ""); err != nil || s == nil {
+		t.Errorf("Expected unbound session, got %v, error: %v", s, err)
+	}
+
+	// Case 6: Binding Off
+	sm.Config.BindIP = false
+	if s, err := read("sessionid123", "198.51.100.1:1234", ""); err != nil || s == nil {
+		t.Errorf("Expected session, got %v, error: %v", s, err)
+	}
+}
+
+func TestSessionManager_ClientIP(t *testing.T) {
+	sm := New()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "192.0.2.1")
+
+	// Case 1: No Trusted Proxies
+	if ip := sm.clientIP(req); ip != "10.0.0.1" {
+		t.Errorf("Expected 10.0.0.1, got %v", ip)
+	}
+
+	// Case 2: Trusted Proxy
+	sm.Config.TrustedProxies = []string{"10.0.0.1"}
+	if ip := sm.clientIP(req); ip != "192.0.2.1" {
+		t.Errorf("Expected 192.0.2.1, got %v", ip)
+	}
+
+	// Case 3: Only Proxies in the Chain
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	if ip := sm.clientIP(req); ip != "10.0.0.1" {
+		t.Errorf("Expected 10.0.0.1, got %v", ip)
+	}
+}
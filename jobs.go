@@ -0,0 +1,203 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Job is a background task visiting every session once, e.g. to re-encrypt
+// values, rebuild an index or migrate sessions to another store. Sessions
+// are visited in the order of their AffinityHash, so a job stopped part way
+// can be resumed from its last Checkpoint without exposing session ids.
+type Job struct {
+	Name string
+
+	// Called for every session. Errors are counted and the job goes on.
+	Run func(s *Session) error
+
+	// Sessions visited per second, unlimited if zero
+	Rate float64
+
+	// Checkpoint of an earlier run to resume after
+	Resume string
+
+	// Called with the progress every ProgressEvery sessions, DefaultProgressEvery
+	// if zero, and once the job ends, e.g. to persist the checkpoint
+	OnProgress    func(p JobProgress)
+	ProgressEvery int
+}
+
+// Sessions between two Job.OnProgress calls if Job.ProgressEvery is zero
+const DefaultProgressEvery = 100
+
+type JobProgress struct {
+	Name       string
+	Total      int    // sessions to visit in this run
+	Done       int    // sessions visited, failed ones included
+	Failed     int    // sessions Run returned an error for
+	LastError  error  // last error returned by Run
+	Checkpoint string // AffinityHash of the last session visited, see Job.Resume
+	Started    time.Time
+	Finished   bool // all sessions were visited
+}
+
+// A job started with StartJob
+type JobRun struct {
+	lock     sync.Mutex
+	progress JobProgress
+	err      error
+	done     chan struct{}
+}
+
+func (jr *JobRun) Progress() JobProgress {
+	jr.lock.Lock()
+	defer jr.lock.Unlock()
+
+	return jr.progress
+}
+
+// Wait for the job to end. The error is ctx.Err() if it was cancelled.
+func (jr *JobRun) Wait() (JobProgress, error) {
+	<-jr.done
+	jr.lock.Lock()
+	defer jr.lock.Unlock()
+
+	return jr.progress, jr.err
+}
+
+type jobs struct {
+	lock    sync.Mutex
+	running map[*JobRun]struct{}
+}
+
+// Run job in the background until every session was visited or ctx is done
+func (sm *SessionManager) StartJob(ctx context.Context, job Job) (*JobRun, error) {
+	if job.Run == nil {
+		return nil, errors.New("job has no Run function")
+	}
+
+	jr := &JobRun{
+		progress: JobProgress{Name: job.Name, Started: time.Now()},
+		done:     make(chan struct{}),
+	}
+
+	sm.jobs.lock.Lock()
+	if sm.jobs.running == nil {
+		sm.jobs.running = make(map[*JobRun]struct{})
+	}
+	sm.jobs.running[jr] = struct{}{}
+	sm.jobs.lock.Unlock()
+
+	go func() {
+		err := sm.runJob(ctx, job, jr)
+
+		sm.jobs.lock.Lock()
+		delete(sm.jobs.running, jr)
+		sm.jobs.lock.Unlock()
+
+		jr.lock.Lock()
+		jr.err = err
+		p := jr.progress
+		jr.lock.Unlock()
+		close(jr.done)
+
+		if job.OnProgress != nil {
+			job.OnProgress(p)
+		}
+	}()
+
+	return jr, nil
+}
+
+// Progress of the jobs running, oldest first
+func (sm *SessionManager) Jobs() []JobProgress {
+	sm.jobs.lock.Lock()
+	runs := make([]*JobRun, 0, len(sm.jobs.running))
+	for jr := range sm.jobs.running {
+		runs = append(runs, jr)
+	}
+	sm.jobs.lock.Unlock()
+
+	progress := make([]JobProgress, 0, len(runs))
+	for _, jr := range runs {
+		progress = append(progress, jr.Progress())
+	}
+	sort.Slice(progress, func(i, j int) bool { return progress[i].Started.Before(progress[j].Started) })
+
+	return progress
+}
+
+type jobTarget struct {
+	hash, sid string
+}
+
+func (sm *SessionManager) runJob(ctx context.Context, job Job, jr *JobRun) error {
+	// Sessions created after this point are not visited
+	var targets []jobTarget
+	sm.rlock()
+	sm.each(func(sid string, s *Session) {
+		if hash := AffinityHash(sid); hash > job.Resume {
+			targets = append(targets, jobTarget{hash, sid})
+		}
+	})
+	sm.lock.RUnlock()
+	sort.Slice(targets, func(i, j int) bool { return targets[i].hash < targets[j].hash })
+
+	jr.lock.Lock()
+	jr.progress.Total = len(targets)
+	jr.progress.Checkpoint = job.Resume
+	jr.lock.Unlock()
+
+	every := job.ProgressEvery
+	if every <= 0 {
+		every = DefaultProgressEvery
+	}
+
+	var tick <-chan time.Time
+	if job.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / job.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for _, t := range targets {
+		if tick != nil {
+			select {
+			case <-tick:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var err error
+		if s, ok := sm.session(t.sid); ok {
+			// Destroyed or expired sessions are skipped but still count
+			err = job.Run(s)
+		}
+
+		jr.lock.Lock()
+		jr.progress.Done++
+		if err != nil {
+			jr.progress.Failed++
+			jr.progress.LastError = err
+		}
+		jr.progress.Checkpoint = t.hash
+		p := jr.progress
+		jr.lock.Unlock()
+
+		if job.OnProgress != nil && p.Done%every == 0 {
+			job.OnProgress(p)
+		}
+	}
+
+	jr.lock.Lock()
+	jr.progress.Finished = true
+	jr.lock.Unlock()
+
+	return nil
+}
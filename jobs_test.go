@@ -0,0 +1,91 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_StartJob(t *testing.T) {
+	sm := New()
+	for i := 0; i < 10; i++ {
+		sm.SessionCreate(fmt.Sprintf("sessionid%d", i))
+	}
+
+	// Case 1: Every Session Visited
+	var lock sync.Mutex
+	var progress []JobProgress
+	jr, err := sm.StartJob(context.Background(), Job{
+		Name: "reencrypt",
+		Run: func(s *Session) error {
+			if s.ID() == "sessionid3" {
+				return errors.New("bad value")
+			}
+			return s.Set("migrated", true)
+		},
+		ProgressEvery: 4,
+		OnProgress: func(p JobProgress) {
+			lock.Lock()
+			progress = append(progress, p)
+			lock.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	p, err := jr.Wait()
+	if err != nil || !p.Finished || p.Total != 10 || p.Done != 10 || p.Failed != 1 || p.LastError == nil {
+		t.Errorf("Expected 10 sessions visited and 1 failed, got %+v, %v", p, err)
+	}
+	s, _ := sm.session("sessionid5")
+	if s.Get("migrated") != true {
+		t.Errorf("Expected sessionid5 to be migrated")
+	}
+
+	// Case 2: Progress Reported
+	lock.Lock()
+	if len(progress) != 3 || progress[0].Done != 4 || progress[1].Done != 8 || !progress[2].Finished {
+		t.Errorf("Expected progress at 4, 8 and the end, got %+v", progress)
+	}
+	lock.Unlock()
+
+	// Case 3: Cancelled And Resumed From The Checkpoint
+	ctx, cancel := context.WithCancel(context.Background())
+	visited := 0
+	jr, _ = sm.StartJob(ctx, Job{Run: func(*Session) error {
+		if visited++; visited == 4 {
+			cancel()
+		}
+		return nil
+	}})
+	p, err = jr.Wait()
+	if !errors.Is(err, context.Canceled) || p.Finished || p.Done != 4 || p.Checkpoint == "" {
+		t.Errorf("Expected the job cancelled after 4 sessions, got %+v, %v", p, err)
+	}
+	jr, _ = sm.StartJob(context.Background(), Job{Resume: p.Checkpoint, Run: func(*Session) error { return nil }})
+	if p, _ := jr.Wait(); p.Total != 6 || !p.Finished {
+		t.Errorf("Expected the 6 remaining sessions visited, got %+v", p)
+	}
+
+	// Case 4: Rate Limited And Listed While Running
+	start := time.Now()
+	jr, _ = sm.StartJob(context.Background(), Job{Name: "slow", Rate: 200, Run: func(*Session) error { return nil }})
+	if jobs := sm.Jobs(); len(jobs) != 1 || jobs[0].Name != "slow" {
+		t.Errorf("Expected the slow job listed, got %+v", jobs)
+	}
+	jr.Wait()
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Expected 10 sessions at 200/s to take 50ms, took %v", elapsed)
+	}
+	if len(sm.Jobs()) != 0 {
+		t.Errorf("Expected no running jobs")
+	}
+
+	// Case 5: Run Required
+	if _, err := sm.StartJob(context.Background(), Job{}); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
@@ -0,0 +1,58 @@
+package session
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+)
+
+// A single mutation of a session key. Values are recorded as hashes so the
+// journal can be inspected without exposing session contents; an empty hash
+// means the key was absent before (OldHash) or deleted (NewHash). RequestID
+// is the request that last used the session through Middleware.
+type JournalEntry struct {
+	Key       string
+	OldHash   string
+	NewHash   string
+	RequestID string
+	Time      time.Time
+}
+
+func valueHash(v interface{}) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%T:%#v", v, v)
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// Append a mutation to the journal. Caller must hold s.lock.
+func (s *Session) record(key, old interface{}, hadOld bool, val interface{}, hasVal bool) {
+	if s.manager == nil || s.manager.Config.JournalSize <= 0 {
+		return
+	}
+
+	e := JournalEntry{Key: fmt.Sprint(key), RequestID: s.requestId, Time: time.Now()}
+	if hadOld {
+		e.OldHash = valueHash(old)
+	}
+	if hasVal {
+		e.NewHash = valueHash(val)
+	}
+
+	if size := s.manager.Config.JournalSize; len(s.journal) >= size {
+		s.journal = append(s.journal[:0], s.journal[len(s.journal)-size+1:]...)
+	}
+	s.journal = append(s.journal, e)
+}
+
+// Recent key mutations, oldest first. Useful to answer "who changed my role
+// mid-session" by looking up the entry's request in the request logs.
+func (s *Session) Journal() []JournalEntry {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	journal := make([]JournalEntry, len(s.journal))
+	copy(journal, s.journal)
+
+	return journal
+}
@@ -0,0 +1,73 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSession_Journal(t *testing.T) {
+	sm := New()
+	sm.Config.JournalSize = 3
+	s, _ := sm.SessionCreate("sessionid123")
+
+	// Case 1: Set, Update and Delete Recorded
+	s.Set("role", "user")
+	s.Set("role", "admin")
+	s.Delete("role")
+
+	j := s.Journal()
+	if len(j) != 3 {
+		t.Fatalf("Expected 3 entries, got %v", len(j))
+	}
+	if j[0].Key != "role" || j[0].OldHash != "" || j[0].NewHash != valueHash("user") {
+		t.Errorf("Expected insert of user, got %+v", j[0])
+	}
+	if j[1].OldHash != valueHash("user") || j[1].NewHash != valueHash("admin") {
+		t.Errorf("Expected user to admin, got %+v", j[1])
+	}
+	if j[2].OldHash != valueHash("admin") || j[2].NewHash != "" {
+		t.Errorf("Expected delete of admin, got %+v", j[2])
+	}
+
+	// Case 2: Journal Is Bounded
+	for i := 0; i < 5; i++ {
+		s.Set(fmt.Sprintf("key%d", i), i)
+	}
+	j = s.Journal()
+	if len(j) != 3 || j[0].Key != "key2" || j[2].Key != "key4" {
+		t.Errorf("Expected key2..key4, got %+v", j)
+	}
+
+	// Case 3: Deleting Missing Key Not Recorded
+	s.Delete("missing")
+	if j = s.Journal(); j[2].Key != "key4" {
+		t.Errorf("Expected key4 last, got %+v", j[2])
+	}
+
+	// Case 4: Disabled by Default
+	smOff := New()
+	sOff, _ := smOff.SessionCreate("sessionid123")
+	sOff.Set("role", "admin")
+	if j = sOff.Journal(); len(j) != 0 {
+		t.Errorf("Expected empty journal, got %+v", j)
+	}
+
+	// Case 5: Session Without Manager
+	sBare := &Session{sd: make(dict)}
+	sBare.Set("role", "admin")
+	if j = sBare.Journal(); len(j) != 0 {
+		t.Errorf("Expected empty journal, got %+v", j)
+	}
+}
+
+func TestValueHash(t *testing.T) {
+	// Case 1: Same Value Same Hash
+	if valueHash("admin") != valueHash("admin") {
+		t.Errorf("Expected equal hashes")
+	}
+
+	// Case 2: Type Is Part of the Hash
+	if valueHash(1) == valueHash(int64(1)) || valueHash("1") == valueHash(1) {
+		t.Errorf("Expected different hashes for different types")
+	}
+}
@@ -0,0 +1,152 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// JSONCodec stores sessions as JSON documents, so services in other
+// languages can read them and they can be inspected with standard tools.
+//
+// Only string keys can be stored, and values must be representable in JSON.
+// They decode as JSON types: numbers as float64, objects as
+// map[string]interface{} and arrays as []interface{}, whatever type they
+// were set with, so read them back accordingly. Custom types can't be
+// restored and RegisterType has no effect on this codec.
+type JSONCodec struct{}
+
+// Values of reserved keys holding a time.Time, restored as such on decode
+var jsonTimeKeys = map[string]bool{sudoKey: true}
+
+type jsonSnapshot struct {
+	ID           string                 `json:"id"`
+	UserID       string                 `json:"user_id,omitempty"`
+	Metadata     jsonMetadata           `json:"metadata"`
+	LastAccessed time.Time              `json:"last_accessed"`
+	UpdatedAt    time.Time              `json:"updated_at"`
+	Owner        string                 `json:"owner,omitempty"`
+	LeaseExpires *time.Time             `json:"lease_expires,omitempty"`
+	Values       map[string]interface{} `json:"values"`
+	StepUp       bool                   `json:"step_up,omitempty"`
+	LastAccess   *jsonAccess            `json:"last_access,omitempty"`
+	ClassExpires map[string]time.Time   `json:"class_expires,omitempty"`
+	KeyClasses   map[string]string      `json:"key_classes,omitempty"`
+}
+
+type jsonMetadata struct {
+	CreatedAt time.Time         `json:"created_at"`
+	IP        string            `json:"ip,omitempty"`
+	UserAgent string            `json:"user_agent,omitempty"`
+	Location  jsonLocation      `json:"location"`
+	Headers   map[string]string `json:"headers,omitempty"`
+}
+
+type jsonLocation struct {
+	Country   string  `json:"country,omitempty"`
+	City      string  `json:"city,omitempty"`
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+}
+
+type jsonAccess struct {
+	IP       string       `json:"ip,omitempty"`
+	Location jsonLocation `json:"location"`
+	Time     time.Time    `json:"time"`
+}
+
+func (JSONCodec) Encode(snap Snapshot) ([]byte, error) {
+	js := jsonSnapshot{
+		ID:     snap.ID,
+		UserID: snap.UserID,
+		Metadata: jsonMetadata{
+			CreatedAt: snap.Metadata.CreatedAt,
+			IP:        snap.Metadata.IP,
+			UserAgent: snap.Metadata.UserAgent,
+			Location:  jsonLocation(snap.Metadata.Location),
+			Headers:   snap.Metadata.Headers,
+		},
+		LastAccessed: snap.LastAccessed,
+		UpdatedAt:    snap.UpdatedAt,
+		Owner:        snap.Owner,
+		Values:       make(map[string]interface{}, len(snap.Values)),
+		StepUp:       snap.StepUp,
+		ClassExpires: snap.ClassExpires,
+	}
+	if !snap.LeaseExpires.IsZero() {
+		js.LeaseExpires = &snap.LeaseExpires
+	}
+	if snap.LastAccess != (Access{}) {
+		js.LastAccess = &jsonAccess{IP: snap.LastAccess.IP, Location: jsonLocation(snap.LastAccess.Location), Time: snap.LastAccess.Time}
+	}
+
+	for k, v := range snap.Values {
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("JSONCodec only stores string keys, got %T", k)
+		}
+		js.Values[key] = v
+	}
+	if len(snap.KeyClasses) > 0 {
+		js.KeyClasses = make(map[string]string, len(snap.KeyClasses))
+		for k, class := range snap.KeyClasses {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("JSONCodec only stores string keys, got %T", k)
+			}
+			js.KeyClasses[key] = class
+		}
+	}
+
+	return json.Marshal(js)
+}
+
+func (JSONCodec) Decode(data []byte) (Snapshot, error) {
+	var js jsonSnapshot
+	if err := json.Unmarshal(data, &js); err != nil {
+		return Snapshot{}, err
+	}
+
+	snap := Snapshot{
+		ID:     js.ID,
+		UserID: js.UserID,
+		Metadata: Metadata{
+			CreatedAt: js.Metadata.CreatedAt,
+			IP:        js.Metadata.IP,
+			UserAgent: js.Metadata.UserAgent,
+			Location:  Location(js.Metadata.Location),
+			Headers:   js.Metadata.Headers,
+		},
+		LastAccessed: js.LastAccessed,
+		UpdatedAt:    js.UpdatedAt,
+		Owner:        js.Owner,
+		Values:       make(map[interface{}]interface{}, len(js.Values)),
+		StepUp:       js.StepUp,
+		ClassExpires: js.ClassExpires,
+	}
+	if js.LeaseExpires != nil {
+		snap.LeaseExpires = *js.LeaseExpires
+	}
+	if js.LastAccess != nil {
+		snap.LastAccess = Access{IP: js.LastAccess.IP, Location: Location(js.LastAccess.Location), Time: js.LastAccess.Time}
+	}
+
+	for k, v := range js.Values {
+		if s, ok := v.(string); ok && jsonTimeKeys[k] {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return Snapshot{}, err
+			}
+			v = t
+		}
+		snap.Values[k] = v
+	}
+	if len(js.KeyClasses) > 0 {
+		snap.KeyClasses = make(map[interface{}]string, len(js.KeyClasses))
+		for k, class := range js.KeyClasses {
+			snap.KeyClasses[k] = class
+		}
+	}
+
+	return snap, nil
+}
@@ -0,0 +1,73 @@
+package session
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestJSONCodec(t *testing.T) {
+	now := time.Now().Round(0)
+	snap := Snapshot{
+		ID:           "sessionid123",
+		UserID:       "user1",
+		Metadata:     Metadata{CreatedAt: now, IP: "10.0.0.1", Location: Location{Country: "DE"}},
+		LastAccessed: now,
+		Values:       map[interface{}]interface{}{"name": "alice", "cart": 3, "tags": []string{"a", "b"}},
+		KeyClasses:   map[interface{}]string{"name": "auth"},
+		ClassExpires: map[string]time.Time{"auth": now.Add(time.Hour)},
+	}
+
+	// Case 1: Readable Without This Package
+	data, err := JSONCodec{}.Encode(snap)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var doc struct {
+		UserID   string                 `json:"user_id"`
+		Values   map[string]interface{} `json:"values"`
+		Metadata struct {
+			IP string `json:"ip"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil || doc.UserID != "user1" || doc.Values["name"] != "alice" || doc.Metadata.IP != "10.0.0.1" {
+		t.Errorf("Expected a plain JSON document, got %s", data)
+	}
+
+	// Case 2: Round Trip With JSON Types
+	got, err := JSONCodec{}.Decode(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.UserID != "user1" || got.Values["name"] != "alice" || got.Values["cart"] != 3.0 || !got.LastAccessed.Equal(now) || got.Metadata.Location.Country != "DE" {
+		t.Errorf("Expected decoded snapshot to match, got %+v", got)
+	}
+	if tags, ok := got.Values["tags"].([]interface{}); !ok || len(tags) != 2 {
+		t.Errorf("Expected tags as []interface{}, got %T", got.Values["tags"])
+	}
+	if got.KeyClasses["name"] != "auth" || !got.ClassExpires["auth"].Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected value classes kept, got %v, %v", got.KeyClasses, got.ClassExpires)
+	}
+
+	// Case 3: Non-String Keys Rejected
+	if _, err := (JSONCodec{}).Encode(Snapshot{Values: map[interface{}]interface{}{1: "x"}}); err == nil {
+		t.Errorf("Expected error for an int key")
+	}
+	if _, err := (JSONCodec{}).Encode(Snapshot{Values: map[interface{}]interface{}{"fn": func() {}}}); err == nil {
+		t.Errorf("Expected error for a value JSON can't represent")
+	}
+
+	// Case 4: Corrupt Data
+	if _, err := (JSONCodec{}).Decode([]byte("{")); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+
+	// Case 5: Package Values Restored
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: NewBackendStore(newMapBackend(), JSONCodec{})})
+	defer sm.Close()
+	s, _ := sm.SessionCreate("sessionid123")
+	s.EnterSudo(time.Minute)
+	if s, ok := sm.session("sessionid123"); !ok || !s.InSudo() {
+		t.Errorf("Expected the sudo window kept")
+	}
+}
@@ -0,0 +1,19 @@
+package session
+
+import (
+	"errors"
+	"strings"
+)
+
+// String keys starting with this prefix hold values managed by the package
+// itself (return URL, locale, CSRF tokens, flash messages, metadata). Set
+// and Delete refuse them so user code can't clobber those values; they can
+// still be read with Get.
+const ReservedKeyPrefix = "_sm."
+
+var ErrReservedKey = errors.New("session key uses the reserved " + ReservedKeyPrefix + " prefix")
+
+func isReservedKey(key interface{}) bool {
+	k, ok := key.(string)
+	return ok && strings.HasPrefix(k, ReservedKeyPrefix)
+}
@@ -0,0 +1,37 @@
+package session
+
+import "testing"
+
+func TestSession_ReservedKeys(t *testing.T) {
+	s := &Session{sd: make(dict)}
+
+	// Case 1: Set Reserved Key Refused
+	if err := s.Set("_sm.locale", "xx"); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey, got %v", err)
+	}
+	if s.Exist("_sm.locale") {
+		t.Errorf("Expected _sm.locale to not be set")
+	}
+
+	// Case 2: Managed Value Readable but Not Deletable
+	s.SetLocale("en-GB")
+	if val := s.Get(localeKey); val != "en-GB" {
+		t.Errorf("Expected en-GB, got %v", val)
+	}
+	if err := s.Delete(localeKey); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey, got %v", err)
+	}
+	if s.Locale() != "en-GB" {
+		t.Errorf("Expected locale to survive Delete")
+	}
+
+	// Case 3: Other Keys Unaffected
+	for _, key := range []interface{}{"sm.locale", "_smx", 42, nil} {
+		if err := s.Set(key, "value"); err != nil {
+			t.Errorf("Expected no error for %v, got %v", key, err)
+		}
+		if err := s.Delete(key); err != nil {
+			t.Errorf("Expected no error for %v, got %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package session
+
+import (
+	"runtime/debug"
+	"sort"
+	"time"
+)
+
+// Age after which a sampled session that was never read is reported by
+// LeakReport when Config.LeakThreshold is not set
+const DefaultLeakThreshold = 10 * time.Minute
+
+// A session created but never read again, with the stack that created it.
+// Reports end up in logs, so the session is identified by its AffinityHash.
+type LeakedSession struct {
+	Handle    string
+	CreatedAt time.Time
+	Stack     string
+}
+
+// Record the creation stack of every Config.LeakSampleRate-th session
+func (sm *SessionManager) sampleLeak(s *Session) {
+	rate := sm.Config.LeakSampleRate
+	if rate <= 0 {
+		return
+	}
+
+	if sm.leakCounter.Add(1)%uint64(rate) == 0 {
+		s.createStack = debug.Stack()
+	}
+}
+
+// Sampled sessions that have not been read since they were created more than
+// Config.LeakThreshold ago, oldest first. These usually point at endpoints
+// that create a session on every request without the client keeping it.
+func (sm *SessionManager) LeakReport() []LeakedSession {
+	threshold := sm.Config.LeakThreshold
+	if threshold <= 0 {
+		threshold = DefaultLeakThreshold
+	}
+	cutoff := time.Now().Add(-threshold)
+
+	sm.rlock()
+	var leaks []LeakedSession
+	sm.each(func(_ string, s *Session) {
+		if s.createStack == nil || s.reads.Load() != 0 {
+			return
+		}
+
+		s.lock.RLock()
+		if s.meta.CreatedAt.Before(cutoff) {
+			leaks = append(leaks, LeakedSession{AffinityHash(s.sessionId), s.meta.CreatedAt, string(s.createStack)})
+		}
+		s.lock.RUnlock()
+	})
+	sm.lock.RUnlock()
+
+	sort.Slice(leaks, func(i, j int) bool {
+		return leaks[i].CreatedAt.Before(leaks[j].CreatedAt)
+	})
+
+	return leaks
+}
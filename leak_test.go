@@ -0,0 +1,61 @@
+package session
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_LeakReport(t *testing.T) {
+	sm := New()
+	sm.Config.LeakSampleRate = 2
+	sm.Config.LeakThreshold = time.Minute
+
+	for i := 0; i < 4; i++ {
+		sm.SessionCreate(fmt.Sprintf("sessionid%d", i))
+	}
+	sm.each(func(_ string, s *Session) {
+		s.meta.CreatedAt = time.Now().Add(-2 * time.Minute)
+	})
+
+	// Case 1: Only Sampled Sessions Reported
+	leaks := sm.LeakReport()
+	if len(leaks) != 2 {
+		t.Fatalf("Expected 2 leaks, got %v", len(leaks))
+	}
+	if !strings.Contains(leaks[0].Stack, "TestSessionManager_LeakReport") {
+		t.Errorf("Expected creation stack to point at the test, got %v", leaks[0].Stack)
+	}
+	for _, leak := range leaks {
+		if leak.Handle != AffinityHash("sessionid1") && leak.Handle != AffinityHash("sessionid3") {
+			t.Errorf("Expected the handle of a sampled session, got %v", leak.Handle)
+		}
+	}
+
+	// Case 2: Read Sessions Are Not Leaks
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid1"})
+	sm.SessionRead(req)
+	if leaks = sm.LeakReport(); len(leaks) != 1 || leaks[0].Handle != AffinityHash("sessionid3") {
+		t.Errorf("Expected only sessionid3 leaked, got %v", leaks)
+	}
+
+	// Case 3: Young Sessions Are Not Leaks
+	sm.SessionCreate("sessionid4")
+	sm.SessionCreate("sessionid5")
+	if leaks = sm.LeakReport(); len(leaks) != 1 {
+		t.Errorf("Expected 1 leak, got %v", len(leaks))
+	}
+
+	// Case 4: Sampling Disabled
+	smOff := New()
+	smOff.SessionCreate("sessionid123")
+	smOff.Config.LeakThreshold = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if leaks = smOff.LeakReport(); len(leaks) != 0 {
+		t.Errorf("Expected no leaks, got %v", leaks)
+	}
+}
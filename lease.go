@@ -0,0 +1,172 @@
+package session
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Peer able to take writes for the sessions it owns, see
+// Config.LeaseDuration
+type OwnerPeer interface {
+	Peer
+
+	// Config.NodeID of the peer
+	NodeID() string
+
+	// Apply a write to a session the peer owns, answered with
+	// SessionManager.ApplyWrite
+	ForwardWrite(w Write) error
+}
+
+type WriteOp int
+
+const (
+	WriteSet WriteOp = iota
+	WriteDelete
+	WriteUserID       // Value holds the user id
+	WriteDeletePrefix // Key holds the prefix
+	WriteBatch        // Value holds the []Write of a Session.Update
+)
+
+// A session write forwarded to the owner of the session
+type Write struct {
+	Op        WriteOp
+	SessionID string
+	Key       interface{}
+	Value     interface{}
+}
+
+// Ownership lease counters, see Config.LeaseDuration
+type LeaseStats struct {
+	Owned         int    // sessions this instance holds a live lease for
+	Forwarded     uint64 // writes forwarded to the owner
+	Stolen        uint64 // leases taken over from an unreachable owner
+	ForwardErrors uint64 // failed forwards, each followed by a steal
+}
+
+type leaseCounters struct {
+	forwarded     atomic.Uint64
+	stolen        atomic.Uint64
+	forwardErrors atomic.Uint64
+}
+
+func (sm *SessionManager) pinning() bool {
+	return sm.Config.LeaseDuration > 0 && sm.Config.NodeID != ""
+}
+
+// Take the lease on s for this instance. Caller must hold s.lock.
+func (sm *SessionManager) lease(s *Session) {
+	s.owner = sm.Config.NodeID
+	s.leaseExpires = time.Now().Add(sm.Config.LeaseDuration)
+}
+
+func (sm *SessionManager) ownerPeer(node string) OwnerPeer {
+	for _, p := range sm.Config.Peers {
+		if op, ok := p.(OwnerPeer); ok && op.NodeID() == node {
+			return op
+		}
+	}
+	return nil
+}
+
+// Forward w to the owner of s if another instance holds a live lease on it.
+// This instance takes the lease instead if the session is unowned, the lease
+// ran out or the owner can't be reached. Returns whether w was forwarded, in
+// which case it is applied locally without replicating it.
+func (s *Session) forward(w Write) bool {
+	sm := s.manager
+	if sm == nil || !sm.pinning() {
+		return false
+	}
+
+	s.lock.Lock()
+	owner := s.owner
+	if owner == "" || owner == sm.Config.NodeID || time.Now().After(s.leaseExpires) {
+		sm.lease(s)
+		s.lock.Unlock()
+		return false
+	}
+	w.SessionID = s.sessionId
+	s.lock.Unlock()
+
+	p := sm.ownerPeer(owner)
+	if p != nil {
+		err := p.ForwardWrite(w)
+		if err == nil {
+			sm.leases.forwarded.Add(1)
+			return true
+		}
+		sm.leases.forwardErrors.Add(1)
+	}
+
+	s.lock.Lock()
+	sm.lease(s)
+	s.lock.Unlock()
+	sm.leases.stolen.Add(1)
+
+	return false
+}
+
+// Apply a write forwarded by a peer to a session this instance owns, to
+// answer OwnerPeer.ForwardWrite. The write is replicated as usual.
+func (sm *SessionManager) ApplyWrite(w Write) error {
+	s, ok := sm.session(w.SessionID)
+	if !ok || s == nil {
+		return errors.New("session not found")
+	}
+
+	switch w.Op {
+	case WriteSet:
+		return s.set(w.Key, w.Value)
+	case WriteDelete:
+		return s.delete(w.Key)
+	case WriteUserID:
+		uid, _ := w.Value.(string)
+		s.SetUserID(uid)
+		return nil
+	case WriteDeletePrefix:
+		prefix, _ := w.Key.(string)
+		return s.DeletePrefix(prefix)
+	case WriteBatch:
+		writes, _ := w.Value.([]Write)
+		return s.Update(func(tx Tx) error {
+			for _, bw := range writes {
+				var err error
+				if bw.Op == WriteDelete {
+					err = tx.Delete(bw.Key)
+				} else {
+					err = tx.Set(bw.Key, bw.Value)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	default:
+		return errors.New("unknown write op")
+	}
+}
+
+func (sm *SessionManager) LeaseStats() LeaseStats {
+	stats := LeaseStats{
+		Forwarded:     sm.leases.forwarded.Load(),
+		Stolen:        sm.leases.stolen.Load(),
+		ForwardErrors: sm.leases.forwardErrors.Load(),
+	}
+
+	sm.rlock()
+	defer sm.lock.RUnlock()
+
+	now := time.Now()
+	sm.each(func(_ string, s *Session) {
+		s.lock.RLock()
+		if s.owner == sm.Config.NodeID && now.Before(s.leaseExpires) {
+			stats.Owned++
+		}
+		s.lock.RUnlock()
+	})
+
+	return stats
+}
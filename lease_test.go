@@ -0,0 +1,105 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+type unreachableOwner struct {
+	failingPeer
+	node string
+}
+
+func (p unreachableOwner) NodeID() string {
+	return p.node
+}
+
+func (unreachableOwner) ForwardWrite(Write) error {
+	return errPeerUnreachable
+}
+
+func TestSessionManager_Leases(t *testing.T) {
+	config := func(node string) SessionManagerConfig {
+		return SessionManagerConfig{MaxLifetime: time.Hour, NodeID: node, LeaseDuration: time.Minute, WriteConsistency: ConsistencyAll}
+	}
+	a, b := New(config("a")), New(config("b"))
+	a.Config.Peers = []Peer{managerPeer{b}}
+	b.Config.Peers = []Peer{managerPeer{a}}
+
+	// Case 1: Creator Owns the Session
+	s, _ := a.SessionCreate("sessionid123")
+	if stats := a.LeaseStats(); stats.Owned != 1 {
+		t.Errorf("Expected a to own 1 session, got %+v", stats)
+	}
+	remote := b.stored("sessionid123")
+	if remote == nil || remote.snapshot().Owner != "a" {
+		t.Fatalf("Expected the replicated copy to be owned by a")
+	}
+
+	// Case 2: Writes on Other Instances Are Forwarded
+	if err := remote.Set("cart", 1); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if s.Get("cart") != 1 || remote.Get("cart") != 1 {
+		t.Errorf("Expected the write on both instances, got %v and %v", s.Get("cart"), remote.Get("cart"))
+	}
+	remote.SetUserID("user1")
+	remote.Delete("cart")
+	if s.UserID() != "user1" || s.Exist("cart") {
+		t.Errorf("Expected forwarded user id and delete")
+	}
+	if stats := b.LeaseStats(); stats.Forwarded != 3 || stats.Owned != 0 {
+		t.Errorf("Expected 3 forwarded writes, got %+v", stats)
+	}
+
+	// Case 3: Unreachable Owner Loses the Lease
+	b.Config.Peers = []Peer{unreachableOwner{node: "a"}}
+	b.Config.WriteConsistency = ConsistencyOne
+	remote.Set("cart", 2)
+	if stats := b.LeaseStats(); stats.Stolen != 1 || stats.ForwardErrors != 1 || stats.Owned != 1 {
+		t.Errorf("Expected b to steal the lease, got %+v", stats)
+	}
+
+	// Case 4: Expired Lease Is Taken Without a Forward
+	s.lock.Lock()
+	s.owner, s.leaseExpires = "b", time.Now().Add(-time.Second)
+	s.lock.Unlock()
+	a.Config.Peers = nil
+	s.Set("cart", 3)
+	if stats := a.LeaseStats(); stats.Owned != 1 || stats.Stolen != 0 {
+		t.Errorf("Expected a to take the expired lease, got %+v", stats)
+	}
+}
+
+func TestSessionManager_ApplyWrite(t *testing.T) {
+	sm := New()
+	s, _ := sm.SessionCreate("sessionid123")
+
+	// Case 1: Set, User ID and Delete
+	sm.ApplyWrite(Write{Op: WriteSet, SessionID: "sessionid123", Key: "cart", Value: 1})
+	sm.ApplyWrite(Write{Op: WriteUserID, SessionID: "sessionid123", Value: "user1"})
+	if s.Get("cart") != 1 || s.UserID() != "user1" {
+		t.Errorf("Expected applied writes")
+	}
+	sm.ApplyWrite(Write{Op: WriteDelete, SessionID: "sessionid123", Key: "cart"})
+	if s.Exist("cart") {
+		t.Errorf("Expected cart to be deleted")
+	}
+
+	// Case 2: Batch of an Update
+	sm.ApplyWrite(Write{Op: WriteBatch, SessionID: "sessionid123", Value: []Write{
+		{Op: WriteSet, Key: "items", Value: 2},
+		{Op: WriteSet, Key: "total", Value: 20},
+	}})
+	if s.Get("items") != 2 || s.Get("total") != 20 {
+		t.Errorf("Expected applied batch")
+	}
+
+	// Case 3: Unknown Session or Op
+	if err := sm.ApplyWrite(Write{SessionID: "unknown"}); err == nil {
+		t.Errorf("Expected error for an unknown session")
+	}
+	if err := sm.ApplyWrite(Write{Op: 42, SessionID: "sessionid123"}); err == nil {
+		t.Errorf("Expected error for an unknown op")
+	}
+}
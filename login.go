@@ -0,0 +1,112 @@
+package session
+
+import (
+	"net/http"
+	"strings"
+)
+
+const returnURLKey = "_sm.return_url"
+
+// Verifies the credentials of a login request, e.g. the username and
+// password form fields, returning the id of the user they belong to
+type AuthFunc func(r *http.Request) (userID string, err error)
+
+// Redirect to Config.LoginURL, remembering the requested URL in the session
+// so the login handler can send the user back with ConsumeReturnURL
+func (sm *SessionManager) RedirectToLogin(w http.ResponseWriter, r *http.Request) {
+	if s, _ := sm.requestSession(r); s != nil {
+		s.rememberURL(r)
+	}
+
+	http.Redirect(w, r, sm.Config.LoginURL, http.StatusFound)
+}
+
+// Store the URL of r for ConsumeReturnURL
+func (s *Session) rememberURL(r *http.Request) {
+	// Only GET requests can be replayed by a redirect
+	if r.Method == http.MethodGet {
+		s.set(returnURLKey, r.URL.RequestURI())
+	}
+}
+
+// Return and forget the URL stored by RedirectToLogin, empty if there is
+// none. Only local paths are returned so it is safe to redirect to.
+func (s *Session) ConsumeReturnURL() string {
+	u, _ := s.Get(returnURLKey).(string)
+	s.delete(returnURLKey)
+
+	if !strings.HasPrefix(u, "/") || strings.HasPrefix(u, "//") || strings.HasPrefix(u, "/\\") {
+		return ""
+	}
+
+	return u
+}
+
+// LoginHandler handles login form POSTs for small apps: it checks the CSRF
+// token of the session the login form was rendered with, verifies the
+// credentials with auth, moves the session to a new id (see
+// SessionRegenerate), binds it to the user and redirects to the URL
+// remembered by RedirectToLogin, "/" if there is none. Requests without a
+// session or valid token get 403, wrong credentials 401.
+func (sm *SessionManager) LoginHandler(auth AuthFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		s, r := sm.requestSession(r)
+		if s == nil || !s.checkCSRF(r) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		uid, err := auth(r)
+		if err != nil || uid == "" {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		if s, err = sm.SessionRegenerate(w, r); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		s.SetUserID(uid)
+		// A token seen before login must not stay valid after it
+		s.delete(csrfKey)
+
+		to := s.ConsumeReturnURL()
+		if to == "" {
+			to = "/"
+		}
+		http.Redirect(w, r, to, http.StatusSeeOther)
+	})
+}
+
+// LogoutHandler handles logout POSTs carrying the CSRF token of the
+// session: it destroys the session, clears the cookie and redirects to "/".
+// Requests without a session or valid token get 403.
+func (sm *SessionManager) LogoutHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		s, r := sm.requestSession(r)
+		if s == nil || !s.checkCSRF(r) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		if err := sm.SessionDestroy(s.ID()); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		sm.ClearCookie(w)
+
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	})
+}
@@ -0,0 +1,182 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_RedirectToLogin(t *testing.T) {
+	sm := New()
+	sm.Config.LoginURL = "/login"
+	s, _ := sm.SessionCreate("sessionid123")
+
+	// Case 1: Return URL Stored
+	req := httptest.NewRequest("GET", "/orders?page=2", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+	rec := httptest.NewRecorder()
+	sm.RedirectToLogin(rec, req)
+
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/login" {
+		t.Errorf("Expected redirect to /login, got %v %v", rec.Code, rec.Header().Get("Location"))
+	}
+	if u := s.ConsumeReturnURL(); u != "/orders?page=2" {
+		t.Errorf("Expected /orders?page=2, got %v", u)
+	}
+
+	// Case 2: Return URL Consumed Once
+	if u := s.ConsumeReturnURL(); u != "" {
+		t.Errorf("Expected empty return URL, got %v", u)
+	}
+
+	// Case 3: Non-GET Requests Are Not Stored
+	req = httptest.NewRequest("POST", "/orders", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+	sm.RedirectToLogin(httptest.NewRecorder(), req)
+	if u := s.ConsumeReturnURL(); u != "" {
+		t.Errorf("Expected empty return URL, got %v", u)
+	}
+
+	// Case 4: No Session
+	req = httptest.NewRequest("GET", "/orders", nil)
+	rec = httptest.NewRecorder()
+	sm.RedirectToLogin(rec, req)
+	if rec.Code != http.StatusFound {
+		t.Errorf("Expected 302, got %v", rec.Code)
+	}
+
+	// Case 5: RequireSession Stores Return URL
+	handler := sm.RequireKeys("user_id")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req = httptest.NewRequest("GET", "/settings", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if u := s.ConsumeReturnURL(); u != "/settings" {
+		t.Errorf("Expected /settings, got %v", u)
+	}
+}
+
+func TestSession_ConsumeReturnURL(t *testing.T) {
+	s := &Session{sd: make(dict)}
+
+	// Case 1: Off-Site URLs Are Dropped
+	for _, u := range []string{"https://evil.example", "//evil.example", "/\\evil.example", "javascript:alert(1)"} {
+		s.set(returnURLKey, u)
+		if got := s.ConsumeReturnURL(); got != "" {
+			t.Errorf("Expected %v to be dropped, got %v", u, got)
+		}
+	}
+
+	// Case 2: Non-String Value
+	s.set(returnURLKey, 42)
+	if got := s.ConsumeReturnURL(); got != "" {
+		t.Errorf("Expected empty return URL, got %v", got)
+	}
+
+	// Case 3: Consumption Persisted
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: NewBackendStore(newMapBackend(), nil)})
+	s, _ = sm.SessionCreate("sessionid123")
+	s.set(returnURLKey, "/orders")
+	s.ConsumeReturnURL()
+	if stored := sm.stored("sessionid123"); stored.Exist(returnURLKey) {
+		t.Errorf("Expected the consumed return URL to be deleted from the store")
+	}
+}
+
+func TestSessionManager_LoginHandler(t *testing.T) {
+	sm := New()
+	login := sm.LoginHandler(func(r *http.Request) (string, error) {
+		if r.PostFormValue("password") != "secret" {
+			return "", errors.New("wrong password")
+		}
+		return "user1", nil
+	})
+	s, _ := sm.SessionCreate("sessionid123")
+	s.set(returnURLKey, "/orders")
+	token := s.CSRFToken()
+
+	post := func(h http.Handler, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: s.ID()})
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Case 1: Missing or Wrong CSRF Token
+	if rec := post(login, url.Values{"password": {"secret"}}); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403, got %v", rec.Code)
+	}
+	if rec := post(login, url.Values{"password": {"secret"}, CSRFField: {"forged"}}); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403, got %v", rec.Code)
+	}
+
+	// Case 2: Wrong Credentials
+	if rec := post(login, url.Values{"password": {"guess"}, CSRFField: {token}}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %v", rec.Code)
+	}
+
+	// Case 3: Login Regenerates and Binds the Session
+	rec := post(login, url.Values{"password": {"secret"}, CSRFField: {token}})
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/orders" {
+		t.Errorf("Expected redirect to /orders, got %v %v", rec.Code, rec.Header().Get("Location"))
+	}
+	if sm.SessionExist("sessionid123") || s.ID() == "sessionid123" || s.UserID() != "user1" {
+		t.Errorf("Expected session moved and bound to user1, got %v %v", s.ID(), s.UserID())
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value != s.ID() {
+		t.Errorf("Expected cookie with the new id, got %v", cookies)
+	}
+
+	// Case 4: CSRF Token Rotated
+	if s.CSRFToken() == token {
+		t.Errorf("Expected a new CSRF token after login")
+	}
+
+	// Case 5: Only POST
+	rec = httptest.NewRecorder()
+	login.ServeHTTP(rec, httptest.NewRequest("GET", "/login", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %v", rec.Code)
+	}
+}
+
+func TestSessionManager_LogoutHandler(t *testing.T) {
+	sm := New()
+	logout := sm.LogoutHandler()
+	s, _ := sm.SessionCreate("sessionid123")
+
+	// Case 1: Missing Token
+	req := httptest.NewRequest("POST", "/logout", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+	rec := httptest.NewRecorder()
+	logout.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || !sm.SessionExist("sessionid123") {
+		t.Errorf("Expected 403 with the session kept, got %v", rec.Code)
+	}
+
+	// Case 2: Token in Header Logs Out
+	req = httptest.NewRequest("POST", "/logout", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+	req.Header.Set(CSRFHeader, s.CSRFToken())
+	rec = httptest.NewRecorder()
+	logout.ServeHTTP(rec, req)
+	if rec.Code != http.StatusSeeOther || sm.SessionExist("sessionid123") {
+		t.Errorf("Expected redirect with the session destroyed, got %v", rec.Code)
+	}
+	if c := rec.Result().Cookies(); len(c) != 1 || c[0].MaxAge != -1 {
+		t.Errorf("Expected cookie cleared, got %v", c)
+	}
+
+	// Case 3: No Session
+	rec = httptest.NewRecorder()
+	logout.ServeHTTP(rec, httptest.NewRequest("POST", "/logout", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403, got %v", rec.Code)
+	}
+}
@@ -0,0 +1,143 @@
+package session
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"time"
+)
+
+// ManagerPool creates per-tenant managers on demand. They share one cleaner
+// goroutine, the stores built by one factory, typically over a single
+// client, and one metrics endpoint, so thousands of tenants don't multiply
+// background goroutines or connections.
+type ManagerPool struct {
+	lock      sync.Mutex
+	managers  map[string]*SessionManager
+	config    SessionManagerConfig
+	store     func(tenant string) Store
+	scheduler *CleanerScheduler
+	ownsSched bool
+}
+
+// Pool creating every manager with config. store builds the store of a
+// tenant, e.g. a redistore with a per-tenant prefix over a shared client;
+// nil keeps each tenant in a MemoryStore. Managers join config.Scheduler,
+// or a scheduler of the pool's own if it is nil.
+func NewManagerPool(config SessionManagerConfig, store func(tenant string) Store) *ManagerPool {
+	mp := &ManagerPool{
+		managers:  make(map[string]*SessionManager),
+		config:    config,
+		store:     store,
+		scheduler: config.Scheduler,
+	}
+	if mp.scheduler == nil {
+		mp.scheduler = NewCleanerScheduler(time.Second)
+		mp.ownsSched = true
+	}
+
+	return mp
+}
+
+// Manager of tenant, created on first use
+func (mp *ManagerPool) Manager(tenant string) *SessionManager {
+	mp.lock.Lock()
+	defer mp.lock.Unlock()
+
+	if sm, ok := mp.managers[tenant]; ok {
+		return sm
+	}
+
+	config := mp.config
+	config.Scheduler = mp.scheduler
+	config.Store = nil
+	if mp.store != nil {
+		config.Store = mp.store(tenant)
+	}
+
+	sm := New(config)
+	mp.managers[tenant] = sm
+	return sm
+}
+
+// Close the manager of tenant and drop it from the pool. Its sessions stay
+// in the store for the next Manager call unless it is a MemoryStore.
+func (mp *ManagerPool) Remove(tenant string) {
+	mp.lock.Lock()
+	sm, ok := mp.managers[tenant]
+	delete(mp.managers, tenant)
+	mp.lock.Unlock()
+
+	if ok {
+		sm.Close()
+	}
+}
+
+// Number of tenants with a manager
+func (mp *ManagerPool) Len() int {
+	mp.lock.Lock()
+	defer mp.lock.Unlock()
+
+	return len(mp.managers)
+}
+
+// Close every manager, and stop the scheduler if the pool started it
+func (mp *ManagerPool) Close() error {
+	mp.lock.Lock()
+	managers := mp.managers
+	mp.managers = make(map[string]*SessionManager)
+	mp.lock.Unlock()
+
+	for _, sm := range managers {
+		sm.Close()
+	}
+	if mp.ownsSched {
+		mp.scheduler.Stop()
+	}
+
+	return nil
+}
+
+// Write the metrics of every tenant, summed, in the OpenMetrics text
+// format. Totals keep the number of series independent of the number of
+// tenants.
+func (mp *ManagerPool) WriteOpenMetrics(w io.Writer) error {
+	mp.lock.Lock()
+	managers := make([]*SessionManager, 0, len(mp.managers))
+	for _, sm := range mp.managers {
+		managers = append(managers, sm)
+	}
+	mp.lock.Unlock()
+
+	var active int
+	var c ContentionStats
+	var p PoolStats
+	for _, sm := range managers {
+		active += sm.SessionCount()
+
+		mc := sm.ContentionStats()
+		c.LockAcquisitions += mc.LockAcquisitions
+		c.LockWaitTotal += mc.LockWaitTotal
+		c.CleanerRuns += mc.CleanerRuns
+		c.CleanerPauseTotal += mc.CleanerPauseTotal
+
+		ps := sm.PoolStats()
+		p.Gets += ps.Gets
+		p.Puts += ps.Puts
+		p.Allocs += ps.Allocs
+	}
+
+	mw := metricsWriter{bufio.NewWriter(w)}
+	mw.gauge("session_tenants", "Tenants with a manager in the pool.", float64(len(managers)))
+	mw.gauge("session_active", "Sessions in the session tables of all tenants.", float64(active))
+	mw.counter("session_lock_acquisitions", "Acquisitions of the session table locks.", float64(c.LockAcquisitions))
+	mw.counter("session_lock_wait_seconds", "Time spent waiting for the session table locks.", c.LockWaitTotal.Seconds())
+	mw.counter("session_cleaner_runs", "Completed cleaner runs.", float64(c.CleanerRuns))
+	mw.counter("session_cleaner_pause_seconds", "Time cleaner runs held the session table locks.", c.CleanerPauseTotal.Seconds())
+	mw.counter("session_pool_gets", "Sessions handed out by the pools.", float64(p.Gets))
+	mw.counter("session_pool_puts", "Sessions returned to the pools.", float64(p.Puts))
+	mw.counter("session_pool_allocs", "Sessions the pools had to allocate.", float64(p.Allocs))
+	io.WriteString(mw.w, "# EOF\n")
+
+	return mw.w.Flush()
+}
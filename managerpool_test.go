@@ -0,0 +1,64 @@
+package session
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManagerPool(t *testing.T) {
+	backend := newMapBackend()
+	var built []string
+	mp := NewManagerPool(SessionManagerConfig{CleanerInterval: time.Minute, MaxLifetime: time.Hour}, func(tenant string) Store {
+		built = append(built, tenant)
+		if tenant == "acme" {
+			return NewBackendStore(backend, nil)
+		}
+		return nil
+	})
+	defer mp.Close()
+
+	// Case 1: Managers Created On Demand And Reused
+	acme := mp.Manager("acme")
+	if mp.Manager("acme") != acme || mp.Len() != 1 {
+		t.Errorf("Expected the acme manager to be reused, got %v managers", mp.Len())
+	}
+	globex := mp.Manager("globex")
+	if globex == acme || mp.Len() != 2 || len(built) != 2 {
+		t.Errorf("Expected 2 managers and 2 stores, got %v and %v", mp.Len(), built)
+	}
+
+	// Case 2: Tenants Isolated
+	acme.SessionCreate("sessionid123")
+	if globex.SessionExist("sessionid123") {
+		t.Errorf("Expected sessionid123 not to exist for globex")
+	}
+	if n, _ := backend.Len(); n != 1 {
+		t.Errorf("Expected acme session in its store, got %v", n)
+	}
+
+	// Case 3: One Shared Cleaner
+	if mp.scheduler.Len() != 2 || acme.Config.Scheduler != globex.Config.Scheduler {
+		t.Errorf("Expected both managers on the pool scheduler, got %v", mp.scheduler.Len())
+	}
+
+	// Case 4: Summed Metrics
+	globex.SessionCreate("sessionid456")
+	var buf bytes.Buffer
+	if err := mp.WriteOpenMetrics(&buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "session_tenants 2\n") || !strings.Contains(buf.String(), "session_active 2\n") || !strings.HasSuffix(buf.String(), "# EOF\n") {
+		t.Errorf("Expected 2 tenants and 2 sessions, got %v", buf.String())
+	}
+
+	// Case 5: Removed Tenant Reloaded From Its Store
+	mp.Remove("acme")
+	if mp.Len() != 1 || mp.scheduler.Len() != 1 {
+		t.Errorf("Expected 1 manager, got %v", mp.Len())
+	}
+	if !mp.Manager("acme").SessionExist("sessionid123") {
+		t.Errorf("Expected sessionid123 to be read back from the store")
+	}
+}
@@ -0,0 +1,134 @@
+// Package memcachestore keeps sessions in memcached, so applications already
+// running it for caching can share sessions between replicas. Entries expire
+// through the memcached expiration, refreshed to Config.MaxLifetime on every
+// write. memcached cannot enumerate its keys, so the store does not list
+// sessions: SessionCount reports zero and admin listings are empty.
+package memcachestore
+
+import (
+	"errors"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+)
+
+// Key prefix used when Options.Prefix is empty
+const DefaultPrefix = "session:"
+
+// Longest key memcached accepts
+const MaxKeyLength = 250
+
+// Expirations longer than this are sent as a Unix time, as memcached reads
+// relative ones only up to 30 days
+const maxRelativeExpiration = 30 * 24 * time.Hour
+
+// Returned by Scan and Len, memcached has no way to list keys
+var ErrNoScan = errors.New("memcached cannot enumerate sessions")
+
+// Client is the subset of memcached commands the store needs, implemented
+// by a thin adapter over the application's memcached client
+type Client interface {
+	// get key, nil on a cache miss
+	Get(key string) ([]byte, error)
+
+	// set key with the expiration as memcached reads it: seconds from now,
+	// or a Unix time if longer than 30 days
+	Set(key string, value []byte, expiration int32) error
+
+	// delete key, nil on a cache miss
+	Delete(key string) error
+}
+
+type Options struct {
+	// Prepended to session ids to build keys, DefaultPrefix if empty
+	Prefix string
+
+	// Serialization of sessions, session.GobCodec if nil
+	Codec session.Codec
+}
+
+// Backend implements session.Backend on top of a Client
+type Backend struct {
+	client Client
+	prefix string
+}
+
+// Store keeping sessions in memcached through client. Pass it as
+// Config.Store.
+func New(client Client, opts Options) *session.BackendStore {
+	return session.NewBackendStore(NewBackend(client, opts.Prefix), opts.Codec)
+}
+
+func NewBackend(client Client, prefix string) *Backend {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &Backend{client: client, prefix: prefix}
+}
+
+func (b *Backend) Load(sid string) ([]byte, error) {
+	key, err := b.key(sid)
+	if err != nil {
+		return nil, err
+	}
+	return b.client.Get(key)
+}
+
+func (b *Backend) Save(r session.Record) error {
+	key, err := b.key(r.ID)
+	if err != nil {
+		return err
+	}
+	return b.client.Set(key, r.Data, expiration(r.ExpiresAt))
+}
+
+func (b *Backend) Remove(sid string) error {
+	key, err := b.key(sid)
+	if err != nil {
+		return err
+	}
+	return b.client.Delete(key)
+}
+
+func (b *Backend) Scan(fn func(sid string, data []byte) bool) error {
+	return ErrNoScan
+}
+
+func (b *Backend) Len() (int, error) {
+	return 0, ErrNoScan
+}
+
+// Entries expire through the memcached expiration
+func (b *Backend) SelfExpiring() bool {
+	return true
+}
+
+// Key of sid, which memcached limits to MaxKeyLength bytes without spaces
+// or control characters
+func (b *Backend) key(sid string) (string, error) {
+	key := b.prefix + sid
+	if len(key) > MaxKeyLength {
+		return "", errors.New("memcached key is too long")
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return "", errors.New("memcached key contains a space or control character")
+		}
+	}
+	return key, nil
+}
+
+// Expiration of an entry to be dropped at t, at least a second from now as
+// memcached reads zero as never
+func expiration(t time.Time) int32 {
+	ttl := time.Until(t)
+	if ttl > maxRelativeExpiration {
+		return int32(t.Unix())
+	}
+
+	seconds := int32((ttl + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
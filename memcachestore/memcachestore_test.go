@@ -0,0 +1,141 @@
+package memcachestore
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+)
+
+// In-memory stand-in for memcached recording the expiration of every set
+type fakeMemcache struct {
+	lock        sync.Mutex
+	data        map[string][]byte
+	expirations map[string]int32
+}
+
+func newFakeMemcache() *fakeMemcache {
+	return &fakeMemcache{data: make(map[string][]byte), expirations: make(map[string]int32)}
+}
+
+func (f *fakeMemcache) Get(key string) ([]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.data[key], nil
+}
+
+func (f *fakeMemcache) Set(key string, value []byte, expiration int32) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.data[key] = value
+	f.expirations[key] = expiration
+	return nil
+}
+
+func (f *fakeMemcache) Delete(key string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.data, key)
+	delete(f.expirations, key)
+	return nil
+}
+
+func (f *fakeMemcache) expiration(key string) int32 {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.expirations[key]
+}
+
+func newManager(client Client) *session.SessionManager {
+	return session.New(session.SessionManagerConfig{
+		CleanerInterval: time.Minute,
+		MaxLifetime:     time.Hour,
+		Store:           New(client, Options{Prefix: "app:"}),
+	})
+}
+
+func TestStore(t *testing.T) {
+	mc := newFakeMemcache()
+	sm := newManager(mc)
+	defer sm.Close()
+
+	// Case 1: Session Written With MaxLifetime Expiration
+	s, err := sm.SessionCreate("sessionid123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	s.Set("key1", "value1")
+	if exp := mc.expiration("app:sessionid123"); exp < 3599 || exp > 3600 {
+		t.Errorf("Expected expiration of an hour, got %v", exp)
+	}
+
+	// Case 2: Session Shared By Another Replica
+	other := newManager(mc)
+	defer other.Close()
+	if !other.SessionExist("sessionid123") {
+		t.Fatalf("Expected session to exist on the other replica")
+	}
+	if got, _ := other.SessionExpiresIn("sessionid123"); got <= 59*time.Minute {
+		t.Errorf("Expected about an hour left, got %v", got)
+	}
+
+	// Case 3: Sessions Not Listed
+	if n := other.SessionCount(); n != 0 {
+		t.Errorf("Expected 0 sessions, got %v", n)
+	}
+
+	// Case 4: Destroy Deletes The Key
+	if err := other.SessionDestroy("sessionid123"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if data, _ := mc.Get("app:sessionid123"); data != nil {
+		t.Errorf("Expected key to be deleted")
+	}
+
+	// Case 5: Invalid Keys Rejected
+	if _, err := sm.SessionCreate("session id"); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	if _, err := sm.SessionCreate(strings.Repeat("a", MaxKeyLength)); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestBackend(t *testing.T) {
+	mc := newFakeMemcache()
+	b := NewBackend(mc, "")
+
+	// Case 1: Default Prefix
+	b.Save(session.Record{ID: "a", Data: []byte("a"), ExpiresAt: time.Now().Add(time.Minute)})
+	if data, _ := mc.Get(DefaultPrefix + "a"); string(data) != "a" {
+		t.Errorf("Expected data under the default prefix, got %q", data)
+	}
+
+	// Case 2: Long Lifetimes Sent As Unix Time
+	expires := time.Now().Add(60 * 24 * time.Hour)
+	b.Save(session.Record{ID: "b", Data: []byte("b"), ExpiresAt: expires})
+	if exp := mc.expiration(DefaultPrefix + "b"); int64(exp) != expires.Unix() {
+		t.Errorf("Expected %v, got %v", expires.Unix(), exp)
+	}
+
+	// Case 3: Nearly Expired Entries Kept A Second
+	b.Save(session.Record{ID: "c", Data: []byte("c"), ExpiresAt: time.Now().Add(time.Millisecond)})
+	if exp := mc.expiration(DefaultPrefix + "c"); exp != 1 {
+		t.Errorf("Expected 1, got %v", exp)
+	}
+
+	// Case 4: No Enumeration
+	if err := b.Scan(func(string, []byte) bool { return true }); !errors.Is(err, ErrNoScan) {
+		t.Errorf("Expected ErrNoScan, got %v", err)
+	}
+	if _, err := b.Len(); !errors.Is(err, ErrNoScan) {
+		t.Errorf("Expected ErrNoScan, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package session
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type Location struct {
+	Country   string
+	City      string
+	Latitude  float64
+	Longitude float64
+}
+
+func (l Location) known() bool {
+	return l.Latitude != 0 || l.Longitude != 0
+}
+
+// Resolves the location of a client IP. Set Config.GeoResolver to have
+// sessions created from a request enriched with their location.
+type GeoResolver interface {
+	Resolve(ip string) (Location, error)
+}
+
+// Longest header value kept in Metadata.Headers
+const maxCapturedHeaderLen = 512
+
+// Information about the client a session was created for
+type Metadata struct {
+	CreatedAt time.Time
+	IP        string
+	UserAgent string
+	Location  Location
+
+	// Values of the Config.CaptureHeaders sent, by canonical name
+	Headers map[string]string
+}
+
+func (s *Session) Metadata() Metadata {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	meta := s.meta
+	if meta.Headers != nil {
+		meta.Headers = make(map[string]string, len(s.meta.Headers))
+		for name, v := range s.meta.Headers {
+			meta.Headers[name] = v
+		}
+	}
+	return meta
+}
+
+// Values of the Config.CaptureHeaders present in r, nil if none are.
+// Repeated headers are joined with commas and long values truncated.
+func (sm *SessionManager) captureHeaders(r *http.Request) map[string]string {
+	var headers map[string]string
+	for _, name := range sm.Config.CaptureHeaders {
+		name = http.CanonicalHeaderKey(name)
+		values := r.Header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+
+		v := strings.Join(values, ", ")
+		if len(v) > maxCapturedHeaderLen {
+			v = v[:maxCapturedHeaderLen]
+		}
+		if headers == nil {
+			headers = make(map[string]string, len(sm.Config.CaptureHeaders))
+		}
+		headers[name] = v
+	}
+
+	return headers
+}
+
+// Address the request came from, without the port
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Create a new session and record the client of r in its metadata
+func (sm *SessionManager) SessionCreateFromRequest(sid string, r *http.Request) (*Session, error) {
+	if sid == "" {
+		return nil, errors.New("session id is empty")
+	}
+	return sm.createFromRequest(sid, nil, r)
+}
+
+// SessionCreateFromRequest, under a generated id if sid is empty, promoting
+// guest if not nil
+func (sm *SessionManager) createFromRequest(sid string, guest *Session, r *http.Request) (*Session, error) {
+	ip := sm.clientIP(r)
+
+	var loc Location
+	if sm.Config.GeoResolver != nil && ip != "" {
+		// Location is best effort, a failed lookup leaves it empty
+		if l, err := sm.Config.GeoResolver.Resolve(ip); err == nil {
+			loc = l
+		}
+	}
+
+	headers := sm.captureHeaders(r)
+
+	s, err := sm.create(sid, guest, func(s *Session) {
+		s.meta.IP = ip
+		s.meta.UserAgent = r.UserAgent()
+		s.meta.Location = loc
+		s.meta.Headers = headers
+		s.lastAccess = Access{IP: ip, Location: loc, Time: s.meta.CreatedAt}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := sm.assessRisk(s, r); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
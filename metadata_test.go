@@ -0,0 +1,114 @@
+package session
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type geoResolverFunc func(ip string) (Location, error)
+
+func (f geoResolverFunc) Resolve(ip string) (Location, error) {
+	return f(ip)
+}
+
+func TestSessionManager_SessionCreateFromRequest(t *testing.T) {
+	sm := New()
+	sm.Config.GeoResolver = geoResolverFunc(func(ip string) (Location, error) {
+		if ip == "192.0.2.1" {
+			return Location{Country: "US", City: "Seattle"}, nil
+		}
+		return Location{}, errors.New("unknown ip")
+	})
+
+	// Case 1: Metadata Recorded with Location
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("User-Agent", "test-agent")
+
+	s, err := sm.SessionCreateFromRequest("sessionid123", req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	meta := s.Metadata()
+	if meta.IP != "192.0.2.1" || meta.UserAgent != "test-agent" {
+		t.Errorf("Expected 192.0.2.1 and test-agent, got %v and %v", meta.IP, meta.UserAgent)
+	}
+	if meta.Location.Country != "US" || meta.Location.City != "Seattle" {
+		t.Errorf("Expected US/Seattle, got %v", meta.Location)
+	}
+	if time.Since(meta.CreatedAt) > time.Second {
+		t.Errorf("Expected CreatedAt to be recent, got %v", meta.CreatedAt)
+	}
+
+	// Case 2: Failed Lookup Leaves Location Empty
+	req.RemoteAddr = "198.51.100.1:1234"
+	s, err = sm.SessionCreateFromRequest("sessionid456", req)
+	if err != nil || s.Metadata().Location != (Location{}) {
+		t.Errorf("Expected empty location, got %v, error: %v", s.Metadata().Location, err)
+	}
+
+	// Case 3: No Resolver Configured
+	sm.Config.GeoResolver = nil
+	req.RemoteAddr = "192.0.2.1:1234"
+	s, err = sm.SessionCreateFromRequest("sessionid789", req)
+	if err != nil || s.Metadata().Location != (Location{}) || s.Metadata().IP != "192.0.2.1" {
+		t.Errorf("Expected IP without location, got %v, error: %v", s.Metadata(), err)
+	}
+
+	// Case 4: Empty Session ID
+	if _, err = sm.SessionCreateFromRequest("", req); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+
+	// Case 5: Metadata Persisted
+	backend := newMapBackend()
+	sm = New(SessionManagerConfig{MaxLifetime: time.Hour, Store: NewBackendStore(backend, nil)})
+	sm.SessionCreateFromRequest("sessionid123", req)
+	if meta := sm.stored("sessionid123").Metadata(); meta.IP != "192.0.2.1" || meta.UserAgent != "test-agent" {
+		t.Errorf("Expected stored metadata, got %v", meta)
+	}
+}
+
+func TestSessionManager_CaptureHeaders(t *testing.T) {
+	sm := New()
+	sm.Config.CaptureHeaders = []string{"accept-language", "Sec-CH-UA", "Sec-CH-UA-Platform"}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Language", "de-CH")
+	req.Header.Add("Sec-CH-UA", `"Chromium";v="118"`)
+	req.Header.Add("Sec-CH-UA", `"Not=A?Brand";v="99"`)
+	req.Header.Set("Authorization", "Bearer token")
+
+	// Case 1: Allowed Headers Captured
+	s, err := sm.SessionCreateFromRequest("sessionid123", req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	headers := s.Metadata().Headers
+	if len(headers) != 2 || headers["Accept-Language"] != "de-CH" || headers["Sec-Ch-Ua"] != `"Chromium";v="118", "Not=A?Brand";v="99"` {
+		t.Errorf("Expected Accept-Language and Sec-Ch-Ua, got %v", headers)
+	}
+
+	// Case 2: Copy Returned
+	headers["Accept-Language"] = "en"
+	if s.Metadata().Headers["Accept-Language"] != "de-CH" {
+		t.Errorf("Expected de-CH, got %v", s.Metadata().Headers)
+	}
+
+	// Case 3: Long Values Truncated
+	req.Header.Set("Accept-Language", strings.Repeat("a", 2*maxCapturedHeaderLen))
+	s, _ = sm.SessionCreateFromRequest("sessionid456", req)
+	if n := len(s.Metadata().Headers["Accept-Language"]); n != maxCapturedHeaderLen {
+		t.Errorf("Expected %v bytes, got %v", maxCapturedHeaderLen, n)
+	}
+
+	// Case 4: Nothing Captured Without An Allow-List
+	sm.Config.CaptureHeaders = nil
+	s, _ = sm.SessionCreateFromRequest("sessionid789", req)
+	if s.Metadata().Headers != nil {
+		t.Errorf("Expected no headers, got %v", s.Metadata().Headers)
+	}
+}
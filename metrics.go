@@ -0,0 +1,78 @@
+package session
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Content type of the OpenMetrics text exposition format
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+type metricsWriter struct {
+	w *bufio.Writer
+}
+
+func (mw metricsWriter) gauge(name, help string, v float64) {
+	fmt.Fprintf(mw.w, "# TYPE %s gauge\n# HELP %s %s\n%s %g\n", name, name, help, name, v)
+}
+
+func (mw metricsWriter) counter(name, help string, v float64) {
+	fmt.Fprintf(mw.w, "# TYPE %s counter\n# HELP %s %s\n%s_total %g\n", name, name, help, name, v)
+}
+
+// Write a snapshot of the manager metrics in the OpenMetrics text format
+func (sm *SessionManager) WriteOpenMetrics(w io.Writer) error {
+	mw := metricsWriter{bufio.NewWriter(w)}
+
+	mw.gauge("session_active", "Sessions in the session table.", float64(sm.SessionCount()))
+
+	var values, authenticated int
+	for _, info := range sm.Sessions() {
+		values += info.Size
+		if info.UserID != "" {
+			authenticated++
+		}
+	}
+	mw.gauge("session_values", "Values set by the application across all sessions.", float64(values))
+	mw.gauge("session_authenticated", "Sessions bound to a user.", float64(authenticated))
+
+	c := sm.ContentionStats()
+	mw.counter("session_lock_acquisitions", "Acquisitions of the session table lock.", float64(c.LockAcquisitions))
+	mw.counter("session_lock_wait_seconds", "Time spent waiting for the session table lock.", c.LockWaitTotal.Seconds())
+	mw.gauge("session_lock_wait_max_seconds", "Longest wait for the session table lock.", c.LockWaitMax.Seconds())
+	mw.counter("session_cleaner_runs", "Completed cleaner runs.", float64(c.CleanerRuns))
+	mw.counter("session_cleaner_pause_seconds", "Time cleaner runs held the session table lock.", c.CleanerPauseTotal.Seconds())
+	mw.gauge("session_cleaner_last_expired", "Sessions expired by the last cleaner run.", float64(sm.cleanerLastExpired.Load()))
+
+	p := sm.PoolStats()
+	mw.counter("session_pool_gets", "Sessions handed out by the pool.", float64(p.Gets))
+	mw.counter("session_pool_puts", "Sessions returned to the pool.", float64(p.Puts))
+	mw.counter("session_pool_allocs", "Sessions the pool had to allocate.", float64(p.Allocs))
+
+	if len(sm.Config.Peers) > 0 {
+		ae := sm.AntiEntropyStats()
+		mw.counter("session_repair_runs", "Completed anti-entropy runs.", float64(ae.Runs))
+		mw.counter("session_repair_pulled", "Newer copies fetched from peers.", float64(ae.Pulled))
+		mw.counter("session_repair_pushed", "Newer copies sent to peers.", float64(ae.Pushed))
+		mw.counter("session_repair_deleted", "Destroyed sessions removed from peers.", float64(ae.Deleted))
+		mw.counter("session_repair_errors", "Failed anti-entropy peer calls.", float64(ae.Errors))
+	}
+
+	if sm.pinning() {
+		l := sm.LeaseStats()
+		mw.gauge("session_leases_owned", "Sessions this instance holds a live lease for.", float64(l.Owned))
+		mw.counter("session_leases_forwarded", "Writes forwarded to the session owner.", float64(l.Forwarded))
+		mw.counter("session_leases_stolen", "Leases taken over from an unreachable owner.", float64(l.Stolen))
+	}
+
+	fmt.Fprint(mw.w, "# EOF\n")
+	return mw.w.Flush()
+}
+
+func (sm *SessionManager) serveMetrics(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", OpenMetricsContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	sm.WriteOpenMetrics(w)
+}
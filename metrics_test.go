@@ -0,0 +1,61 @@
+package session
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_WriteOpenMetrics(t *testing.T) {
+	sm := New()
+	s, _ := sm.SessionCreate("sessionid123")
+	s.Set("key1", "value1")
+	sm.SessionCreate("sessionid456")
+
+	// Case 1: Gauges and Counters
+	var buf bytes.Buffer
+	if err := sm.WriteOpenMetrics(&buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE session_active gauge\n",
+		"session_active 2\n",
+		"# TYPE session_lock_acquisitions counter\n",
+		"session_lock_acquisitions_total ",
+		"session_pool_gets_total 0\n",
+		"session_values 1\n",
+		"session_authenticated 0\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in %v", want, out)
+		}
+	}
+	if !strings.HasSuffix(out, "# EOF\n") {
+		t.Errorf("Expected the exposition to end with # EOF")
+	}
+
+	// Case 2: Replication Metrics Only When Clustered
+	if strings.Contains(out, "session_repair_runs") || strings.Contains(out, "session_leases_owned") {
+		t.Errorf("Expected no replication metrics without peers")
+	}
+	sm.Config.Peers = []Peer{failingPeer{}}
+	sm.Config.NodeID, sm.Config.LeaseDuration = "a", time.Minute
+	buf.Reset()
+	sm.WriteOpenMetrics(&buf)
+	if !strings.Contains(buf.String(), "session_repair_runs_total 0\n") || !strings.Contains(buf.String(), "session_leases_owned 0\n") {
+		t.Errorf("Expected replication metrics, got %v", buf.String())
+	}
+
+	// Case 3: Served by Admin Handler
+	handler := sm.AdminHandler(AdminConfig{Tokens: map[string]AdminRole{"token": AdminReadOnly}})
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Type") != OpenMetricsContentType || !strings.Contains(rec.Body.String(), "session_active 2") {
+		t.Errorf("Expected OpenMetrics response, got %v", rec.Body.String())
+	}
+}
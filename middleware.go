@@ -0,0 +1,175 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Response header carrying the number of seconds left before the session expires
+const DefaultExpiryHeader = "X-Session-Expires-In"
+
+// Request header Middleware takes the request ID from
+const DefaultRequestIDHeader = "X-Request-ID"
+
+type contextKey int
+
+const (
+	sessionContextKey contextKey = iota
+	requestIDContextKey
+)
+
+// Returns the session stored in the context by Middleware, nil if there is none
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionContextKey).(*Session)
+	return s
+}
+
+// Returns the request ID stored in the context by Middleware, empty if the
+// request had none
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+func (sm *SessionManager) requestID(r *http.Request) string {
+	header := sm.Config.RequestIDHeader
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+	return r.Header.Get(header)
+}
+
+// Attach the request ID of r to s so the journal entries and events s causes
+// can be traced back to it. With concurrent requests on one session the
+// latest one is attributed.
+func (sm *SessionManager) attachRequest(r *http.Request, s *Session) *http.Request {
+	id := sm.requestID(r)
+	if id == "" {
+		return r
+	}
+
+	if s != nil {
+		s.lock.Lock()
+		s.requestId = id
+		s.lock.Unlock()
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), requestIDContextKey, id))
+}
+
+func (s *Session) requestID() string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.requestId
+}
+
+// Middleware reads the session of the incoming request and stores it in the
+// request context along with the request ID from Config.RequestIDHeader.
+// Responses to requests with a session are marked private, see
+// PrivateResponse. Requests without a valid session are passed through as
+// is.
+func (sm *SessionManager) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, err := sm.SessionRead(r)
+		if err != nil || s == nil {
+			next.ServeHTTP(w, sm.attachRequest(r, nil))
+			return
+		}
+		r = sm.attachRequest(r, s)
+		sm.trackPage(s, r)
+		sm.writeCacheHeaders(w)
+
+		if sm.Config.EnableExpiryHeader {
+			sm.writeExpiryHeader(w, s)
+		}
+
+		if sm.Config.RollingCookies && sm.Config.AutoRefreshSession {
+			sm.SetCookie(w, s)
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sessionContextKey, s)))
+	})
+}
+
+func (sm *SessionManager) writeExpiryHeader(w http.ResponseWriter, s *Session) {
+	// SessionRead refreshes the session in the background when
+	// AutoRefreshSession is set, so the full idle timeout is what remains,
+	// up to the absolute lifetime.
+	expiresIn := sm.idleTimeout()
+	if sm.Config.AbsoluteLifetime > 0 {
+		if left := time.Until(s.Metadata().CreatedAt.Add(sm.Config.AbsoluteLifetime)); left < expiresIn {
+			expiresIn = left
+		}
+		if expiresIn < 0 {
+			expiresIn = 0
+		}
+	}
+	if !sm.Config.AutoRefreshSession {
+		var err error
+		if expiresIn, err = sm.SessionExpiresIn(s.ID()); err != nil {
+			return
+		}
+	}
+
+	header := sm.Config.ExpiryHeader
+	if header == "" {
+		header = DefaultExpiryHeader
+	}
+	w.Header().Set(header, strconv.Itoa(int(expiresIn.Seconds())))
+}
+
+// Session of the request, from the context if Middleware already read it
+func (sm *SessionManager) requestSession(r *http.Request) (*Session, *http.Request) {
+	if s := FromContext(r.Context()); s != nil {
+		return s, r
+	}
+
+	s, err := sm.SessionRead(r)
+	if err != nil || s == nil {
+		return nil, r
+	}
+	r = sm.attachRequest(r, s)
+
+	return s, r.WithContext(context.WithValue(r.Context(), sessionContextKey, s))
+}
+
+// Reject the request with 401, or redirect to Config.LoginURL if set
+func (sm *SessionManager) unauthorized(w http.ResponseWriter, r *http.Request) {
+	if sm.Config.LoginURL != "" {
+		sm.RedirectToLogin(w, r)
+		return
+	}
+
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// RequireSession only lets requests with a valid session through to next
+func (sm *SessionManager) RequireSession(next http.Handler) http.Handler {
+	return sm.RequireKeys()(next)
+}
+
+// RequireKeys only lets requests through whose session has all of keys set,
+// e.g. RequireKeys("user_id") for routes that need a signed in user
+func (sm *SessionManager) RequireKeys(keys ...interface{}) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s, r := sm.requestSession(r)
+			if s == nil {
+				sm.unauthorized(w, r)
+				return
+			}
+
+			for _, key := range keys {
+				if !s.Exist(key) {
+					sm.unauthorized(w, r)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
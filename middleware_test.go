@@ -0,0 +1,211 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_Middleware(t *testing.T) {
+	sm := New()
+	sm.Config.EnableExpiryHeader = true
+	sm.SessionCreate("sessionid123")
+
+	var got *Session
+	handler := sm.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+	}))
+
+	// Case 1: Session Stored in Context and Expiry Header Set
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got == nil || got.sessionId != "sessionid123" {
+		t.Errorf("Expected sessionid123 in context, got %v", got)
+	}
+	if val := rec.Header().Get(DefaultExpiryHeader); val != "86399" && val != "86400" {
+		t.Errorf("Expected 86399 or 86400, got %v", val)
+	}
+
+	// Case 2: No Session
+	got = nil
+	req = httptest.NewRequest("GET", "/", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got != nil {
+		t.Errorf("Expected nil session in context, got %v", got)
+	}
+	if val := rec.Header().Get(DefaultExpiryHeader); val != "" {
+		t.Errorf("Expected no expiry header, got %v", val)
+	}
+
+	// Case 3: Empty Cookie Value
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: ""})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got != nil {
+		t.Errorf("Expected nil session in context, got %v", got)
+	}
+
+	// Case 4: Custom Header and Auto Refresh
+	sm.Config.ExpiryHeader = "Session-Expires-In"
+	sm.Config.AutoRefreshSession = true
+	sm.Config.MaxLifetime = 10 * time.Minute
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if val := rec.Header().Get("Session-Expires-In"); val != "600" {
+		t.Errorf("Expected 600, got %v", val)
+	}
+
+	// Case 5: Expiry Header Disabled
+	sm.Config.EnableExpiryHeader = false
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if val := rec.Header().Get("Session-Expires-In"); val != "" {
+		t.Errorf("Expected no expiry header, got %v", val)
+	}
+}
+
+func TestSessionManager_MiddlewareRollingCookies(t *testing.T) {
+	sm := New()
+	sm.Config.AutoRefreshSession = true
+	sm.SessionCreate("sessionid123")
+	handler := sm.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Case 1: Rolling Cookies Disabled
+	if cookies := serve().Result().Cookies(); len(cookies) != 0 {
+		t.Errorf("Expected no cookie, got %v", cookies)
+	}
+
+	// Case 2: Cookie Re-Issued on Refresh
+	sm.Config.RollingCookies = true
+	cookies := serve().Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value != "sessionid123" || time.Until(cookies[0].Expires) < 23*time.Hour {
+		t.Errorf("Expected re-issued cookie, got %v", cookies)
+	}
+
+	// Case 3: No Refresh Without AutoRefreshSession
+	sm.Config.AutoRefreshSession = false
+	if cookies = serve().Result().Cookies(); len(cookies) != 0 {
+		t.Errorf("Expected no cookie, got %v", cookies)
+	}
+}
+
+func TestSessionManager_RequireKeys(t *testing.T) {
+	sm := New()
+	s, _ := sm.SessionCreate("sessionid123")
+	sm.SessionCreate("sessionid456")
+	s.Set("user_id", 42)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if FromContext(r.Context()) == nil {
+			t.Errorf("Expected session in context")
+		}
+	})
+	serve := func(h http.Handler, sid string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/account", nil)
+		if sid != "" {
+			req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: sid})
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Case 1: RequireSession With Session
+	if rec := serve(sm.RequireSession(ok), "sessionid456"); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %v", rec.Code)
+	}
+
+	// Case 2: RequireSession Without Session
+	if rec := serve(sm.RequireSession(ok), ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %v", rec.Code)
+	}
+
+	// Case 3: RequireKeys With Key
+	if rec := serve(sm.RequireKeys("user_id")(ok), "sessionid123"); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %v", rec.Code)
+	}
+
+	// Case 4: RequireKeys Without Key
+	if rec := serve(sm.RequireKeys("user_id")(ok), "sessionid456"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %v", rec.Code)
+	}
+
+	// Case 5: Redirect to Login
+	sm.Config.LoginURL = "/login"
+	rec := serve(sm.RequireKeys("user_id")(ok), "sessionid456")
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/login" {
+		t.Errorf("Expected redirect to /login, got %v %v", rec.Code, rec.Header().Get("Location"))
+	}
+
+	// Case 6: Session From Middleware
+	if rec = serve(sm.Middleware(sm.RequireKeys("user_id")(ok)), "sessionid123"); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %v", rec.Code)
+	}
+}
+
+func TestSessionManager_MiddlewareRequestID(t *testing.T) {
+	sm := New()
+	sm.Config.JournalSize = 10
+	sm.SessionCreate("sessionid123")
+
+	var got string
+	handler := sm.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = RequestIDFromContext(r.Context())
+		if s := FromContext(r.Context()); s != nil {
+			s.Set("cart", 1)
+			sm.SessionDestroy(s.ID())
+		}
+	}))
+
+	// Case 1: Request Without a Session
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(DefaultRequestIDHeader, "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if got != "req-1" {
+		t.Errorf("Expected req-1 in the context, got %v", got)
+	}
+
+	// Case 2: Mutations and Events Carry the Request ID
+	s, _ := sm.session("sessionid123")
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(DefaultRequestIDHeader, "req-2")
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if journal := s.Journal(); len(journal) != 1 || journal[0].RequestID != "req-2" {
+		t.Errorf("Expected journal entry for req-2, got %v", journal)
+	}
+	if events := sm.RecentEvents(); events[0].Type != EventDestroyed || events[0].RequestID != "req-2" {
+		t.Errorf("Expected destroyed event for req-2, got %v", events[0])
+	}
+
+	// Case 3: Custom Header
+	sm.Config.RequestIDHeader = "X-Trace-ID"
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Trace-ID", "trace-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if got != "trace-1" {
+		t.Errorf("Expected trace-1 in the context, got %v", got)
+	}
+}
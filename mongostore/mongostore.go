@@ -0,0 +1,160 @@
+// Package mongostore keeps sessions in a MongoDB collection, one document
+// per session:
+//
+//	_id          session id
+//	data         session encoded by the codec
+//	lastAccessed time of the last access
+//	expiresAt    time the session expires
+//
+// New creates a TTL index on lastAccessed expiring documents MaxLifetime
+// after their last access, so MongoDB deletes expired sessions itself. The
+// TTL monitor only runs periodically, so the store also hides documents past
+// their expiry.
+package mongostore
+
+import (
+	"errors"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+)
+
+// Database and collection used when the options leave them empty
+const (
+	DefaultDatabase   = "sessions"
+	DefaultCollection = "sessions"
+)
+
+// Fields of the documents written
+const (
+	IDField           = "_id"
+	DataField         = "data"
+	LastAccessedField = "lastAccessed"
+	ExpiresAtField    = "expiresAt"
+)
+
+// A session document, mapped onto the fields above by the Client
+type Document struct {
+	ID           string
+	Data         []byte
+	LastAccessed time.Time
+	ExpiresAt    time.Time
+}
+
+// Client is the subset of MongoDB operations the store needs, implemented
+// by a thin adapter over the MongoDB driver
+type Client interface {
+	// findOne by _id, nil if there is no document
+	FindOne(database, collection, id string) (*Document, error)
+
+	// replaceOne by _id with upsert
+	ReplaceOne(database, collection string, doc Document) error
+
+	DeleteOne(database, collection, id string) error
+
+	// find over the whole collection, calling fn for each document until
+	// it returns false
+	Find(database, collection string, fn func(doc Document) bool) error
+
+	// createIndex on field with expireAfterSeconds, if it does not exist
+	EnsureTTLIndex(database, collection, field string, expireAfter time.Duration) error
+}
+
+type Options struct {
+	// DefaultDatabase and DefaultCollection if empty
+	Database   string
+	Collection string
+
+	// Config.MaxLifetime of the manager, the expiry of the TTL index
+	MaxLifetime time.Duration
+
+	// Skip creating the TTL index
+	NoCreateIndex bool
+
+	// Serialization of sessions, session.GobCodec if nil
+	Codec session.Codec
+}
+
+// Backend implements session.Backend on top of a Client
+type Backend struct {
+	client     Client
+	database   string
+	collection string
+}
+
+// Store keeping sessions in MongoDB through client, creating the TTL index
+// unless Options.NoCreateIndex is set. Pass it as Config.Store.
+func New(client Client, opts Options) (*session.BackendStore, error) {
+	b, err := NewBackend(client, opts)
+	if err != nil {
+		return nil, err
+	}
+	return session.NewBackendStore(b, opts.Codec), nil
+}
+
+func NewBackend(client Client, opts Options) (*Backend, error) {
+	b := &Backend{client: client, database: opts.Database, collection: opts.Collection}
+	if b.database == "" {
+		b.database = DefaultDatabase
+	}
+	if b.collection == "" {
+		b.collection = DefaultCollection
+	}
+
+	if !opts.NoCreateIndex {
+		if opts.MaxLifetime <= 0 {
+			return nil, errors.New("MaxLifetime is required for the TTL index")
+		}
+		if err := client.EnsureTTLIndex(b.database, b.collection, LastAccessedField, opts.MaxLifetime); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+func (b *Backend) Load(sid string) ([]byte, error) {
+	doc, err := b.client.FindOne(b.database, b.collection, sid)
+	if err != nil || doc == nil || !time.Now().Before(doc.ExpiresAt) {
+		return nil, err
+	}
+	return doc.Data, nil
+}
+
+func (b *Backend) Save(r session.Record) error {
+	return b.client.ReplaceOne(b.database, b.collection, Document{
+		ID:           r.ID,
+		Data:         r.Data,
+		LastAccessed: r.LastAccessed,
+		ExpiresAt:    r.ExpiresAt,
+	})
+}
+
+func (b *Backend) Remove(sid string) error {
+	return b.client.DeleteOne(b.database, b.collection, sid)
+}
+
+// Documents the TTL monitor has not deleted yet are skipped
+func (b *Backend) Scan(fn func(sid string, data []byte) bool) error {
+	now := time.Now()
+	return b.client.Find(b.database, b.collection, func(doc Document) bool {
+		if !now.Before(doc.ExpiresAt) {
+			return true
+		}
+		return fn(doc.ID, doc.Data)
+	})
+}
+
+func (b *Backend) Len() (int, error) {
+	n := 0
+	err := b.Scan(func(string, []byte) bool {
+		n++
+		return true
+	})
+	return n, err
+}
+
+// Documents expire through the TTL index
+func (b *Backend) SelfExpiring() bool {
+	return true
+}
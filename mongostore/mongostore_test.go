@@ -0,0 +1,133 @@
+package mongostore
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+)
+
+// In-memory stand-in for MongoDB keeping collections as maps
+type fakeMongo struct {
+	lock        sync.Mutex
+	collections map[string]map[string]Document
+	indexes     []string
+}
+
+func newFakeMongo() *fakeMongo {
+	return &fakeMongo{collections: make(map[string]map[string]Document)}
+}
+
+func (f *fakeMongo) FindOne(database, collection, id string) (*Document, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if doc, ok := f.collections[database+"."+collection][id]; ok {
+		return &doc, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeMongo) ReplaceOne(database, collection string, doc Document) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	name := database + "." + collection
+	if f.collections[name] == nil {
+		f.collections[name] = make(map[string]Document)
+	}
+	f.collections[name][doc.ID] = doc
+	return nil
+}
+
+func (f *fakeMongo) DeleteOne(database, collection, id string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.collections[database+"."+collection], id)
+	return nil
+}
+
+func (f *fakeMongo) Find(database, collection string, fn func(doc Document) bool) error {
+	f.lock.Lock()
+	docs := make([]Document, 0, len(f.collections[database+"."+collection]))
+	for _, doc := range f.collections[database+"."+collection] {
+		docs = append(docs, doc)
+	}
+	f.lock.Unlock()
+
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	for _, doc := range docs {
+		if !fn(doc) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeMongo) EnsureTTLIndex(database, collection, field string, expireAfter time.Duration) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.indexes = append(f.indexes, database+"."+collection+"."+field+" "+expireAfter.String())
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	mongo := newFakeMongo()
+
+	// Case 1: TTL Index Created
+	if _, err := New(mongo, Options{Database: "app", Collection: "web", MaxLifetime: time.Hour}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(mongo.indexes) != 1 || mongo.indexes[0] != "app.web.lastAccessed 1h0m0s" {
+		t.Errorf("Expected TTL index on app.web.lastAccessed, got %v", mongo.indexes)
+	}
+
+	// Case 2: MaxLifetime Required For The Index
+	if _, err := New(mongo, Options{}); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	if _, err := New(mongo, Options{NoCreateIndex: true}); err != nil || len(mongo.indexes) != 1 {
+		t.Errorf("Expected no index created, got %v, %v", mongo.indexes, err)
+	}
+}
+
+func TestStore(t *testing.T) {
+	mongo := newFakeMongo()
+	store, err := New(mongo, Options{MaxLifetime: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := session.New(session.SessionManagerConfig{MaxLifetime: time.Hour, Store: store})
+	defer sm.Close()
+
+	// Case 1: Document Written
+	s, _ := sm.SessionCreate("sessionid123")
+	s.Set("key1", "value1")
+	doc, _ := mongo.FindOne(DefaultDatabase, DefaultCollection, "sessionid123")
+	if doc == nil || doc.ExpiresAt.Sub(doc.LastAccessed) != time.Hour || len(doc.Data) == 0 {
+		t.Fatalf("Expected document expiring an hour after last access, got %+v", doc)
+	}
+
+	// Case 2: Session Read Back
+	other := session.New(session.SessionManagerConfig{MaxLifetime: time.Hour, Store: store})
+	defer other.Close()
+	if !other.SessionExist("sessionid123") || other.SessionCount() != 1 {
+		t.Errorf("Expected the session to be read back")
+	}
+
+	// Case 3: Expired Documents Hidden
+	mongo.ReplaceOne(DefaultDatabase, DefaultCollection, Document{ID: "sessionid456", Data: []byte("x"), ExpiresAt: time.Now().Add(-time.Second)})
+	if sm.SessionExist("sessionid456") || sm.SessionCount() != 1 {
+		t.Errorf("Expected expired document to read as missing")
+	}
+
+	// Case 4: Destroy Deletes The Document
+	sm.SessionDestroy("sessionid123")
+	if doc, _ := mongo.FindOne(DefaultDatabase, DefaultCollection, "sessionid123"); doc != nil {
+		t.Errorf("Expected document to be deleted")
+	}
+}
@@ -0,0 +1,730 @@
+package session
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// MessagePackCodec stores sessions as MessagePack maps, laid out like the
+// documents of JSONCodec. They are smaller and faster to encode and decode
+// than gob for sessions holding few, simple values, and readable by
+// MessagePack libraries of other languages.
+//
+// Only string keys can be stored, and values must be nil, booleans,
+// numbers, strings, []byte, time.Time, or slices and string-keyed maps of
+// those. Integers decode as int64 (uint64 above math.MaxInt64), floats as
+// float64, arrays as []interface{} and maps as map[string]interface{},
+// whatever type they were set with. Custom types can't be restored and
+// RegisterType has no effect on this codec.
+type MessagePackCodec struct{}
+
+var errMsgpackTruncated = errors.New("msgpack: truncated data")
+
+// Deepest nesting of arrays and maps decoded, so crafted data can't
+// exhaust the stack
+const maxMsgpackDepth = 64
+
+// MessagePack timestamp extension type, -1
+const msgpackTimeExt byte = 0xff
+
+type msgpackWriter struct {
+	buf []byte
+}
+
+func (w *msgpackWriter) byte(b byte) {
+	w.buf = append(w.buf, b)
+}
+
+func (w *msgpackWriter) uint16(prefix byte, v uint16) {
+	w.buf = append(w.buf, prefix)
+	w.buf = binary.BigEndian.AppendUint16(w.buf, v)
+}
+
+func (w *msgpackWriter) uint32(prefix byte, v uint32) {
+	w.buf = append(w.buf, prefix)
+	w.buf = binary.BigEndian.AppendUint32(w.buf, v)
+}
+
+func (w *msgpackWriter) uint64(prefix byte, v uint64) {
+	w.buf = append(w.buf, prefix)
+	w.buf = binary.BigEndian.AppendUint64(w.buf, v)
+}
+
+func (w *msgpackWriter) int(v int64) {
+	switch {
+	case v >= 0:
+		w.uint(uint64(v))
+	case v >= -32:
+		w.byte(byte(v))
+	case v >= math.MinInt8:
+		w.buf = append(w.buf, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		w.uint16(0xd1, uint16(v))
+	case v >= math.MinInt32:
+		w.uint32(0xd2, uint32(v))
+	default:
+		w.uint64(0xd3, uint64(v))
+	}
+}
+
+func (w *msgpackWriter) uint(v uint64) {
+	switch {
+	case v <= 0x7f:
+		w.byte(byte(v))
+	case v <= math.MaxUint8:
+		w.buf = append(w.buf, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		w.uint16(0xcd, uint16(v))
+	case v <= math.MaxUint32:
+		w.uint32(0xce, uint32(v))
+	default:
+		w.uint64(0xcf, v)
+	}
+}
+
+func (w *msgpackWriter) string(s string) {
+	switch n := len(s); {
+	case n < 32:
+		w.byte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		w.uint16(0xda, uint16(n))
+	default:
+		w.uint32(0xdb, uint32(n))
+	}
+	w.buf = append(w.buf, s...)
+}
+
+func (w *msgpackWriter) bytes(b []byte) {
+	switch n := len(b); {
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		w.uint16(0xc5, uint16(n))
+	default:
+		w.uint32(0xc6, uint32(n))
+	}
+	w.buf = append(w.buf, b...)
+}
+
+func (w *msgpackWriter) arrayHeader(n int) {
+	switch {
+	case n < 16:
+		w.byte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		w.uint16(0xdc, uint16(n))
+	default:
+		w.uint32(0xdd, uint32(n))
+	}
+}
+
+func (w *msgpackWriter) mapHeader(n int) {
+	switch {
+	case n < 16:
+		w.byte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		w.uint16(0xde, uint16(n))
+	default:
+		w.uint32(0xdf, uint32(n))
+	}
+}
+
+// Written as the 64-bit timestamp if it fits, else the 96-bit one
+func (w *msgpackWriter) time(t time.Time) {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	if sec>>34 == 0 {
+		w.buf = append(w.buf, 0xd7, msgpackTimeExt)
+		w.buf = binary.BigEndian.AppendUint64(w.buf, nsec<<34|uint64(sec))
+		return
+	}
+	w.buf = append(w.buf, 0xc7, 12, msgpackTimeExt)
+	w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(nsec))
+	w.buf = binary.BigEndian.AppendUint64(w.buf, uint64(sec))
+}
+
+func (w *msgpackWriter) value(v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		w.byte(0xc0)
+	case bool:
+		if v {
+			w.byte(0xc3)
+		} else {
+			w.byte(0xc2)
+		}
+	case int:
+		w.int(int64(v))
+	case int8:
+		w.int(int64(v))
+	case int16:
+		w.int(int64(v))
+	case int32:
+		w.int(int64(v))
+	case int64:
+		w.int(v)
+	case uint:
+		w.uint(uint64(v))
+	case uint8:
+		w.uint(uint64(v))
+	case uint16:
+		w.uint(uint64(v))
+	case uint32:
+		w.uint(uint64(v))
+	case uint64:
+		w.uint(v)
+	case float32:
+		w.uint32(0xca, math.Float32bits(v))
+	case float64:
+		w.uint64(0xcb, math.Float64bits(v))
+	case string:
+		w.string(v)
+	case []byte:
+		w.bytes(v)
+	case time.Time:
+		w.time(v)
+	case []string:
+		w.arrayHeader(len(v))
+		for _, e := range v {
+			w.string(e)
+		}
+	case []interface{}:
+		w.arrayHeader(len(v))
+		for _, e := range v {
+			if err := w.value(e); err != nil {
+				return err
+			}
+		}
+	case map[string]string:
+		w.mapHeader(len(v))
+		for k, e := range v {
+			w.string(k)
+			w.string(e)
+		}
+	case map[string]interface{}:
+		w.mapHeader(len(v))
+		for k, e := range v {
+			w.string(k)
+			if err := w.value(e); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("MessagePackCodec can't store values of type %T", v)
+	}
+	return nil
+}
+
+// Map of the fields of a struct, leaving out empty ones like the omitempty
+// fields of JSONCodec
+type msgpackMap struct {
+	w msgpackWriter
+	n int
+}
+
+// Writer for the value of field k
+func (m *msgpackMap) field(k string) *msgpackWriter {
+	m.n++
+	m.w.string(k)
+	return &m.w
+}
+
+func (m *msgpackMap) stringField(k, v string) {
+	if v != "" {
+		m.field(k).string(v)
+	}
+}
+
+func (m *msgpackMap) timeField(k string, t time.Time) {
+	if !t.IsZero() {
+		m.field(k).time(t)
+	}
+}
+
+func (w *msgpackWriter) fields(m *msgpackMap) {
+	w.mapHeader(m.n)
+	w.buf = append(w.buf, m.w.buf...)
+}
+
+func (w *msgpackWriter) location(l Location) {
+	var m msgpackMap
+	m.stringField("country", l.Country)
+	m.stringField("city", l.City)
+	if l.Latitude != 0 || l.Longitude != 0 {
+		m.field("latitude").value(l.Latitude)
+		m.field("longitude").value(l.Longitude)
+	}
+	w.fields(&m)
+}
+
+func (MessagePackCodec) Encode(snap Snapshot) ([]byte, error) {
+	var m msgpackMap
+	m.w.buf = make([]byte, 0, 256)
+
+	m.field("id").string(snap.ID)
+	m.stringField("user_id", snap.UserID)
+
+	var meta msgpackMap
+	meta.timeField("created_at", snap.Metadata.CreatedAt)
+	meta.stringField("ip", snap.Metadata.IP)
+	meta.stringField("user_agent", snap.Metadata.UserAgent)
+	meta.field("location").location(snap.Metadata.Location)
+	if len(snap.Metadata.Headers) > 0 {
+		meta.field("headers").value(snap.Metadata.Headers)
+	}
+	m.field("metadata").fields(&meta)
+
+	m.timeField("last_accessed", snap.LastAccessed)
+	m.timeField("updated_at", snap.UpdatedAt)
+	m.stringField("owner", snap.Owner)
+	m.timeField("lease_expires", snap.LeaseExpires)
+
+	w := m.field("values")
+	w.mapHeader(len(snap.Values))
+	for k, v := range snap.Values {
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("MessagePackCodec only stores string keys, got %T", k)
+		}
+		w.string(key)
+		if err := w.value(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if snap.StepUp {
+		m.field("step_up").value(true)
+	}
+
+	if snap.LastAccess != (Access{}) {
+		var access msgpackMap
+		access.stringField("ip", snap.LastAccess.IP)
+		access.field("location").location(snap.LastAccess.Location)
+		access.timeField("time", snap.LastAccess.Time)
+		m.field("last_access").fields(&access)
+	}
+
+	if len(snap.ClassExpires) > 0 {
+		w := m.field("class_expires")
+		w.mapHeader(len(snap.ClassExpires))
+		for class, t := range snap.ClassExpires {
+			w.string(class)
+			w.time(t)
+		}
+	}
+
+	if len(snap.KeyClasses) > 0 {
+		w := m.field("key_classes")
+		w.mapHeader(len(snap.KeyClasses))
+		for k, class := range snap.KeyClasses {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("MessagePackCodec only stores string keys, got %T", k)
+			}
+			w.string(key)
+			w.string(class)
+		}
+	}
+
+	var out msgpackWriter
+	out.buf = make([]byte, 0, len(m.w.buf)+5)
+	out.fields(&m)
+	return out.buf, nil
+}
+
+type msgpackReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *msgpackReader) next(n int) ([]byte, error) {
+	if n < 0 || n > len(r.data)-r.pos {
+		return nil, errMsgpackTruncated
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// Length of the given size following the type byte
+func (r *msgpackReader) length(size int) (int, error) {
+	b, err := r.next(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return int(b[0]), nil
+	case 2:
+		return int(binary.BigEndian.Uint16(b)), nil
+	default:
+		return int(binary.BigEndian.Uint32(b)), nil
+	}
+}
+
+func (r *msgpackReader) value(depth int) (interface{}, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := b[0]; {
+	case t <= 0x7f:
+		return int64(t), nil
+	case t >= 0xe0:
+		return int64(int8(t)), nil
+	case t&0xe0 == 0xa0:
+		return r.str(int(t & 0x1f))
+	case t&0xf0 == 0x90:
+		return r.array(int(t&0x0f), depth)
+	case t&0xf0 == 0x80:
+		return r.mapOf(int(t&0x0f), depth)
+	}
+
+	switch t := b[0]; t {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		b, err := r.next(1 << (t - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		var v uint64
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+		if v > math.MaxInt64 {
+			return v, nil
+		}
+		return int64(v), nil
+	case 0xd0:
+		b, err := r.next(1)
+		if err != nil {
+			return nil, err
+		}
+		return int64(int8(b[0])), nil
+	case 0xd1:
+		b, err := r.next(2)
+		if err != nil {
+			return nil, err
+		}
+		return int64(int16(binary.BigEndian.Uint16(b))), nil
+	case 0xd2:
+		b, err := r.next(4)
+		if err != nil {
+			return nil, err
+		}
+		return int64(int32(binary.BigEndian.Uint32(b))), nil
+	case 0xd3:
+		b, err := r.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return int64(binary.BigEndian.Uint64(b)), nil
+	case 0xca:
+		b, err := r.next(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	case 0xcb:
+		b, err := r.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.length(1 << (t - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return r.str(n)
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.length(1 << (t - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 0xdc, 0xdd:
+		n, err := r.length(2 << (t - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return r.array(n, depth)
+	case 0xde, 0xdf:
+		n, err := r.length(2 << (t - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return r.mapOf(n, depth)
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return r.ext(1 << (t - 0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := r.length(1 << (t - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return r.ext(n)
+	}
+
+	return nil, fmt.Errorf("msgpack: invalid type byte 0x%x", b[0])
+}
+
+func (r *msgpackReader) str(n int) (string, error) {
+	b, err := r.next(n)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (r *msgpackReader) array(n, depth int) ([]interface{}, error) {
+	if depth >= maxMsgpackDepth {
+		return nil, errors.New("msgpack: nested too deeply")
+	}
+	// Every element takes at least a byte
+	if n > len(r.data)-r.pos {
+		return nil, errMsgpackTruncated
+	}
+
+	a := make([]interface{}, n)
+	for i := range a {
+		v, err := r.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		a[i] = v
+	}
+	return a, nil
+}
+
+func (r *msgpackReader) mapOf(n, depth int) (map[string]interface{}, error) {
+	if depth >= maxMsgpackDepth {
+		return nil, errors.New("msgpack: nested too deeply")
+	}
+	// Every entry takes at least two bytes
+	if n > (len(r.data)-r.pos)/2 {
+		return nil, errMsgpackTruncated
+	}
+
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := r.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("msgpack: map key of type %T", k)
+		}
+		if m[key], err = r.value(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Extension data of n bytes, only timestamps are supported
+func (r *msgpackReader) ext(n int) (interface{}, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+	typ := b[0]
+	if b, err = r.next(n); err != nil {
+		return nil, err
+	}
+	if typ != msgpackTimeExt {
+		return nil, fmt.Errorf("msgpack: unsupported extension type %d", int8(typ))
+	}
+
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(b)), 0), nil
+	case 8:
+		v := binary.BigEndian.Uint64(b)
+		return time.Unix(int64(v&(1<<34-1)), int64(v>>34)), nil
+	case 12:
+		return time.Unix(int64(binary.BigEndian.Uint64(b[4:])), int64(binary.BigEndian.Uint32(b))), nil
+	}
+	return nil, fmt.Errorf("msgpack: timestamp of %d bytes", n)
+}
+
+// Fields of a decoded MessagePack map, recording the first type mismatch
+type msgpackFields struct {
+	m   map[string]interface{}
+	err error
+}
+
+func (f *msgpackFields) mismatch(field string, v interface{}) {
+	if f.err == nil {
+		f.err = fmt.Errorf("msgpack: field %s of type %T", field, v)
+	}
+}
+
+func (f *msgpackFields) string(field string) string {
+	switch v := f.m[field].(type) {
+	case string:
+		return v
+	case nil:
+	default:
+		f.mismatch(field, v)
+	}
+	return ""
+}
+
+func (f *msgpackFields) bool(field string) bool {
+	switch v := f.m[field].(type) {
+	case bool:
+		return v
+	case nil:
+	default:
+		f.mismatch(field, v)
+	}
+	return false
+}
+
+func (f *msgpackFields) float(field string) float64 {
+	switch v := f.m[field].(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	case nil:
+	default:
+		f.mismatch(field, v)
+	}
+	return 0
+}
+
+// Zero times are restored as time.Time{} in UTC, like gob does
+func (f *msgpackFields) time(field string) time.Time {
+	switch v := f.m[field].(type) {
+	case time.Time:
+		if v.IsZero() {
+			return time.Time{}
+		}
+		return v
+	case nil:
+	default:
+		f.mismatch(field, v)
+	}
+	return time.Time{}
+}
+
+func (f *msgpackFields) fields(field string) *msgpackFields {
+	switch v := f.m[field].(type) {
+	case map[string]interface{}:
+		return &msgpackFields{m: v}
+	case nil:
+	default:
+		f.mismatch(field, v)
+	}
+	return &msgpackFields{}
+}
+
+func (f *msgpackFields) location(field string) Location {
+	l := f.fields(field)
+	loc := Location{
+		Country:   l.string("country"),
+		City:      l.string("city"),
+		Latitude:  l.float("latitude"),
+		Longitude: l.float("longitude"),
+	}
+	if f.err == nil {
+		f.err = l.err
+	}
+	return loc
+}
+
+func (MessagePackCodec) Decode(data []byte) (Snapshot, error) {
+	r := &msgpackReader{data: data}
+	v, err := r.value(0)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return Snapshot{}, fmt.Errorf("msgpack: session of type %T", v)
+	}
+
+	f := &msgpackFields{m: m}
+	meta := f.fields("metadata")
+	access := f.fields("last_access")
+	snap := Snapshot{
+		ID:     f.string("id"),
+		UserID: f.string("user_id"),
+		Metadata: Metadata{
+			CreatedAt: meta.time("created_at"),
+			IP:        meta.string("ip"),
+			UserAgent: meta.string("user_agent"),
+			Location:  meta.location("location"),
+		},
+		LastAccessed: f.time("last_accessed"),
+		UpdatedAt:    f.time("updated_at"),
+		Owner:        f.string("owner"),
+		LeaseExpires: f.time("lease_expires"),
+		StepUp:       f.bool("step_up"),
+		LastAccess: Access{
+			IP:       access.string("ip"),
+			Location: access.location("location"),
+			Time:     access.time("time"),
+		},
+	}
+	for _, sub := range []*msgpackFields{meta, access} {
+		if f.err == nil {
+			f.err = sub.err
+		}
+	}
+
+	if headers := meta.fields("headers"); len(headers.m) > 0 {
+		snap.Metadata.Headers = make(map[string]string, len(headers.m))
+		for name := range headers.m {
+			snap.Metadata.Headers[name] = headers.string(name)
+		}
+		if f.err == nil {
+			f.err = headers.err
+		}
+	}
+
+	values := f.fields("values")
+	snap.Values = make(map[interface{}]interface{}, len(values.m))
+	for k, v := range values.m {
+		snap.Values[k] = v
+	}
+
+	if classes := f.fields("class_expires"); len(classes.m) > 0 {
+		snap.ClassExpires = make(map[string]time.Time, len(classes.m))
+		for class := range classes.m {
+			snap.ClassExpires[class] = classes.time(class)
+		}
+		if f.err == nil {
+			f.err = classes.err
+		}
+	}
+	if keys := f.fields("key_classes"); len(keys.m) > 0 {
+		snap.KeyClasses = make(map[interface{}]string, len(keys.m))
+		for k := range keys.m {
+			snap.KeyClasses[k] = keys.string(k)
+		}
+		if f.err == nil {
+			f.err = keys.err
+		}
+	}
+
+	if f.err != nil {
+		return Snapshot{}, f.err
+	}
+	return snap, nil
+}
@@ -0,0 +1,186 @@
+package session
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMessagePackCodec(t *testing.T) {
+	now := time.Now().Round(0)
+	snap := Snapshot{
+		ID:           "sessionid123",
+		UserID:       "user1",
+		Metadata:     Metadata{CreatedAt: now, IP: "10.0.0.1", Location: Location{Country: "DE", Latitude: 52.5}, Headers: map[string]string{"Accept-Language": "de"}},
+		LastAccessed: now,
+		Values: map[interface{}]interface{}{
+			"name":  "alice",
+			"cart":  3,
+			"neg":   int64(-100000),
+			"big":   uint64(math.MaxUint64),
+			"ratio": 0.5,
+			"raw":   []byte{1, 2, 3},
+			"seen":  now.Add(-time.Minute),
+			"tags":  []string{"a", "b"},
+			"prefs": map[string]interface{}{"theme": "dark", "size": 12},
+			"none":  nil,
+		},
+		KeyClasses:   map[interface{}]string{"name": "auth"},
+		ClassExpires: map[string]time.Time{"auth": now.Add(time.Hour)},
+	}
+
+	// Case 1: Round Trip With MessagePack Types
+	data, err := MessagePackCodec{}.Encode(snap)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got, err := MessagePackCodec{}.Decode(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.ID != "sessionid123" || got.UserID != "user1" || !got.LastAccessed.Equal(now) || !got.Metadata.CreatedAt.Equal(now) {
+		t.Errorf("Expected decoded snapshot to match, got %+v", got)
+	}
+	if got.Metadata.Location.Country != "DE" || got.Metadata.Location.Latitude != 52.5 || got.Metadata.Headers["Accept-Language"] != "de" {
+		t.Errorf("Expected metadata kept, got %+v", got.Metadata)
+	}
+	if got.Values["name"] != "alice" || got.Values["cart"] != int64(3) || got.Values["neg"] != int64(-100000) ||
+		got.Values["big"] != uint64(math.MaxUint64) || got.Values["ratio"] != 0.5 || got.Values["none"] != nil {
+		t.Errorf("Expected scalar values kept, got %v", got.Values)
+	}
+	if raw, ok := got.Values["raw"].([]byte); !ok || !bytes.Equal(raw, []byte{1, 2, 3}) {
+		t.Errorf("Expected raw bytes kept, got %v", got.Values["raw"])
+	}
+	if seen, ok := got.Values["seen"].(time.Time); !ok || !seen.Equal(now.Add(-time.Minute)) {
+		t.Errorf("Expected time kept, got %v", got.Values["seen"])
+	}
+	if tags, ok := got.Values["tags"].([]interface{}); !ok || len(tags) != 2 || tags[1] != "b" {
+		t.Errorf("Expected tags as []interface{}, got %T", got.Values["tags"])
+	}
+	if prefs, ok := got.Values["prefs"].(map[string]interface{}); !ok || prefs["theme"] != "dark" || prefs["size"] != int64(12) {
+		t.Errorf("Expected prefs as map[string]interface{}, got %v", got.Values["prefs"])
+	}
+	if got.KeyClasses["name"] != "auth" || !got.ClassExpires["auth"].Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected value classes kept, got %v, %v", got.KeyClasses, got.ClassExpires)
+	}
+	if !got.LeaseExpires.IsZero() || got.LastAccess != (Access{}) {
+		t.Errorf("Expected zero fields kept zero, got %v, %v", got.LeaseExpires, got.LastAccess)
+	}
+
+	// Case 2: Smaller Than Gob
+	small := benchmarkSnapshots()["small"]
+	gob, _ := GobCodec{}.Encode(small)
+	if data, _ := (MessagePackCodec{}).Encode(small); len(data) >= len(gob) {
+		t.Errorf("Expected fewer bytes than gob's %v, got %v", len(gob), len(data))
+	}
+
+	// Case 3: Unsupported Keys and Values Rejected
+	if _, err := (MessagePackCodec{}).Encode(Snapshot{Values: map[interface{}]interface{}{1: "x"}}); err == nil {
+		t.Errorf("Expected error for an int key")
+	}
+	if _, err := (MessagePackCodec{}).Encode(Snapshot{Values: map[interface{}]interface{}{"x": struct{}{}}}); err == nil {
+		t.Errorf("Expected error for a struct value")
+	}
+
+	// Case 4: Corrupt Data
+	for _, data := range [][]byte{{}, {0xc1}, data[:len(data)/2], {0xdf, 0xff, 0xff, 0xff, 0xff}, bytes.Repeat([]byte{0x91}, 100)} {
+		if _, err := (MessagePackCodec{}).Decode(data); err == nil {
+			t.Errorf("Expected error decoding %x", data)
+		}
+	}
+
+	// Case 5: Package Values Restored
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: NewBackendStore(newMapBackend(), MessagePackCodec{})})
+	defer sm.Close()
+	s, _ := sm.SessionCreate("sessionid123")
+	s.EnterSudo(time.Minute)
+	if s, ok := sm.session("sessionid123"); !ok || !s.InSudo() {
+		t.Errorf("Expected the sudo window kept")
+	}
+}
+
+func FuzzMessagePackCodec_Decode(f *testing.F) {
+	seed, _ := MessagePackCodec{}.Encode(Snapshot{ID: "sessionid123", UserID: "user1", LastAccessed: time.Now(), Values: map[interface{}]interface{}{"key1": "value1"}})
+	f.Add(seed)
+	f.Add([]byte("garbage"))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Case 1: Malformed Data Never Panics
+		snap, err := MessagePackCodec{}.Decode(data)
+		if err != nil {
+			return
+		}
+
+		// Case 2: Decoded Snapshots Encode Again
+		if _, err := (MessagePackCodec{}).Encode(snap); err != nil {
+			t.Errorf("Expected decoded snapshot to encode, got %v", err)
+		}
+	})
+}
+
+// Sessions of different shapes: a logged-in session with a few short
+// values, and one carrying a large cart. Values are of types gob knows
+// without RegisterType.
+func benchmarkSnapshots() map[string]Snapshot {
+	now := time.Now()
+	small := Snapshot{
+		ID:           "sessionid123",
+		UserID:       "user1",
+		Metadata:     Metadata{CreatedAt: now, IP: "10.0.0.1", UserAgent: "Mozilla/5.0"},
+		LastAccessed: now,
+		Values:       map[interface{}]interface{}{"theme": "dark", "cart": 3, "csrf": "0123456789abcdef"},
+	}
+
+	items := make([]string, 200)
+	for i := range items {
+		items[i] = fmt.Sprintf("sku-%d:%d:%.2f", i, i%5+1, float64(i)*1.25)
+	}
+	large := small
+	large.Values = map[interface{}]interface{}{"theme": "dark", "cart": items, "history": []string{"/", "/shop", "/cart", "/checkout"}}
+
+	return map[string]Snapshot{"small": small, "large": large}
+}
+
+var benchmarkCodecs = []struct {
+	name  string
+	codec Codec
+}{
+	{"gob", GobCodec{}},
+	{"json", JSONCodec{}},
+	{"msgpack", MessagePackCodec{}},
+}
+
+func BenchmarkCodec_Encode(b *testing.B) {
+	for shape, snap := range benchmarkSnapshots() {
+		for _, c := range benchmarkCodecs {
+			b.Run(shape+"/"+c.name, func(b *testing.B) {
+				data, _ := c.codec.Encode(snap)
+				b.ReportMetric(float64(len(data)), "bytes")
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					c.codec.Encode(snap)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkCodec_Decode(b *testing.B) {
+	for shape, snap := range benchmarkSnapshots() {
+		for _, c := range benchmarkCodecs {
+			b.Run(shape+"/"+c.name, func(b *testing.B) {
+				data, err := c.codec.Encode(snap)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					c.codec.Decode(data)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package session
+
+import "strings"
+
+// Separates Config.Namespace from the session id in backend keys
+const namespaceSep = "."
+
+// Escapes the separator in namespaces, so the keys of namespace "a" don't
+// also match those of "a.b"
+var namespaceEscaper = strings.NewReplacer("%", "%25", namespaceSep, "%2E")
+
+// Prefix of the backend keys of the manager's namespace
+func (bs *BackendStore) prefix() string {
+	return namespaceEscaper.Replace(bs.sm.Config.Namespace) + namespaceSep
+}
+
+// Backend key of sid, prefixed with the namespace of the manager if set
+func (bs *BackendStore) key(sid string) string {
+	if bs.sm == nil || bs.sm.Config.Namespace == "" {
+		return sid
+	}
+	return bs.prefix() + sid
+}
+
+// Session id of a backend key, false if the key belongs to another
+// namespace
+func (bs *BackendStore) sid(key string) (string, bool) {
+	if bs.sm == nil || bs.sm.Config.Namespace == "" {
+		return key, true
+	}
+	return strings.CutPrefix(key, bs.prefix())
+}
+
+func (bs *BackendStore) namespaced() bool {
+	return bs.sm != nil && bs.sm.Config.Namespace != ""
+}
@@ -0,0 +1,100 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackendStore_Namespace(t *testing.T) {
+	backend := &watchBackend{mapBackend: newMapBackend()}
+	var removed []string
+	shop := New(SessionManagerConfig{MaxLifetime: time.Hour, Namespace: "shop", Store: NewBackendStore(backend, nil)})
+	defer shop.Close()
+	blog := New(SessionManagerConfig{
+		MaxLifetime: time.Hour,
+		Namespace:   "blog",
+		Store:       NewBackendStore(backend, nil),
+		OnEvent: func(e Event) {
+			if e.Type == EventRemoved {
+				removed = append(removed, e.SessionID)
+			}
+		},
+	})
+	defer blog.Close()
+
+	// Case 1: Keys Prefixed With the Namespace
+	shop.SessionCreate("sessionid123")
+	blog.SessionCreate("sessionid456")
+	if _, ok := backend.data["shop.sessionid123"]; !ok {
+		t.Errorf("Expected shop.sessionid123 in backend, got %v", backend.data)
+	}
+
+	// Case 2: Other Namespaces Invisible
+	if shop.SessionExist("sessionid456") || blog.SessionExist("sessionid123") {
+		t.Errorf("Expected sessions of the other namespace missing")
+	}
+	if shop.SessionCount() != 1 || blog.SessionCount() != 1 {
+		t.Errorf("Expected 1 session each, got %v and %v", shop.SessionCount(), blog.SessionCount())
+	}
+
+	// Case 3: Same Id in Two Namespaces
+	blog.SessionCreate("sessionid123")
+	s, _ := blog.session("sessionid123")
+	s.Set("post", 1)
+	if got, _ := shop.session("sessionid123"); got == nil || got.Get("post") != nil {
+		t.Errorf("Expected shop session untouched, got %v", got)
+	}
+
+	// Case 4: Cleaner Leaves Other Namespaces Alone
+	shop.Config.MaxLifetime = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	shop.GlobalCleaner()
+	if shop.SessionCount() != 0 || blog.SessionCount() != 2 {
+		t.Errorf("Expected only shop sessions expired, got %v and %v", shop.SessionCount(), blog.SessionCount())
+	}
+
+	// Case 5: Removals Reported Within the Namespace
+	if len(removed) != 0 {
+		t.Errorf("Expected no removals reported to blog, got %v", removed)
+	}
+	backend.Remove("blog.sessionid456")
+	if len(removed) != 1 || removed[0] != "sessionid456" {
+		t.Errorf("Expected [sessionid456], got %v", removed)
+	}
+}
+
+func TestBackendStore_OverlappingNamespaces(t *testing.T) {
+	backend := newMapBackend()
+	a := New(SessionManagerConfig{MaxLifetime: time.Hour, Namespace: "a", Store: NewBackendStore(backend, nil)})
+	defer a.Close()
+	ab := New(SessionManagerConfig{MaxLifetime: time.Hour, Namespace: "a.b", Store: NewBackendStore(backend, nil)})
+	defer ab.Close()
+	a.SessionCreate("sessionid123")
+	ab.SessionCreate("sessionid456")
+
+	// Case 1: Separator Escaped
+	if _, ok := backend.data["a%2Eb.sessionid456"]; !ok {
+		t.Errorf("Expected a%%2Eb.sessionid456 in backend, got %v", backend.data)
+	}
+
+	// Case 2: Prefix Namespace Doesn't See the Other
+	if a.SessionCount() != 1 || a.SessionExist("b.sessionid456") {
+		t.Errorf("Expected only the session of namespace a, got %v", a.SessionCount())
+	}
+	var sids []string
+	a.store.Iterate(func(sid string, s *Session) bool {
+		sids = append(sids, sid)
+		return true
+	})
+	if len(sids) != 1 || sids[0] != "sessionid123" {
+		t.Errorf("Expected [sessionid123], got %v", sids)
+	}
+
+	// Case 3: Cleaner Leaves the Other Namespace Alone
+	a.Config.MaxLifetime = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	a.GlobalCleaner()
+	if a.SessionCount() != 0 || ab.SessionCount() != 1 {
+		t.Errorf("Expected only the session of namespace a expired, got %v and %v", a.SessionCount(), ab.SessionCount())
+	}
+}
@@ -0,0 +1,144 @@
+// Package natsstore keeps sessions in a NATS JetStream key-value bucket, for
+// deployments already running NATS. Each key is written with a per-key TTL
+// of the time left before the session expires, so the server deletes
+// expired sessions itself. A watch on the bucket reports sessions destroyed
+// by another instance or expired to every manager at once, as EventRemoved.
+package natsstore
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+)
+
+// Key prefix used when Options.Prefix is empty
+const DefaultPrefix = "sessions."
+
+// Client is the subset of KeyValue operations the store needs, implemented
+// by a thin adapter over a jetstream.KeyValue. The bucket must allow per-key
+// TTLs (AllowMsgTTL / LimitMarkerTTL).
+type Client interface {
+	// Value of key, nil if it does not exist or was deleted
+	Get(key string) ([]byte, error)
+
+	// Put key with a per-key TTL, e.g. with jetstream.KeyTTL
+	Put(key string, value []byte, ttl time.Duration) error
+
+	Delete(key string) error
+
+	// Call fn for every live key matching the subject filter, e.g.
+	// "sessions.>", with its value until it returns false
+	List(filter string, fn func(key string, value []byte) bool) error
+
+	// Watch keys matching the subject filter, calling fn for every delete,
+	// purge or TTL expiry until stop is called
+	WatchDeletes(filter string, fn func(key string)) (stop func(), err error)
+}
+
+type Options struct {
+	// Prepended to session ids to build keys, DefaultPrefix if empty. Must
+	// end with a dot so the keys of the store can be selected by subject.
+	Prefix string
+
+	// Serialization of sessions, session.GobCodec if nil
+	Codec session.Codec
+}
+
+// Backend implements session.Backend on top of a Client
+type Backend struct {
+	client Client
+	prefix string
+}
+
+// Store keeping sessions in a NATS KV bucket through client. Pass it as
+// Config.Store.
+func New(client Client, opts Options) *session.BackendStore {
+	return session.NewBackendStore(NewBackend(client, opts.Prefix), opts.Codec)
+}
+
+func NewBackend(client Client, prefix string) *Backend {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &Backend{client: client, prefix: prefix}
+}
+
+func (b *Backend) Load(sid string) ([]byte, error) {
+	key, err := b.key(sid)
+	if err != nil {
+		return nil, err
+	}
+	return b.client.Get(key)
+}
+
+func (b *Backend) Save(r session.Record) error {
+	key, err := b.key(r.ID)
+	if err != nil {
+		return err
+	}
+	return b.client.Put(key, r.Data, ttl(r.ExpiresAt))
+}
+
+func (b *Backend) Remove(sid string) error {
+	key, err := b.key(sid)
+	if err != nil {
+		return err
+	}
+	return b.client.Delete(key)
+}
+
+func (b *Backend) Scan(fn func(sid string, data []byte) bool) error {
+	return b.client.List(b.prefix+">", func(key string, value []byte) bool {
+		return fn(strings.TrimPrefix(key, b.prefix), value)
+	})
+}
+
+func (b *Backend) Len() (int, error) {
+	n := 0
+	err := b.client.List(b.prefix+">", func(string, []byte) bool {
+		n++
+		return true
+	})
+	return n, err
+}
+
+// Keys expire with their TTL
+func (b *Backend) SelfExpiring() bool {
+	return true
+}
+
+// Report sessions deleted from the bucket, by any instance or through their
+// TTL
+func (b *Backend) Watch(fn func(sid string)) (func(), error) {
+	return b.client.WatchDeletes(b.prefix+">", func(key string) {
+		fn(strings.TrimPrefix(key, b.prefix))
+	})
+}
+
+// NATS keys are subjects made of dot-separated tokens of
+// [-/_=a-zA-Z0-9], so a session id must not make an empty token
+func (b *Backend) key(sid string) (string, error) {
+	key := b.prefix + sid
+	if sid == "" || strings.HasPrefix(key, ".") || strings.HasSuffix(key, ".") || strings.Contains(key, "..") {
+		return "", errors.New("NATS key has an empty token")
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-/_=.", c) >= 0) {
+			return "", errors.New("NATS key contains an invalid character")
+		}
+	}
+	return key, nil
+}
+
+// TTL of an entry to be dropped at t, in whole seconds and at least one as
+// NATS counts per-key TTLs in seconds
+func ttl(t time.Time) time.Duration {
+	d := time.Until(t)
+	if d < time.Second {
+		return time.Second
+	}
+	return (d + time.Second - 1).Truncate(time.Second)
+}
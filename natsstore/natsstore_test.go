@@ -0,0 +1,198 @@
+package natsstore
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+	"github.com/vpatel95/session-manager/v2/storetest"
+)
+
+// In-memory stand-in for a KV bucket recording TTLs and fanning out deletes
+// to watchers synchronously
+type fakeKV struct {
+	lock     sync.Mutex
+	data     map[string][]byte
+	ttls     map[string]time.Duration
+	watchers map[int]func(key string)
+	next     int
+}
+
+func newFakeKV() *fakeKV {
+	return &fakeKV{data: make(map[string][]byte), ttls: make(map[string]time.Duration), watchers: make(map[int]func(string))}
+}
+
+func (f *fakeKV) Get(key string) ([]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.data[key], nil
+}
+
+func (f *fakeKV) Put(key string, value []byte, ttl time.Duration) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.data[key] = value
+	f.ttls[key] = ttl
+	return nil
+}
+
+func (f *fakeKV) Delete(key string) error {
+	f.lock.Lock()
+	_, ok := f.data[key]
+	delete(f.data, key)
+	delete(f.ttls, key)
+	var watchers []func(string)
+	if ok {
+		for _, w := range f.watchers {
+			watchers = append(watchers, w)
+		}
+	}
+	f.lock.Unlock()
+
+	for _, w := range watchers {
+		w(key)
+	}
+	return nil
+}
+
+// Only filters of the form "prefix.>" are supported
+func (f *fakeKV) List(filter string, fn func(key string, value []byte) bool) error {
+	prefix := strings.TrimSuffix(filter, ">")
+	f.lock.Lock()
+	var keys []string
+	for key := range f.data {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = f.data[key]
+	}
+	f.lock.Unlock()
+
+	for i, key := range keys {
+		if !fn(key, values[i]) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeKV) WatchDeletes(filter string, fn func(key string)) (func(), error) {
+	prefix := strings.TrimSuffix(filter, ">")
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	id := f.next
+	f.next++
+	f.watchers[id] = func(key string) {
+		if strings.HasPrefix(key, prefix) {
+			fn(key)
+		}
+	}
+	return func() {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		delete(f.watchers, id)
+	}, nil
+}
+
+func (f *fakeKV) ttl(key string) time.Duration {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.ttls[key]
+}
+
+func TestStore(t *testing.T) {
+	kv := newFakeKV()
+	var lock sync.Mutex
+	removed := make(map[string][]string)
+	newManager := func(name string) *session.SessionManager {
+		return session.New(session.SessionManagerConfig{
+			CleanerInterval: time.Minute,
+			MaxLifetime:     time.Hour,
+			Store:           New(kv, Options{}),
+			OnEvent: func(e session.Event) {
+				if e.Type == session.EventRemoved {
+					lock.Lock()
+					removed[name] = append(removed[name], e.SessionID)
+					lock.Unlock()
+				}
+			},
+		})
+	}
+	a, b := newManager("a"), newManager("b")
+	defer a.Close()
+	defer b.Close()
+
+	// Case 1: Key Written With A TTL In Seconds
+	s, err := a.SessionCreate("sessionid123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	s.Set("key1", "value1")
+	if ttl := kv.ttl(DefaultPrefix + "sessionid123"); ttl != time.Hour {
+		t.Errorf("Expected TTL of an hour, got %v", ttl)
+	}
+
+	// Case 2: Shared By Every Instance
+	if !b.SessionExist("sessionid123") || b.SessionCount() != 1 {
+		t.Errorf("Expected the session on the other instance")
+	}
+
+	// Case 3: Destroy Reported To The Other Instances Only
+	if err := b.SessionDestroy("sessionid123"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	lock.Lock()
+	if len(removed["a"]) != 1 || removed["a"][0] != "sessionid123" || len(removed["b"]) != 0 {
+		t.Errorf("Expected sessionid123 reported to a only, got %v", removed)
+	}
+	lock.Unlock()
+
+	// Case 4: TTL Expiry Reported To Every Instance
+	a.SessionCreate("sessionid456")
+	kv.Delete(DefaultPrefix + "sessionid456")
+	lock.Lock()
+	if len(removed["a"]) != 2 || len(removed["b"]) != 1 {
+		t.Errorf("Expected the expiry reported to both instances, got %v", removed)
+	}
+	lock.Unlock()
+
+	// Case 5: Invalid Keys Rejected
+	for _, sid := range []string{"session id", "session..id", "sessionid.", "session*"} {
+		if _, err := a.SessionCreate(sid); err == nil {
+			t.Errorf("Expected error for %q", sid)
+		}
+	}
+	if _, err := a.SessionCreate("a.b-c_d=e/f"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	// Case 1: Rounded Up To The Second
+	if d := ttl(time.Now().Add(1500 * time.Millisecond)); d != 2*time.Second {
+		t.Errorf("Expected 2s, got %v", d)
+	}
+
+	// Case 2: At Least A Second
+	if d := ttl(time.Now().Add(-time.Minute)); d != time.Second {
+		t.Errorf("Expected 1s, got %v", d)
+	}
+}
+
+func TestEquivalence(t *testing.T) {
+	// Case 1: Same Behavior As MemoryStore
+	for seed := int64(1); seed <= 5; seed++ {
+		storetest.Equivalent(t, New(newFakeKV(), Options{}), seed, 200)
+	}
+}
@@ -0,0 +1,158 @@
+// Package pgstore keeps sessions in a PostgreSQL table through database/sql,
+// so they survive deploys and can be queried for audits. Register the driver
+// of your choice and pass the *sql.DB to New.
+//
+// The table holds one row per session:
+//
+//	sid           TEXT PRIMARY KEY
+//	data          BYTEA NOT NULL        session encoded by the codec
+//	last_accessed TIMESTAMPTZ NOT NULL
+//	expires_at    TIMESTAMPTZ NOT NULL
+//
+// Expired rows read as missing and are deleted on every cleaner run.
+package pgstore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+)
+
+// Table used when Options.Table is empty
+const DefaultTable = "sessions"
+
+type Options struct {
+	// Table name, optionally schema qualified. DefaultTable if empty.
+	Table string
+
+	// Skip creating the table and its index if they do not exist
+	NoCreateTable bool
+
+	// Serialization of sessions, session.GobCodec if nil
+	Codec session.Codec
+}
+
+// Backend implements session.Backend on top of a *sql.DB
+type Backend struct {
+	db *sql.DB
+
+	load, save, remove, scan, count, sweep string
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Store keeping sessions in db, creating the table unless
+// Options.NoCreateTable is set. Pass it as Config.Store.
+func New(db *sql.DB, opts Options) (*session.BackendStore, error) {
+	b, err := NewBackend(db, opts)
+	if err != nil {
+		return nil, err
+	}
+	return session.NewBackendStore(b, opts.Codec), nil
+}
+
+func NewBackend(db *sql.DB, opts Options) (*Backend, error) {
+	table := opts.Table
+	if table == "" {
+		table = DefaultTable
+	}
+	if !identifier.MatchString(table) {
+		return nil, errors.New("invalid table name")
+	}
+
+	b := &Backend{
+		db:     db,
+		load:   fmt.Sprintf("SELECT data FROM %s WHERE sid = $1 AND expires_at > $2", table),
+		save:   fmt.Sprintf("INSERT INTO %s (sid, data, last_accessed, expires_at) VALUES ($1, $2, $3, $4) ON CONFLICT (sid) DO UPDATE SET data = EXCLUDED.data, last_accessed = EXCLUDED.last_accessed, expires_at = EXCLUDED.expires_at", table),
+		remove: fmt.Sprintf("DELETE FROM %s WHERE sid = $1", table),
+		scan:   fmt.Sprintf("SELECT sid, data FROM %s WHERE expires_at > $1", table),
+		count:  fmt.Sprintf("SELECT count(*) FROM %s WHERE expires_at > $1", table),
+		sweep:  fmt.Sprintf("DELETE FROM %s WHERE expires_at <= $1", table),
+	}
+
+	if !opts.NoCreateTable {
+		if err := b.createTable(table); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+func (b *Backend) createTable(table string) error {
+	_, err := b.db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	sid TEXT PRIMARY KEY,
+	data BYTEA NOT NULL,
+	last_accessed TIMESTAMPTZ NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL
+)`, table))
+	if err != nil {
+		return err
+	}
+
+	index := strings.ReplaceAll(table, ".", "_") + "_expires_at_idx"
+	_, err = b.db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (expires_at)", index, table))
+	return err
+}
+
+func (b *Backend) Load(sid string) ([]byte, error) {
+	var data []byte
+	err := b.db.QueryRow(b.load, sid, time.Now()).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (b *Backend) Save(r session.Record) error {
+	_, err := b.db.Exec(b.save, r.ID, r.Data, r.LastAccessed, r.ExpiresAt)
+	return err
+}
+
+func (b *Backend) Remove(sid string) error {
+	_, err := b.db.Exec(b.remove, sid)
+	return err
+}
+
+func (b *Backend) Scan(fn func(sid string, data []byte) bool) error {
+	rows, err := b.db.Query(b.scan, time.Now())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sid string
+		var data []byte
+		if err := rows.Scan(&sid, &data); err != nil {
+			return err
+		}
+		if !fn(sid, data) {
+			break
+		}
+	}
+
+	return rows.Err()
+}
+
+func (b *Backend) Len() (int, error) {
+	var n int
+	err := b.db.QueryRow(b.count, time.Now()).Scan(&n)
+	return n, err
+}
+
+// Expired rows read as missing until Sweep deletes them
+func (b *Backend) SelfExpiring() bool {
+	return true
+}
+
+// Delete expired rows, run on every cleaner run
+func (b *Backend) Sweep() error {
+	_, err := b.db.Exec(b.sweep, time.Now())
+	return err
+}
@@ -0,0 +1,221 @@
+package pgstore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+)
+
+// database/sql driver answering the statements of the backend from a map
+type fakeDB struct {
+	lock  sync.Mutex
+	rows  map[string]fakeRow
+	execs []string
+}
+
+type fakeRow struct {
+	data         []byte
+	lastAccessed time.Time
+	expiresAt    time.Time
+}
+
+var (
+	fakeLock sync.Mutex
+	fakeDBs  = make(map[string]*fakeDB)
+)
+
+func init() {
+	sql.Register("pgstore-fake", fakeDriver{})
+}
+
+func openFake(t *testing.T) (*sql.DB, *fakeDB) {
+	f := &fakeDB{rows: make(map[string]fakeRow)}
+	fakeLock.Lock()
+	fakeDBs[t.Name()] = f
+	fakeLock.Unlock()
+
+	db, err := sql.Open("pgstore-fake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, f
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeLock.Lock()
+	defer fakeLock.Unlock()
+	return fakeConn{fakeDBs[name]}, nil
+}
+
+type fakeConn struct{ f *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{c.f, query}, nil
+}
+func (fakeConn) Close() error              { return nil }
+func (fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type fakeStmt struct {
+	f     *fakeDB
+	query string
+}
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	f := s.f
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.execs = append(f.execs, s.query)
+	var n int64
+	switch {
+	case strings.HasPrefix(s.query, "CREATE"):
+	case strings.HasPrefix(s.query, "INSERT"):
+		f.rows[args[0].(string)] = fakeRow{args[1].([]byte), args[2].(time.Time), args[3].(time.Time)}
+		n = 1
+	case strings.HasSuffix(s.query, "WHERE sid = $1"):
+		if _, ok := f.rows[args[0].(string)]; ok {
+			delete(f.rows, args[0].(string))
+			n = 1
+		}
+	case strings.HasSuffix(s.query, "WHERE expires_at <= $1"):
+		for sid, r := range f.rows {
+			if !r.expiresAt.After(args[0].(time.Time)) {
+				delete(f.rows, sid)
+				n++
+			}
+		}
+	default:
+		return nil, errors.New("unexpected statement: " + s.query)
+	}
+	return driver.RowsAffected(n), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	f := s.f
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var cols []string
+	var values [][]driver.Value
+	switch {
+	case strings.HasPrefix(s.query, "SELECT data"):
+		cols = []string{"data"}
+		if r, ok := f.rows[args[0].(string)]; ok && r.expiresAt.After(args[1].(time.Time)) {
+			values = append(values, []driver.Value{r.data})
+		}
+	case strings.HasPrefix(s.query, "SELECT sid, data"):
+		cols = []string{"sid", "data"}
+		for sid, r := range f.rows {
+			if r.expiresAt.After(args[0].(time.Time)) {
+				values = append(values, []driver.Value{sid, r.data})
+			}
+		}
+		sort.Slice(values, func(i, j int) bool { return values[i][0].(string) < values[j][0].(string) })
+	case strings.HasPrefix(s.query, "SELECT count"):
+		cols = []string{"count"}
+		var n int64
+		for _, r := range f.rows {
+			if r.expiresAt.After(args[0].(time.Time)) {
+				n++
+			}
+		}
+		values = append(values, []driver.Value{n})
+	default:
+		return nil, errors.New("unexpected query: " + s.query)
+	}
+	return &fakeRows{cols: cols, values: values}, nil
+}
+
+type fakeRows struct {
+	cols   []string
+	values [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	db, f := openFake(t)
+
+	// Case 1: Table And Index Created
+	if _, err := New(db, Options{Table: "app.sessions"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(f.execs) != 2 || !strings.Contains(f.execs[0], "CREATE TABLE IF NOT EXISTS app.sessions") || !strings.Contains(f.execs[1], "app_sessions_expires_at_idx") {
+		t.Errorf("Expected table and index creation, got %v", f.execs)
+	}
+
+	// Case 2: Creation Skipped
+	f.execs = nil
+	New(db, Options{NoCreateTable: true})
+	if len(f.execs) != 0 {
+		t.Errorf("Expected no statements, got %v", f.execs)
+	}
+
+	// Case 3: Invalid Table Name
+	if _, err := New(db, Options{Table: "sessions; DROP TABLE users"}); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestStore(t *testing.T) {
+	db, f := openFake(t)
+	store, err := New(db, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := session.New(session.SessionManagerConfig{MaxLifetime: time.Hour, Store: store})
+
+	// Case 1: Row Written With Expiry
+	s, _ := sm.SessionCreate("sessionid123")
+	s.Set("key1", "value1")
+	r := f.rows["sessionid123"]
+	if r.expiresAt.Sub(r.lastAccessed) != time.Hour || len(r.data) == 0 {
+		t.Errorf("Expected row expiring an hour after last access, got %+v", r)
+	}
+
+	// Case 2: Session Read Back
+	other := session.New(session.SessionManagerConfig{MaxLifetime: time.Hour, Store: store})
+	if !other.SessionExist("sessionid123") || other.SessionCount() != 1 {
+		t.Errorf("Expected the session to be read back")
+	}
+
+	// Case 3: Expired Rows Hidden And Swept
+	f.rows["sessionid456"] = fakeRow{[]byte("x"), time.Now().Add(-2 * time.Hour), time.Now().Add(-time.Hour)}
+	if sm.SessionExist("sessionid456") || sm.SessionCount() != 1 {
+		t.Errorf("Expected expired row to read as missing")
+	}
+	sm.GlobalCleaner()
+	if _, ok := f.rows["sessionid456"]; ok {
+		t.Errorf("Expected expired row to be deleted")
+	}
+
+	// Case 4: Destroy Deletes The Row
+	sm.SessionDestroy("sessionid123")
+	if len(f.rows) != 0 {
+		t.Errorf("Expected no rows, got %v", len(f.rows))
+	}
+}
@@ -0,0 +1,81 @@
+package session
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Allocation stats of the session pool, see Config.PoolSessions
+type PoolStats struct {
+	Gets   uint64 // sessions handed out by the pool
+	Puts   uint64 // destroyed or expired sessions returned to the pool
+	Allocs uint64 // sessions the pool had to allocate
+}
+
+type sessionPool struct {
+	pool   sync.Pool
+	gets   atomic.Uint64
+	puts   atomic.Uint64
+	allocs atomic.Uint64
+}
+
+func (p *sessionPool) get(keys int) *Session {
+	p.gets.Add(1)
+	if s, ok := p.pool.Get().(*Session); ok {
+		return s
+	}
+
+	p.allocs.Add(1)
+	return &Session{sd: make(dict, keys)}
+}
+
+// Clear s and return it to the pool
+func (p *sessionPool) put(s *Session) {
+	s.lock.Lock()
+	sd := s.sd
+	for k := range sd {
+		delete(sd, k)
+	}
+	s.manager = nil
+	s.sessionId = ""
+	s.lastAccessed = time.Time{}
+	s.updatedAt = time.Time{}
+	s.owner = ""
+	s.leaseExpires = time.Time{}
+	s.idleWarned.Store(false)
+	s.meta = Metadata{}
+	s.lastAccess = Access{}
+	s.stepUp = false
+	s.userId = ""
+	s.reads.Store(0)
+	s.createStack = nil
+	s.journal = s.journal[:0]
+	s.guestId = ""
+	s.requestId = ""
+	s.flags = nil
+	s.classes = nil
+	s.keyClass = nil
+	s.promote = nil
+	s.lock.Unlock()
+
+	p.puts.Add(1)
+	p.pool.Put(s)
+}
+
+// Return a session that left the manager to the pool. With
+// Config.PoolSessions set, callers must not keep using a session after it
+// was destroyed or expired, as it will be handed out again.
+func (sm *SessionManager) release(s *Session) {
+	if sm.Config.PoolSessions && s != nil {
+		sm.pool.put(s)
+	}
+}
+
+func (sm *SessionManager) PoolStats() PoolStats {
+	return PoolStats{
+		Gets:   sm.pool.gets.Load(),
+		Puts:   sm.pool.puts.Load(),
+		Allocs: sm.pool.allocs.Load(),
+	}
+}
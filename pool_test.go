@@ -0,0 +1,52 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_PoolSessions(t *testing.T) {
+	sm := New()
+	sm.Config.PoolSessions = true
+
+	// Case 1: Destroyed Session Returned Cleared
+	s, _ := sm.SessionCreate("sessionid123")
+	s.Set("key1", "value1")
+	s.SetUserID("user1")
+	sm.SessionDestroy("sessionid123")
+
+	stats := sm.PoolStats()
+	if stats.Gets != 1 || stats.Puts != 1 || stats.Allocs != 1 {
+		t.Errorf("Expected 1 get, put and alloc, got %+v", stats)
+	}
+	if s.Exist("key1") || s.UserID() != "" || s.ID() != "" {
+		t.Errorf("Expected pooled session to be cleared")
+	}
+
+	// Case 2: New Session Starts Clean
+	s, _ = sm.SessionCreate("sessionid456")
+	if s.ID() != "sessionid456" || s.Exist("key1") || s.UserID() != "" || time.Since(s.Metadata().CreatedAt) > time.Second {
+		t.Errorf("Expected clean session sessionid456, got %v", s.ID())
+	}
+
+	// Case 3: Expired Sessions Returned
+	sm.Config.MaxLifetime = time.Hour
+	for i := 0; i < 10; i++ {
+		sm.SessionCreate(fmt.Sprintf("expired%d", i))
+		sm.stored(fmt.Sprintf("expired%d", i)).age(2 * time.Hour)
+	}
+	before := sm.PoolStats().Puts
+	sm.GlobalCleaner()
+	if puts := sm.PoolStats().Puts; puts != before+10 {
+		t.Errorf("Expected %v puts, got %v", before+10, puts)
+	}
+
+	// Case 4: Pooling Disabled
+	smOff := New()
+	smOff.SessionCreate("sessionid123")
+	smOff.SessionDestroy("sessionid123")
+	if stats = smOff.PoolStats(); stats != (PoolStats{}) {
+		t.Errorf("Expected no pool activity, got %+v", stats)
+	}
+}
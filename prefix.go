@@ -0,0 +1,60 @@
+package session
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// String keys starting with prefix, sorted. Reserved keys are left out,
+// like DeletePrefix leaves them alone.
+func (s *Session) KeysWithPrefix(prefix string) []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	now := time.Now()
+	var keys []string
+	for key := range s.sd {
+		if k, ok := key.(string); ok && strings.HasPrefix(k, prefix) && !isReservedKey(k) && !s.classExpired(key, now) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Delete every string key starting with prefix at once, e.g. to wipe the
+// "wizard:" keys when a flow is abandoned. Reserved keys are left alone.
+func (s *Session) DeletePrefix(prefix string) error {
+	if isReservedKey(prefix) {
+		return ErrReservedKey
+	}
+	if err := s.frozen(); err != nil {
+		return err
+	}
+
+	forwarded := s.forward(Write{Op: WriteDeletePrefix, Key: prefix})
+
+	s.lock.Lock()
+	deleted := make(map[interface{}]txValue)
+	for key, old := range s.sd {
+		k, ok := key.(string)
+		if !ok || !strings.HasPrefix(k, prefix) || isReservedKey(k) {
+			continue
+		}
+		s.unclass(key)
+		delete(s.sd, key)
+		s.record(key, old, true, nil, false)
+		deleted[key] = txValue{value: old}
+	}
+	if len(deleted) > 0 {
+		s.updatedAt = time.Now()
+	}
+	s.lock.Unlock()
+
+	if len(deleted) == 0 || forwarded {
+		return nil
+	}
+	return s.commit(s.undoer(deleted))
+}
@@ -0,0 +1,63 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSession_KeysWithPrefix(t *testing.T) {
+	s := &Session{sd: make(dict)}
+	s.Set("wizard:step", 2)
+	s.Set("wizard:name", "x")
+	s.Set("cart", 1)
+	s.Set(42, "int key")
+
+	// Case 1: Matching String Keys, Sorted
+	if got := s.KeysWithPrefix("wizard:"); !reflect.DeepEqual(got, []string{"wizard:name", "wizard:step"}) {
+		t.Errorf("Expected wizard keys, got %v", got)
+	}
+
+	// Case 2: No Match
+	if got := s.KeysWithPrefix("checkout:"); len(got) != 0 {
+		t.Errorf("Expected no keys, got %v", got)
+	}
+
+	// Case 3: Reserved Keys Left Out
+	s.SetLocale("en")
+	if got := s.KeysWithPrefix(""); !reflect.DeepEqual(got, []string{"cart", "wizard:name", "wizard:step"}) {
+		t.Errorf("Expected only user keys, got %v", got)
+	}
+	if got := s.KeysWithPrefix(ReservedKeyPrefix); len(got) != 0 {
+		t.Errorf("Expected no reserved keys, got %v", got)
+	}
+}
+
+func TestSession_DeletePrefix(t *testing.T) {
+	sm := New()
+	sm.Config.JournalSize = 10
+	s, _ := sm.SessionCreate("sessionid123")
+	s.Set("wizard:step", 2)
+	s.Set("wizard:name", "x")
+	s.Set("cart", 1)
+	s.SetLocale("en")
+
+	// Case 1: Matching Keys Deleted
+	if err := s.DeletePrefix("wizard:"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if s.Exist("wizard:step") || s.Exist("wizard:name") || !s.Exist("cart") {
+		t.Errorf("Expected only the wizard keys to be deleted")
+	}
+	if journal := s.Journal(); len(journal) != 6 || journal[5].NewHash != "" {
+		t.Errorf("Expected the deletes to be journaled, got %v", journal)
+	}
+
+	// Case 2: Reserved Keys Are Kept
+	if err := s.DeletePrefix(ReservedKeyPrefix); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey, got %v", err)
+	}
+	s.DeletePrefix("")
+	if s.Exist("cart") || s.Locale() != "en" {
+		t.Errorf("Expected user keys deleted and the locale kept")
+	}
+}
@@ -0,0 +1,57 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	localeKey = "_sm.locale"
+	tzKey     = "_sm.tz"
+)
+
+// Store the user's preferred locale, e.g. "en-US"
+func (s *Session) SetLocale(locale string) error {
+	return s.set(localeKey, locale)
+}
+
+// Preferred locale, empty if not set
+func (s *Session) Locale() string {
+	locale, _ := s.Get(localeKey).(string)
+	return locale
+}
+
+// Store the user's time zone by IANA name, e.g. "Europe/Berlin"
+func (s *Session) SetTZ(name string) error {
+	if _, err := time.LoadLocation(name); err != nil {
+		return err
+	}
+
+	return s.set(tzKey, name)
+}
+
+// Preferred time zone, UTC if not set
+func (s *Session) TZ() *time.Location {
+	name, _ := s.Get(tzKey).(string)
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc
+	}
+
+	return time.UTC
+}
+
+// Locale of the session stored in ctx by Middleware, empty if there is none
+func LocaleFromContext(ctx context.Context) string {
+	if s := FromContext(ctx); s != nil {
+		return s.Locale()
+	}
+	return ""
+}
+
+// Time zone of the session stored in ctx by Middleware, UTC if there is none
+func TZFromContext(ctx context.Context) *time.Location {
+	if s := FromContext(ctx); s != nil {
+		return s.TZ()
+	}
+	return time.UTC
+}
@@ -0,0 +1,79 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSession_Locale(t *testing.T) {
+	s := &Session{sd: make(dict)}
+
+	// Case 1: Not Set
+	if l := s.Locale(); l != "" {
+		t.Errorf("Expected empty locale, got %v", l)
+	}
+
+	// Case 2: Set
+	if err := s.SetLocale("de-DE"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if l := s.Locale(); l != "de-DE" {
+		t.Errorf("Expected de-DE, got %v", l)
+	}
+}
+
+func TestSession_TZ(t *testing.T) {
+	s := &Session{sd: make(dict)}
+
+	// Case 1: Not Set
+	if tz := s.TZ(); tz != time.UTC {
+		t.Errorf("Expected UTC, got %v", tz)
+	}
+
+	// Case 2: Valid Zone
+	if err := s.SetTZ("America/New_York"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tz := s.TZ(); tz.String() != "America/New_York" {
+		t.Errorf("Expected America/New_York, got %v", tz)
+	}
+
+	// Case 3: Invalid Zone Keeps Previous
+	if err := s.SetTZ("Nowhere/Special"); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	if tz := s.TZ(); tz.String() != "America/New_York" {
+		t.Errorf("Expected America/New_York, got %v", tz)
+	}
+}
+
+func TestPreferencesFromContext(t *testing.T) {
+	sm := New()
+	s, _ := sm.SessionCreate("sessionid123")
+	s.SetLocale("fr-FR")
+	s.SetTZ("Europe/Paris")
+
+	// Case 1: Session in Context
+	var locale string
+	var tz *time.Location
+	handler := sm.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale, tz = LocaleFromContext(r.Context()), TZFromContext(r.Context())
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if locale != "fr-FR" || tz.String() != "Europe/Paris" {
+		t.Errorf("Expected fr-FR and Europe/Paris, got %v and %v", locale, tz)
+	}
+
+	// Case 2: No Session in Context
+	if l := LocaleFromContext(context.Background()); l != "" {
+		t.Errorf("Expected empty locale, got %v", l)
+	}
+	if tz := TZFromContext(context.Background()); tz != time.UTC {
+		t.Errorf("Expected UTC, got %v", tz)
+	}
+}
@@ -0,0 +1,104 @@
+package session
+
+import (
+	"sort"
+	"time"
+)
+
+// Sessions accessed within since, most recently accessed first, e.g. for
+// "who's online" features. Sessions are only marked accessed by
+// SessionUpdate, which SessionRead calls when AutoRefreshSession is set.
+func (sm *SessionManager) ActiveSessions(since time.Duration) []*Session {
+	type active struct {
+		s        *Session
+		accessed time.Time
+	}
+	var sessions []active
+
+	sm.rlock()
+	cutoff := time.Now().Add(-since)
+	sm.each(func(_ string, s *Session) {
+		if at := s.accessed(); at.After(cutoff) {
+			sessions = append(sessions, active{s, at})
+		}
+	})
+	sm.lock.RUnlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].accessed.After(sessions[j].accessed)
+	})
+
+	result := make([]*Session, len(sessions))
+	for i, a := range sessions {
+		result[i] = a.s
+	}
+	return result
+}
+
+// The n sessions idle the longest, least recently accessed first, all of
+// them if n <= 0. Meant for reports on lifetime tuning and clients that
+// hold on to sessions without using them.
+func (sm *SessionManager) IdleSessions(n int) []*Session {
+	type idle struct {
+		s        *Session
+		accessed time.Time
+	}
+	var sessions []idle
+
+	sm.rlock()
+	sm.each(func(_ string, s *Session) {
+		sessions = append(sessions, idle{s, s.accessed()})
+	})
+	sm.lock.RUnlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].accessed.Before(sessions[j].accessed)
+	})
+	if n > 0 && n < len(sessions) {
+		sessions = sessions[:n]
+	}
+
+	result := make([]*Session, len(sessions))
+	for i, a := range sessions {
+		result[i] = a.s
+	}
+	return result
+}
+
+// Users with a session accessed within since, with the last access of any
+// of their sessions. Sessions not bound to a user are left out.
+func (sm *SessionManager) OnlineUsers(since time.Duration) map[string]time.Time {
+	users := make(map[string]time.Time)
+
+	sm.rlock()
+	defer sm.lock.RUnlock()
+
+	cutoff := time.Now().Add(-since)
+	sm.each(func(_ string, s *Session) {
+		uid, at := s.UserID(), s.accessed()
+		if uid != "" && at.After(cutoff) && at.After(users[uid]) {
+			users[uid] = at
+		}
+	})
+
+	return users
+}
+
+// Last access of any session of the user, false if the user has none
+func (sm *SessionManager) LastSeen(uid string) (time.Time, bool) {
+	var last time.Time
+	var found bool
+
+	sm.rlock()
+	defer sm.lock.RUnlock()
+
+	sm.each(func(_ string, s *Session) {
+		if s.UserID() == uid {
+			if at := s.accessed(); !found || at.After(last) {
+				last, found = at, true
+			}
+		}
+	})
+
+	return last, found
+}
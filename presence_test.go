@@ -0,0 +1,62 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionManager_Presence(t *testing.T) {
+	sm := New()
+	sm.Config.MaxLifetime = time.Hour
+
+	alice1, _ := sm.SessionCreate("sessionid1")
+	alice1.SetUserID("alice")
+	alice2, _ := sm.SessionCreate("sessionid2")
+	alice2.SetUserID("alice")
+	bob, _ := sm.SessionCreate("sessionid3")
+	bob.SetUserID("bob")
+	anon, _ := sm.SessionCreate("sessionid4")
+
+	alice1.age(30 * time.Minute)
+	alice2.age(2 * time.Minute)
+	bob.age(20 * time.Minute)
+	anon.age(time.Minute)
+
+	// Case 1: Active Sessions, Most Recent First
+	active := sm.ActiveSessions(5 * time.Minute)
+	if len(active) != 2 || active[0] != anon || active[1] != alice2 {
+		t.Errorf("Expected [sessionid4 sessionid2], got %v", active)
+	}
+
+	// Case 2: Online Users With Their Latest Access
+	users := sm.OnlineUsers(25 * time.Minute)
+	if len(users) != 2 {
+		t.Fatalf("Expected alice and bob online, got %v", users)
+	}
+	if !users["alice"].Equal(alice2.accessed()) {
+		t.Errorf("Expected last access of sessionid2 for alice, got %v", users["alice"])
+	}
+
+	// Case 3: Last Seen
+	if at, ok := sm.LastSeen("bob"); !ok || !at.Equal(bob.accessed()) {
+		t.Errorf("Expected last access of sessionid3, got %v", at)
+	}
+	if _, ok := sm.LastSeen("carol"); ok {
+		t.Errorf("Expected carol never seen")
+	}
+
+	// Case 4: Access Brings a User Online
+	sm.SessionUpdate("sessionid1")
+	if users := sm.OnlineUsers(time.Minute); len(users) != 1 || users["alice"].IsZero() {
+		t.Errorf("Expected only alice online, got %v", users)
+	}
+
+	// Case 5: Longest Idle First
+	idle := sm.IdleSessions(2)
+	if len(idle) != 2 || idle[0] != bob || idle[1] != alice2 {
+		t.Errorf("Expected [sessionid3 sessionid2], got %v", idle)
+	}
+	if idle := sm.IdleSessions(0); len(idle) != 4 || idle[3] != alice1 {
+		t.Errorf("Expected all sessions ending with sessionid1, got %v", idle)
+	}
+}
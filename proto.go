@@ -0,0 +1,117 @@
+package session
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Types registered with RegisterProtoType
+var protoTypes sync.Map // reflect.Type -> struct{}
+
+func init() {
+	RegisterTypeName("session.protoValue", protoValue{})
+}
+
+// A protobuf message as stored by ProtoCodec: its registered name and wire
+// encoding
+type protoValue struct {
+	Type string
+	Data []byte
+}
+
+// Make session values of the type of msg, a pointer to a generated protobuf
+// message, go through the protobuf wire format in a ProtoCodec instead of
+// gob reflection, e.g. RegisterProtoType("shop.v1.Cart", (*shoppb.Cart)(nil)).
+// name is usually the full name of the message; register it once, from an
+// init function, on every instance sharing the store.
+func RegisterProtoType(name string, msg interface{}) {
+	t := reflect.TypeOf(msg)
+	if t == nil || t.Kind() != reflect.Pointer {
+		panic(fmt.Sprintf("session: registering proto type %v, want a pointer to a message", t))
+	}
+	registerType(name, msg)
+	protoTypes.Store(t, struct{}{})
+}
+
+// ProtoCodec stores session values of types registered with
+// RegisterProtoType in their protobuf wire format, tagged with their
+// registered name, and hands everything else to another Codec. It takes the
+// marshal and unmarshal functions of the protobuf runtime so the package
+// doesn't depend on it:
+//
+//	codec := session.NewProtoCodec(nil,
+//		func(m interface{}) ([]byte, error) { return proto.Marshal(m.(proto.Message)) },
+//		func(b []byte, m interface{}) error { return proto.Unmarshal(b, m.(proto.Message)) })
+//
+// Only messages stored directly as values are converted, not those nested
+// in slices or maps. The other Codec must be able to hold the tagged
+// messages, which GobCodec, CompressedCodec and EncryptedCodec over it can
+// but JSONCodec and MessagePackCodec can't.
+type ProtoCodec struct {
+	codec     Codec
+	marshal   func(msg interface{}) ([]byte, error)
+	unmarshal func(data []byte, msg interface{}) error
+}
+
+// Codec converting registered protobuf messages with marshal and unmarshal
+// around codec, GobCodec if nil
+func NewProtoCodec(codec Codec, marshal func(msg interface{}) ([]byte, error), unmarshal func(data []byte, msg interface{}) error) *ProtoCodec {
+	if codec == nil {
+		codec = GobCodec{}
+	}
+	return &ProtoCodec{codec: codec, marshal: marshal, unmarshal: unmarshal}
+}
+
+func (pc *ProtoCodec) Encode(snap Snapshot) ([]byte, error) {
+	var values map[interface{}]interface{}
+	for k, v := range snap.Values {
+		if _, ok := protoTypes.Load(reflect.TypeOf(v)); !ok || reflect.ValueOf(v).IsNil() {
+			continue
+		}
+		name, _ := RegisteredName(v)
+		data, err := pc.marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling %s: %w", name, err)
+		}
+
+		// Copy on first write, snap.Values belongs to the caller
+		if values == nil {
+			values = make(map[interface{}]interface{}, len(snap.Values))
+			for k, v := range snap.Values {
+				values[k] = v
+			}
+		}
+		values[k] = protoValue{Type: name, Data: data}
+	}
+	if values != nil {
+		snap.Values = values
+	}
+
+	return pc.codec.Encode(snap)
+}
+
+func (pc *ProtoCodec) Decode(data []byte) (Snapshot, error) {
+	snap, err := pc.codec.Decode(data)
+	if err != nil {
+		return snap, err
+	}
+
+	for k, v := range snap.Values {
+		pv, ok := v.(protoValue)
+		if !ok {
+			continue
+		}
+		t, ok := RegisteredType(pv.Type)
+		if !ok {
+			return Snapshot{}, fmt.Errorf("proto type %q is not registered", pv.Type)
+		}
+		msg := reflect.New(t.Elem()).Interface()
+		if err := pc.unmarshal(pv.Data, msg); err != nil {
+			return Snapshot{}, fmt.Errorf("unmarshaling %s: %w", pv.Type, err)
+		}
+		snap.Values[k] = msg
+	}
+
+	return snap, nil
+}
@@ -0,0 +1,82 @@
+package session
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Stand-in for a generated message, deliberately not known to gob
+type cartMessage struct {
+	Items []string
+}
+
+func init() {
+	RegisterProtoType("shop.v1.Cart", (*cartMessage)(nil))
+}
+
+func TestProtoCodec(t *testing.T) {
+	var marshals int
+	codec := NewProtoCodec(nil,
+		func(m interface{}) ([]byte, error) {
+			marshals++
+			return []byte(strings.Join(m.(*cartMessage).Items, ",")), nil
+		},
+		func(b []byte, m interface{}) error {
+			if len(b) == 0 {
+				return errors.New("empty message")
+			}
+			m.(*cartMessage).Items = strings.Split(string(b), ",")
+			return nil
+		})
+
+	snap := Snapshot{
+		ID:           "sessionid123",
+		LastAccessed: time.Now(),
+		Values:       map[interface{}]interface{}{"cart": &cartMessage{Items: []string{"a", "b"}}, "name": "alice"},
+	}
+
+	// Case 1: Messages Round Trip Through the Wire Format
+	data, err := codec.Encode(snap)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if marshals != 1 {
+		t.Errorf("Expected the cart marshaled once, got %v", marshals)
+	}
+	got, err := codec.Decode(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cart, ok := got.Values["cart"].(*cartMessage); !ok || len(cart.Items) != 2 || cart.Items[1] != "b" {
+		t.Errorf("Expected the cart restored, got %#v", got.Values["cart"])
+	}
+	if got.Values["name"] != "alice" {
+		t.Errorf("Expected other values kept, got %v", got.Values["name"])
+	}
+
+	// Case 2: Caller's Values Untouched
+	if _, ok := snap.Values["cart"].(*cartMessage); !ok {
+		t.Errorf("Expected the snapshot passed in unchanged, got %T", snap.Values["cart"])
+	}
+
+	// Case 3: Gob Alone Can't Store the Message
+	if _, err := (GobCodec{}).Encode(snap); err == nil {
+		t.Errorf("Expected gob to reject the unregistered message")
+	}
+
+	// Case 4: Unmarshal Errors Reported
+	data, _ = codec.Encode(Snapshot{Values: map[interface{}]interface{}{"cart": &cartMessage{}}})
+	if _, err := codec.Decode(data); err == nil {
+		t.Errorf("Expected error for an empty message")
+	}
+
+	// Case 5: Registration Requires a Pointer
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic registering a non-pointer")
+		}
+	}()
+	RegisterProtoType("shop.v1.Other", cartMessage{})
+}
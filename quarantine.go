@@ -0,0 +1,64 @@
+package session
+
+import "sync/atomic"
+
+// What a BackendStore does with a stored session that fails to decode
+type CorruptionPolicy int
+
+const (
+	// Reading the session fails with the decode error, on every request
+	// presenting it until the entry expires
+	CorruptFail CorruptionPolicy = iota
+	// The entry is set aside and the session reads as missing, so the user
+	// simply starts a new one
+	CorruptQuarantine
+)
+
+// Implemented by backends that can move a corrupt entry aside for later
+// inspection, e.g. under another key. Quarantined entries are removed from
+// backends without it.
+type Quarantiner interface {
+	Quarantine(sid string, data []byte) error
+}
+
+// Stored sessions that failed to decode, see Config.CorruptSessions
+type CorruptionStats struct {
+	Detected    uint64 // entries that failed to decode
+	Quarantined uint64 // entries set aside under CorruptQuarantine
+}
+
+type corruption struct {
+	detected    atomic.Uint64
+	quarantined atomic.Uint64
+}
+
+// Handle data stored for sid failing to decode with err, returning the error
+// the read fails with, nil once the entry is quarantined
+func (sm *SessionManager) corrupt(backend Backend, sid string, data []byte, err error) error {
+	sm.corruption.detected.Add(1)
+	if sm.Config.OnCorruptSession != nil {
+		sm.Config.OnCorruptSession(sid, data, err)
+	}
+
+	if sm.Config.CorruptSessions != CorruptQuarantine {
+		return err
+	}
+
+	if q, ok := backend.(Quarantiner); ok {
+		err = q.Quarantine(sid, data)
+	} else {
+		err = backend.Remove(sid)
+	}
+	if err != nil {
+		return err
+	}
+	sm.corruption.quarantined.Add(1)
+	return nil
+}
+
+func (sm *SessionManager) CorruptionStats() CorruptionStats {
+	return CorruptionStats{
+		Detected:    sm.corruption.detected.Load(),
+		Quarantined: sm.corruption.quarantined.Load(),
+	}
+}
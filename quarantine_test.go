@@ -0,0 +1,79 @@
+package session
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+// mapBackend moving quarantined entries into aside
+type quarantineBackend struct {
+	*mapBackend
+	aside map[string][]byte
+}
+
+func (b *quarantineBackend) Quarantine(sid string, data []byte) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.aside[sid] = data
+	delete(b.data, sid)
+	return nil
+}
+
+func TestSessionManager_CorruptSessions(t *testing.T) {
+	backend := newMapBackend()
+	var hooked []string
+	sm := New(SessionManagerConfig{
+		MaxLifetime: time.Hour,
+		Store:       NewBackendStore(backend, nil),
+		OnCorruptSession: func(sid string, data []byte, err error) {
+			hooked = append(hooked, sid)
+		},
+	})
+	backend.Save(Record{ID: "sessionid123", Data: []byte("garbage")})
+
+	// Case 1: Reads Fail By Default
+	if _, err := sm.SessionExpiresIn("sessionid123"); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	if _, err := sm.store.Get("sessionid123"); err == nil {
+		t.Errorf("Expected decode error, got nil")
+	}
+	if stats := sm.CorruptionStats(); stats.Detected != 2 || stats.Quarantined != 0 {
+		t.Errorf("Expected 2 detected, got %+v", stats)
+	}
+	if len(hooked) != 2 || hooked[0] != "sessionid123" {
+		t.Errorf("Expected hook called for sessionid123, got %v", hooked)
+	}
+
+	// Case 2: Quarantined Entry Reads As Missing
+	sm.Config.CorruptSessions = CorruptQuarantine
+	s, err := sm.store.Get("sessionid123")
+	if err != nil || s != nil {
+		t.Errorf("Expected missing session, got %v, %v", s, err)
+	}
+	if data, _ := backend.Load("sessionid123"); data != nil {
+		t.Errorf("Expected entry to be removed, got %q", data)
+	}
+	if stats := sm.CorruptionStats(); stats.Quarantined != 1 {
+		t.Errorf("Expected 1 quarantined, got %+v", stats)
+	}
+
+	// Case 3: Moved Aside By A Quarantiner
+	qb := &quarantineBackend{mapBackend: newMapBackend(), aside: make(map[string][]byte)}
+	qm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: NewBackendStore(qb, nil), CorruptSessions: CorruptQuarantine})
+	qb.Save(Record{ID: "sessionid789", Data: []byte("garbage")})
+	if qm.SessionExist("sessionid789") {
+		t.Errorf("Expected sessionid789 not to exist")
+	}
+	if string(qb.aside["sessionid789"]) != "garbage" {
+		t.Errorf("Expected entry moved aside, got %v", qb.aside)
+	}
+
+	// Case 4: Quarantined During A Scan
+	qb.Save(Record{ID: "sessionid000", Data: []byte("garbage")})
+	qm.Dump(io.Discard, FormatText)
+	if _, ok := qb.aside["sessionid000"]; !ok {
+		t.Errorf("Expected entry moved aside after the scan")
+	}
+}
@@ -0,0 +1,116 @@
+// Package redistore keeps sessions in Redis, so they are shared by every
+// replica of an application and survive restarts. Entries expire through the
+// Redis TTL, refreshed to Config.MaxLifetime on every write, so the cleaner
+// does not scan this store.
+package redistore
+
+import (
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+)
+
+// Key prefix used when Options.Prefix is empty
+const DefaultPrefix = "session:"
+
+// Client is the subset of Redis commands the store needs, implemented by a
+// thin adapter over the application's Redis client
+type Client interface {
+	// GET key, nil if the key does not exist
+	Get(key string) ([]byte, error)
+
+	// SET key value PX ttl
+	Set(key string, value []byte, ttl time.Duration) error
+
+	// DEL key
+	Del(key string) error
+
+	// SCAN cursor MATCH match COUNT count, returning the keys and the next
+	// cursor, zero once the iteration is complete
+	Scan(cursor uint64, match string, count int64) ([]string, uint64, error)
+}
+
+type Options struct {
+	// Prepended to session ids to build keys, DefaultPrefix if empty
+	Prefix string
+
+	// Serialization of sessions, session.GobCodec if nil
+	Codec session.Codec
+}
+
+// Backend implements session.Backend on top of a Client
+type Backend struct {
+	client Client
+	prefix string
+}
+
+// Store keeping sessions in Redis through client. Pass it as Config.Store.
+func New(client Client, opts Options) *session.BackendStore {
+	return session.NewBackendStore(NewBackend(client, opts.Prefix), opts.Codec)
+}
+
+func NewBackend(client Client, prefix string) *Backend {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &Backend{client: client, prefix: prefix}
+}
+
+func (b *Backend) Load(sid string) ([]byte, error) {
+	return b.client.Get(b.prefix + sid)
+}
+
+func (b *Backend) Save(r session.Record) error {
+	return b.client.Set(b.prefix+r.ID, r.Data, time.Until(r.ExpiresAt))
+}
+
+func (b *Backend) Remove(sid string) error {
+	return b.client.Del(b.prefix + sid)
+}
+
+// Keys deleted or expiring during the scan are skipped
+func (b *Backend) Scan(fn func(sid string, data []byte) bool) error {
+	return b.keys(func(key string) (bool, error) {
+		data, err := b.client.Get(key)
+		if err != nil {
+			return false, err
+		}
+		if data == nil {
+			return true, nil
+		}
+		return fn(key[len(b.prefix):], data), nil
+	})
+}
+
+func (b *Backend) Len() (int, error) {
+	n := 0
+	err := b.keys(func(string) (bool, error) {
+		n++
+		return true, nil
+	})
+	return n, err
+}
+
+// Entries expire through the Redis TTL
+func (b *Backend) SelfExpiring() bool {
+	return true
+}
+
+func (b *Backend) keys(fn func(key string) (bool, error)) error {
+	var cursor uint64
+	for {
+		keys, next, err := b.client.Scan(cursor, b.prefix+"*", 100)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if more, err := fn(key); err != nil || !more {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,171 @@
+package redistore
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+	"github.com/vpatel95/session-manager/v2/storetest"
+)
+
+// In-memory stand-in for Redis honoring TTLs and paginating SCAN
+type fakeRedis struct {
+	lock    sync.Mutex
+	data    map[string][]byte
+	expires map[string]time.Time
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{data: make(map[string][]byte), expires: make(map[string]time.Time)}
+}
+
+// Caller must hold f.lock
+func (f *fakeRedis) expire(key string) {
+	if e, ok := f.expires[key]; ok && !time.Now().Before(e) {
+		delete(f.data, key)
+		delete(f.expires, key)
+	}
+}
+
+func (f *fakeRedis) Get(key string) ([]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.expire(key)
+	return f.data[key], nil
+}
+
+func (f *fakeRedis) Set(key string, value []byte, ttl time.Duration) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.data[key] = value
+	f.expires[key] = time.Now().Add(ttl)
+	return nil
+}
+
+func (f *fakeRedis) Del(key string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.data, key)
+	delete(f.expires, key)
+	return nil
+}
+
+func (f *fakeRedis) Scan(cursor uint64, match string, count int64) ([]string, uint64, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var all []string
+	for key := range f.data {
+		f.expire(key)
+		if ok, _ := path.Match(match, key); ok && f.data[key] != nil {
+			all = append(all, key)
+		}
+	}
+	sort.Strings(all)
+
+	end := cursor + uint64(count)
+	if end >= uint64(len(all)) {
+		if cursor >= uint64(len(all)) {
+			return nil, 0, nil
+		}
+		return all[cursor:], 0, nil
+	}
+	return all[cursor:end], end, nil
+}
+
+func (f *fakeRedis) ttl(key string) time.Duration {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return time.Until(f.expires[key])
+}
+
+func newManager(client Client) *session.SessionManager {
+	return session.New(session.SessionManagerConfig{
+		CleanerInterval: time.Minute,
+		MaxLifetime:     time.Hour,
+		Store:           New(client, Options{}),
+	})
+}
+
+func TestStore(t *testing.T) {
+	redis := newFakeRedis()
+	sm := newManager(redis)
+	defer sm.Close()
+
+	// Case 1: Session Written With MaxLifetime TTL
+	s, err := sm.SessionCreate("sessionid123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	s.Set("key1", "value1")
+	s.SetUserID("user1")
+	if ttl := redis.ttl(DefaultPrefix + "sessionid123"); ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Errorf("Expected TTL of about an hour, got %v", ttl)
+	}
+
+	// Case 2: Session Shared By Another Replica
+	other := newManager(redis)
+	defer other.Close()
+	if !other.SessionExist("sessionid123") {
+		t.Fatalf("Expected session to exist on the other replica")
+	}
+	if other.SessionCount() != 1 {
+		t.Errorf("Expected 1 session, got %v", other.SessionCount())
+	}
+
+	// Case 3: Expired Through The TTL
+	redis.Set(DefaultPrefix+"sessionid456", []byte("x"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if sm.SessionExist("sessionid456") {
+		t.Errorf("Expected sessionid456 to have expired")
+	}
+
+	// Case 4: Destroy Deletes The Key
+	if err := other.SessionDestroy("sessionid123"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if data, _ := redis.Get(DefaultPrefix + "sessionid123"); data != nil {
+		t.Errorf("Expected key to be deleted")
+	}
+}
+
+func TestBackend_Scan(t *testing.T) {
+	redis := newFakeRedis()
+	b := NewBackend(redis, "app:")
+	for _, sid := range []string{"a", "b", "c"} {
+		b.Save(session.Record{ID: sid, Data: []byte(sid), ExpiresAt: time.Now().Add(time.Hour)})
+	}
+	for i := 0; i < 250; i++ {
+		redis.Set(fmt.Sprintf("other:%d", i), []byte("x"), time.Hour)
+	}
+
+	// Case 1: Only Prefixed Keys Counted
+	if n, err := b.Len(); err != nil || n != 3 {
+		t.Errorf("Expected 3 keys, got %v, %v", n, err)
+	}
+
+	// Case 2: Session Ids Without Prefix
+	var sids []string
+	b.Scan(func(sid string, data []byte) bool {
+		sids = append(sids, sid)
+		return true
+	})
+	if len(sids) != 3 || sids[0] != "a" {
+		t.Errorf("Expected [a b c], got %v", sids)
+	}
+}
+
+func TestEquivalence(t *testing.T) {
+	// Case 1: Same Behavior As MemoryStore
+	for seed := int64(1); seed <= 5; seed++ {
+		storetest.Equivalent(t, New(newFakeRedis(), Options{}), seed, 200)
+	}
+}
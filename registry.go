@@ -0,0 +1,91 @@
+package session
+
+import (
+	"encoding/gob"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Custom types stored as session values, by name, so codecs can tag values
+// and rebuild them with their concrete type after a restart or on another
+// instance
+var registry = struct {
+	lock  sync.RWMutex
+	types map[string]reflect.Type
+	names map[reflect.Type]string
+}{types: make(map[string]reflect.Type), names: make(map[reflect.Type]string)}
+
+// Make values of the type of v round-trip through the codecs, e.g.
+// RegisterType(Cart{}) before storing a Cart in a session. Register a type
+// once, from an init function, on every instance sharing the store; the name
+// is derived from the package path and type name. Registering a type again
+// under another name panics, as gob.Register does.
+func RegisterType(v interface{}) {
+	registerType(typeName(reflect.TypeOf(v)), v)
+	gob.Register(v)
+}
+
+// Like RegisterType under an explicit name, which keeps stored sessions
+// readable after the type is renamed or moved
+func RegisterTypeName(name string, v interface{}) {
+	registerType(name, v)
+	gob.RegisterName(name, v)
+}
+
+func registerType(name string, v interface{}) {
+	if name == "" {
+		panic("session: registering type with empty name")
+	}
+	t := reflect.TypeOf(v)
+	if t == nil {
+		panic("session: registering nil type")
+	}
+
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	if n, ok := registry.names[t]; ok && n != name {
+		panic(fmt.Sprintf("session: registering %v as %q, already registered as %q", t, name, n))
+	}
+	if other, ok := registry.types[name]; ok && other != t {
+		panic(fmt.Sprintf("session: registering %v as %q, already used by %v", t, name, other))
+	}
+	registry.types[name] = t
+	registry.names[t] = name
+}
+
+// Type registered under name, for codecs rebuilding tagged values
+func RegisteredType(name string) (reflect.Type, bool) {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+
+	t, ok := registry.types[name]
+	return t, ok
+}
+
+// Name the type of v was registered under, for codecs tagging values
+func RegisteredName(v interface{}) (string, bool) {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+
+	name, ok := registry.names[reflect.TypeOf(v)]
+	return name, ok
+}
+
+// Package path and name of t, with a leading * per pointer level
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+
+	star := ""
+	for t.Kind() == reflect.Pointer && t.Name() == "" {
+		star += "*"
+		t = t.Elem()
+	}
+	if t.Name() == "" || t.PkgPath() == "" {
+		return star + t.String()
+	}
+	return star + t.PkgPath() + "." + t.Name()
+}
@@ -0,0 +1,70 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type registryCart struct {
+	Items []string
+	Total float64
+}
+
+type registryItem struct {
+	SKU string
+}
+
+func init() {
+	RegisterType(registryCart{})
+	RegisterTypeName("session.item", &registryItem{})
+}
+
+func TestRegisterType(t *testing.T) {
+	// Case 1: Derived Name
+	name, ok := RegisteredName(registryCart{})
+	if !ok || name != "github.com/vpatel95/session-manager/v2.registryCart" {
+		t.Errorf("Expected derived name, got %q, %v", name, ok)
+	}
+	if got, ok := RegisteredType(name); !ok || got != reflect.TypeOf(registryCart{}) {
+		t.Errorf("Expected registryCart, got %v, %v", got, ok)
+	}
+
+	// Case 2: Explicit Name
+	if name, ok := RegisteredName(&registryItem{}); !ok || name != "session.item" {
+		t.Errorf("Expected session.item, got %q, %v", name, ok)
+	}
+	if _, ok := RegisteredName(registryItem{}); ok {
+		t.Errorf("Expected the value type not to be registered")
+	}
+
+	// Case 3: Values Round-Trip Through A Store
+	backend := newMapBackend()
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: NewBackendStore(backend, nil)})
+	s, _ := sm.SessionCreate("sessionid123")
+	if err := s.Set("cart", registryCart{Items: []string{"a"}, Total: 2.5}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Set("item", &registryItem{SKU: "b"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	other := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: NewBackendStore(backend, nil)})
+	got, ok := other.session("sessionid123")
+	if !ok {
+		t.Fatalf("Expected session to exist")
+	}
+	if cart, ok := got.Get("cart").(registryCart); !ok || cart.Total != 2.5 || len(cart.Items) != 1 {
+		t.Errorf("Expected cart, got %#v", got.Get("cart"))
+	}
+	if item, ok := got.Get("item").(*registryItem); !ok || item.SKU != "b" {
+		t.Errorf("Expected item, got %#v", got.Get("item"))
+	}
+
+	// Case 4: Conflicting Registration
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic, got none")
+		}
+	}()
+	RegisterTypeName("session.other", registryCart{})
+}
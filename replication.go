@@ -0,0 +1,171 @@
+package session
+
+import (
+	"errors"
+	"sync"
+)
+
+// How many replicas, this instance included, must take part in a read or a
+// write when Config.Peers is set
+type Consistency int
+
+const (
+	// This instance alone. Writes are still sent to every peer, without
+	// waiting for them.
+	ConsistencyOne Consistency = iota
+	// A majority of the instances
+	ConsistencyQuorum
+	// Every instance
+	ConsistencyAll
+)
+
+// Returned when fewer peers than the configured consistency needs answered.
+// The local change is kept and sent again with the next write.
+var ErrConsistency = errors.New("not enough replicas answered")
+
+// Number of peers that must answer on top of this instance
+func (c Consistency) peers(n int) int {
+	switch c {
+	case ConsistencyQuorum:
+		return (n + 1) / 2
+	case ConsistencyAll:
+		return n
+	default:
+		return 0
+	}
+}
+
+// Run op against every peer concurrently and wait until need of them
+// succeeded. The remaining calls finish in the background.
+func (sm *SessionManager) fanOut(need int, op func(p Peer) error) error {
+	peers := sm.Config.Peers
+	results := make(chan error, len(peers))
+	for _, p := range peers {
+		go func(p Peer) {
+			results <- op(p)
+		}(p)
+	}
+
+	var succeeded, failed int
+	for succeeded < need {
+		if err := <-results; err != nil {
+			if failed++; len(peers)-failed < need {
+				return ErrConsistency
+			}
+			continue
+		}
+		succeeded++
+	}
+
+	return nil
+}
+
+// Send a copy of s to the peers
+func (sm *SessionManager) replicate(s *Session) error {
+	if len(sm.Config.Peers) == 0 {
+		return nil
+	}
+
+	snap := s.snapshot()
+	return sm.fanOut(sm.Config.WriteConsistency.peers(len(sm.Config.Peers)), func(p Peer) error {
+		return p.StoreSession(snap)
+	})
+}
+
+func (sm *SessionManager) replicateDelete(sid string) error {
+	if len(sm.Config.Peers) == 0 {
+		return nil
+	}
+
+	return sm.fanOut(sm.Config.WriteConsistency.peers(len(sm.Config.Peers)), func(p Peer) error {
+		return p.DeleteSession(sid)
+	})
+}
+
+// Reconcile the local copy of sid, nil if there is none, with the copies of
+// as many peers as Config.ReadConsistency asks for, see
+// Config.ConflictResolver. The result is adopted or applied locally.
+func (sm *SessionManager) readReplicas(sid string, local *Session) (*Session, error) {
+	if len(sm.Config.Peers) == 0 {
+		return local, nil
+	}
+
+	need := sm.Config.ReadConsistency.peers(len(sm.Config.Peers))
+	if need == 0 {
+		if local == nil {
+			local = sm.fetchFromPeers(sid)
+		}
+		return local, nil
+	}
+
+	var lock sync.Mutex
+	var copies []Snapshot
+	err := sm.fanOut(need, func(p Peer) error {
+		snap, found, err := p.FetchSession(sid)
+		if err != nil || !found || snap.ID != sid || !sm.live(snap) {
+			return err
+		}
+
+		lock.Lock()
+		copies = append(copies, snap)
+		lock.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lock.Lock()
+	copies = append([]Snapshot(nil), copies...)
+	lock.Unlock()
+
+	if len(copies) == 0 {
+		return local, nil
+	}
+
+	if local == nil {
+		merged := copies[0]
+		for _, c := range copies[1:] {
+			merged = sm.conflictResolver().Resolve(merged, c)
+		}
+		return sm.adopt(merged), nil
+	}
+
+	for _, c := range copies {
+		sm.reconcile(local, c)
+	}
+	return local, sm.save(local)
+}
+
+// Store a copy sent by a peer, resolving conflicts with the local one with
+// Config.ConflictResolver. Copies applied this way are not replicated again.
+func (sm *SessionManager) ApplySnapshot(snap Snapshot) {
+	if !sm.live(snap) {
+		return
+	}
+
+	s, ok := sm.session(snap.ID)
+	if !ok || s == nil {
+		sm.adopt(snap)
+		return
+	}
+
+	sm.reconcile(s, snap)
+	sm.save(s)
+}
+
+// Remove a session a peer destroyed, without replicating the removal again
+func (sm *SessionManager) ApplyDelete(sid string) {
+	unlock := sm.lockSid(sid)
+	s := sm.lookup(sid)
+	if s != nil {
+		sm.store.Delete(sid)
+	}
+	sm.tombstone(sid)
+	unlock()
+
+	if s != nil {
+		sm.emit(Event{Type: EventDestroyed, SessionID: sid, RequestID: s.requestID(), Info: sm.eventInfo(s)})
+		sm.release(s)
+	}
+}
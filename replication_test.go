@@ -0,0 +1,154 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConsistency_Peers(t *testing.T) {
+	cases := []struct {
+		c     Consistency
+		peers int
+		want  int
+	}{
+		{ConsistencyOne, 2, 0},
+		{ConsistencyQuorum, 1, 1},
+		{ConsistencyQuorum, 2, 1},
+		{ConsistencyQuorum, 3, 2},
+		{ConsistencyQuorum, 4, 2},
+		{ConsistencyAll, 4, 4},
+	}
+	for _, c := range cases {
+		if got := c.c.peers(c.peers); got != c.want {
+			t.Errorf("Expected %v peers for %v of %v, got %v", c.want, c.c, c.peers, got)
+		}
+	}
+}
+
+func TestSessionManager_ReplicatedWrites(t *testing.T) {
+	b, c := New(), New()
+	a := New(SessionManagerConfig{
+		MaxLifetime:      time.Hour,
+		Peers:            []Peer{managerPeer{b}, failingPeer{}, managerPeer{c}},
+		WriteConsistency: ConsistencyQuorum,
+	})
+
+	// Case 1: Quorum Tolerates a Failing Peer
+	s, err := a.SessionCreate("sessionid123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Set("cart", 1); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	waitFor := func(cond func() bool) bool {
+		deadline := time.Now().Add(time.Second)
+		for !cond() && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		return cond()
+	}
+	for _, peer := range []*SessionManager{b, c} {
+		if !waitFor(func() bool { snap, ok := peer.Snapshot("sessionid123"); return ok && snap.Values["cart"] == 1 }) {
+			t.Errorf("Expected the write to reach every reachable peer")
+		}
+	}
+
+	// Case 2: All Fails With an Unreachable Peer
+	a.Config.WriteConsistency = ConsistencyAll
+	if err := s.Set("cart", 2); !errors.Is(err, ErrConsistency) {
+		t.Errorf("Expected ErrConsistency, got %v", err)
+	}
+	if s.Get("cart") != 2 {
+		t.Errorf("Expected the local write to be kept, got %v", s.Get("cart"))
+	}
+	for _, peer := range []*SessionManager{b, c} {
+		// Let the copies still in flight land before destroying
+		if !waitFor(func() bool { snap, ok := peer.Snapshot("sessionid123"); return ok && snap.Values["cart"] == 2 }) {
+			t.Errorf("Expected the write to reach every reachable peer")
+		}
+	}
+
+	// Case 3: Destroy Is Replicated
+	a.Config.WriteConsistency = ConsistencyQuorum
+	if err := a.SessionDestroy("sessionid123"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if !waitFor(func() bool { return !b.SessionExist("sessionid123") && !c.SessionExist("sessionid123") }) {
+		t.Errorf("Expected the session to be destroyed on every reachable peer")
+	}
+
+	// Case 4: Applied Copies Are Not Replicated Again
+	b.Config.Peers = []Peer{managerPeer{a}}
+	c.ApplySnapshot(Snapshot{ID: "sessionid456", LastAccessed: time.Now(), UpdatedAt: time.Now()})
+	if !c.SessionExist("sessionid456") || a.SessionExist("sessionid456") {
+		t.Errorf("Expected the copy to stay on c")
+	}
+}
+
+func TestSessionManager_ReplicatedReads(t *testing.T) {
+	b := New()
+	a := New(SessionManagerConfig{
+		MaxLifetime:     time.Hour,
+		Peers:           []Peer{managerPeer{b}},
+		ReadConsistency: ConsistencyQuorum,
+	})
+	read := func(sid string) (*Session, error) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(&http.Cookie{Name: a.Cookie.Name, Value: sid})
+		return a.SessionRead(req)
+	}
+
+	// Case 1: Newer Peer Copy Wins
+	s, _ := a.SessionCreate("sessionid123")
+	s.Set("cart", 1)
+	remote, _ := b.SessionCreate("sessionid123")
+	time.Sleep(time.Millisecond)
+	remote.Set("cart", 2)
+	if got, err := read("sessionid123"); err != nil || got != s || got.Get("cart") != 2 {
+		t.Errorf("Expected the newer peer copy to be applied, got %v, error: %v", got.Get("cart"), err)
+	}
+
+	// Case 2: Older Peer Copy Loses
+	time.Sleep(time.Millisecond)
+	s.Set("cart", 3)
+	b.SessionDestroy("sessionid123")
+	b.ApplySnapshot(Snapshot{ID: "sessionid123", LastAccessed: time.Now(), UpdatedAt: time.Now().Add(-time.Minute), Values: map[interface{}]interface{}{"cart": 0}})
+	if got, _ := read("sessionid123"); got.Get("cart") != 3 {
+		t.Errorf("Expected the local copy to win, got %v", got.Get("cart"))
+	}
+
+	// Case 3: Too Few Replicas
+	a.Config.Peers = []Peer{failingPeer{}}
+	if _, err := read("sessionid123"); !errors.Is(err, ErrConsistency) {
+		t.Errorf("Expected ErrConsistency, got %v", err)
+	}
+}
+
+func TestSessionManager_ReplicatedConcurrentAccess(t *testing.T) {
+	b := New()
+	a := New(SessionManagerConfig{MaxLifetime: time.Hour, Peers: []Peer{managerPeer{b}}})
+	s, _ := a.SessionCreate("sessionid123")
+
+	// Case 1: Refreshes Race Replicated Writes Safely
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			s.Set("cart", i)
+		}(i)
+		go func() {
+			defer wg.Done()
+			a.SessionUpdate("sessionid123")
+		}()
+	}
+	wg.Wait()
+	if !b.SessionExist("sessionid123") {
+		t.Errorf("Expected the session to be replicated")
+	}
+}
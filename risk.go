@@ -0,0 +1,82 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Scores how risky a request to a session looks. Set Config.RiskScorer to
+// have it consulted whenever a session is created from or read by a request.
+type RiskScorer interface {
+	Score(s *Session, r *http.Request) float64
+}
+
+type RiskAction int
+
+const (
+	// Only call Config.OnRisk
+	RiskNotify RiskAction = iota
+	// Flag the session as requiring step-up authentication
+	RiskStepUp
+	// Destroy the session
+	RiskDestroy
+)
+
+// Action taken when a risk score reaches Threshold
+type RiskRule struct {
+	Threshold float64
+	Action    RiskAction
+}
+
+var ErrSessionRisk = errors.New("session terminated due to risk score")
+
+// Reports whether a risk rule flagged the session for step-up authentication
+func (s *Session) StepUpRequired() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.stepUp
+}
+
+// Clear the step-up flag once the user has re-authenticated
+func (s *Session) StepUpComplete() {
+	s.lock.Lock()
+	s.stepUp = false
+	s.lock.Unlock()
+
+	s.commit(nil)
+}
+
+// Score the request and apply every rule whose threshold the score reaches
+func (sm *SessionManager) assessRisk(s *Session, r *http.Request) error {
+	if sm.Config.RiskScorer == nil {
+		return nil
+	}
+
+	score := sm.Config.RiskScorer.Score(s, r)
+	for _, rule := range sm.Config.RiskRules {
+		if score < rule.Threshold {
+			continue
+		}
+
+		if sm.Config.OnRisk != nil {
+			sm.Config.OnRisk(s, score, rule.Action)
+		}
+
+		switch rule.Action {
+		case RiskStepUp:
+			s.lock.Lock()
+			s.stepUp = true
+			s.lock.Unlock()
+			// Fail closed if the flag can't be stored
+			if err := s.commit(nil); err != nil {
+				return err
+			}
+		case RiskDestroy:
+			sm.SessionDestroy(s.ID())
+			return ErrSessionRisk
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type riskScorerFunc func(s *Session, r *http.Request) float64
+
+func (f riskScorerFunc) Score(s *Session, r *http.Request) float64 {
+	return f(s, r)
+}
+
+func TestSessionManager_RiskScorer(t *testing.T) {
+	sm := New()
+	score := 0.0
+	sm.Config.RiskScorer = riskScorerFunc(func(s *Session, r *http.Request) float64 {
+		return score
+	})
+	sm.Config.RiskRules = []RiskRule{
+		{Threshold: 0.3, Action: RiskNotify},
+		{Threshold: 0.6, Action: RiskStepUp},
+		{Threshold: 0.9, Action: RiskDestroy},
+	}
+	var actions []RiskAction
+	sm.Config.OnRisk = func(s *Session, score float64, action RiskAction) {
+		actions = append(actions, action)
+	}
+
+	newReq := func() *http.Request {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+		return req
+	}
+
+	// Case 1: Low Score on Create
+	s, err := sm.SessionCreateFromRequest("sessionid123", newReq())
+	if err != nil || len(actions) != 0 || s.StepUpRequired() {
+		t.Errorf("Expected no actions, got %v, error: %v", actions, err)
+	}
+
+	// Case 2: Notify Only
+	score = 0.4
+	if _, err = sm.SessionRead(newReq()); err != nil || len(actions) != 1 || actions[0] != RiskNotify {
+		t.Errorf("Expected [RiskNotify], got %v, error: %v", actions, err)
+	}
+	if s.StepUpRequired() {
+		t.Errorf("Expected step-up to not be required")
+	}
+
+	// Case 3: Step-Up Required
+	actions = nil
+	score = 0.7
+	if _, err = sm.SessionRead(newReq()); err != nil || len(actions) != 2 {
+		t.Errorf("Expected 2 actions, got %v, error: %v", actions, err)
+	}
+	if !s.StepUpRequired() {
+		t.Errorf("Expected step-up to be required")
+	}
+	s.StepUpComplete()
+	if s.StepUpRequired() {
+		t.Errorf("Expected step-up to be cleared")
+	}
+
+	// Case 4: Destroy on Read
+	score = 0.95
+	if _, err = sm.SessionRead(newReq()); err != ErrSessionRisk {
+		t.Errorf("Expected ErrSessionRisk, got %v", err)
+	}
+	if sm.SessionExist("sessionid123") {
+		t.Errorf("Expected sessionid123 to be destroyed")
+	}
+
+	// Case 5: Destroy on Create
+	if _, err = sm.SessionCreateFromRequest("sessionid456", newReq()); err != ErrSessionRisk {
+		t.Errorf("Expected ErrSessionRisk, got %v", err)
+	}
+	if sm.SessionExist("sessionid456") {
+		t.Errorf("Expected sessionid456 to be destroyed")
+	}
+}
@@ -0,0 +1,115 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// CleanerScheduler runs the cleaners of many managers from a single goroutine,
+// e.g. for per-tenant managers. Managers join it through Config.Scheduler and
+// keep their own CleanerInterval, rounded up to the scheduler resolution.
+type CleanerScheduler struct {
+	lock     sync.Mutex
+	managers map[*SessionManager]*scheduledCleaner
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+type scheduledCleaner struct {
+	interval time.Duration
+	next     time.Time
+}
+
+// Start a scheduler checking for due cleaners every resolution
+func NewCleanerScheduler(resolution time.Duration) *CleanerScheduler {
+	if resolution <= 0 {
+		resolution = time.Second
+	}
+
+	cs := &CleanerScheduler{
+		managers: make(map[*SessionManager]*scheduledCleaner),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go cs.run(resolution)
+
+	return cs
+}
+
+func (cs *CleanerScheduler) run(resolution time.Duration) {
+	defer close(cs.done)
+
+	ticker := time.NewTicker(resolution)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			for _, sm := range cs.due(now) {
+				sm.GlobalCleaner()
+			}
+		case <-cs.stop:
+			return
+		}
+	}
+}
+
+func (cs *CleanerScheduler) due(now time.Time) []*SessionManager {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	var due []*SessionManager
+	for sm, c := range cs.managers {
+		if c.interval > 0 && !now.Before(c.next) {
+			c.next = now.Add(c.interval)
+			due = append(due, sm)
+		}
+	}
+
+	return due
+}
+
+func (cs *CleanerScheduler) add(sm *SessionManager, interval time.Duration) {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	cs.managers[sm] = &scheduledCleaner{interval: interval, next: time.Now().Add(interval)}
+}
+
+func (cs *CleanerScheduler) remove(sm *SessionManager) {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	delete(cs.managers, sm)
+}
+
+// Returns false if sm is not registered
+func (cs *CleanerScheduler) setInterval(sm *SessionManager, interval time.Duration) bool {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	c, ok := cs.managers[sm]
+	if ok {
+		c.interval = interval
+		c.next = time.Now().Add(interval)
+	}
+
+	return ok
+}
+
+// Number of managers currently registered
+func (cs *CleanerScheduler) Len() int {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	return len(cs.managers)
+}
+
+// Stop the scheduler. Registered managers stop being cleaned automatically.
+func (cs *CleanerScheduler) Stop() {
+	cs.once.Do(func() {
+		close(cs.stop)
+		<-cs.done
+	})
+}
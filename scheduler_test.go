@@ -0,0 +1,55 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCleanerScheduler(t *testing.T) {
+	cs := NewCleanerScheduler(5 * time.Millisecond)
+	defer cs.Stop()
+
+	config := SessionManagerConfig{CleanerInterval: 10 * time.Millisecond, MaxLifetime: time.Hour, Scheduler: cs}
+	managers := []*SessionManager{New(config), New(config), New(config)}
+
+	// Case 1: Managers Register With the Scheduler
+	if cs.Len() != len(managers) {
+		t.Errorf("Expected %d managers, got %d", len(managers), cs.Len())
+	}
+
+	// Case 2: Expired Sessions of Every Manager Are Cleaned
+	for _, sm := range managers {
+		sm.SessionCreate("sessionid123")
+		sm.stored("sessionid123").age(2 * time.Hour)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for _, sm := range managers {
+		for sm.SessionExist("sessionid123") && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if sm.SessionExist("sessionid123") {
+			t.Errorf("Expected sessionid123 to be cleaned up")
+		}
+	}
+
+	// Case 3: Disabled Interval Is Skipped
+	managers[0].SetCleanerInterval(0)
+	managers[0].SessionCreate("sessionid456")
+	managers[0].stored("sessionid456").age(2 * time.Hour)
+	time.Sleep(50 * time.Millisecond)
+	if !managers[0].SessionExist("sessionid456") {
+		t.Errorf("Expected sessionid456 to survive with cleaner disabled")
+	}
+
+	// Case 4: Close Unregisters the Manager
+	for _, sm := range managers {
+		sm.Close()
+	}
+	if cs.Len() != 0 {
+		t.Errorf("Expected no managers, got %d", cs.Len())
+	}
+	managers[0].SetCleanerInterval(time.Millisecond)
+	if cs.Len() != 0 {
+		t.Errorf("Expected SetCleanerInterval after Close not to register, got %d", cs.Len())
+	}
+}
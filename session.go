@@ -4,8 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"net/url"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,17 +16,83 @@ type (
 )
 
 type Session struct {
+	manager      *SessionManager
 	sessionId    string
 	lastAccessed time.Time
+	updatedAt    time.Time
+	owner        string
+	leaseExpires time.Time
+	idleWarned   atomic.Bool
+	meta         Metadata
+	lastAccess   Access
+	stepUp       bool
+	userId       string
+	reads        atomic.Uint64
+	createStack  []byte
+	journal      []JournalEntry
+	requestId    string
+	flags        map[string]flagEntry
+	classes      map[string]*valueClass
+	keyClass     map[interface{}]string
+	guestId      string
+	promote      func() error
+	promoteLock  sync.Mutex
 	sd           dict
 	lock         sync.RWMutex
 }
 
+func (s *Session) ID() string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.sessionId
+}
+
+// Bind the session to a user, e.g. after login. Ignored while the manager
+// is frozen.
+func (s *Session) SetUserID(uid string) {
+	if s.frozen() != nil || s.promoteGuest() != nil {
+		return
+	}
+
+	forwarded := s.forward(Write{Op: WriteUserID, Value: uid})
+
+	s.lock.Lock()
+	old := s.userId
+	s.userId = uid
+	s.updatedAt = time.Now()
+	s.lock.Unlock()
+
+	if !forwarded {
+		s.commit(func() {
+			s.lock.Lock()
+			s.userId = old
+			s.lock.Unlock()
+		})
+	}
+}
+
+// Time of the last access. lastAccessed is guarded by s.lock like the rest
+// of the session.
+func (s *Session) accessed() time.Time {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.lastAccessed
+}
+
+func (s *Session) UserID() string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.userId
+}
+
 func (s *Session) Get(key interface{}) interface{} {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 
-	if val, ok := s.sd[key]; ok {
+	if val, ok := s.sd[key]; ok && !s.classExpired(key, time.Now()) {
 		return val
 	}
 
@@ -36,7 +103,7 @@ func (s *Session) Exist(key interface{}) bool {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 
-	if _, ok := s.sd[key]; ok {
+	if _, ok := s.sd[key]; ok && !s.classExpired(key, time.Now()) {
 		return true
 	}
 
@@ -44,70 +111,454 @@ func (s *Session) Exist(key interface{}) bool {
 }
 
 func (s *Session) Set(key, sd interface{}) error {
-	s.lock.Lock()
-	defer s.lock.Unlock()
+	if isReservedKey(key) {
+		return ErrReservedKey
+	}
+	if err := s.validate(key, sd); err != nil {
+		return err
+	}
+
+	return s.set(key, sd)
+}
+
+// Set without the reserved key check, for values managed by the package
+func (s *Session) set(key, sd interface{}) error {
+	return s.setIn("", time.Time{}, key, sd)
+}
+
+// set, also adding key to class until expires unless class is empty. The
+// class is changed with the value, so a failed commit reverts both.
+func (s *Session) setIn(class string, expires time.Time, key, sd interface{}) error {
+	if err := s.frozen(); err != nil {
+		return err
+	}
+	if err := s.promoteGuest(); err != nil {
+		return err
+	}
 
+	key = s.internKey(key)
+	forwarded := s.forward(Write{Op: WriteSet, Key: key, Value: sd})
+
+	s.lock.Lock()
+	if s.classExpired(key, time.Now()) {
+		// A new value must not vanish with the class of the old one
+		s.unclass(key)
+	}
+	unclassify := func() {}
+	if class != "" {
+		unclassify = s.classify(class, key, expires)
+	}
+	old, existed := s.sd[key]
 	s.sd[key] = sd
+	s.record(key, old, existed, sd, true)
+	s.updatedAt = time.Now()
+	s.lock.Unlock()
 
-	return nil
+	if forwarded {
+		return nil
+	}
+	undo := s.undoer(map[interface{}]txValue{key: {value: old, deleted: !existed}})
+	return s.commit(func() {
+		undo()
+		s.lock.Lock()
+		unclassify()
+		s.lock.Unlock()
+	})
 }
 
 func (s *Session) Delete(key interface{}) error {
+	if isReservedKey(key) {
+		return ErrReservedKey
+	}
+
+	return s.delete(key)
+}
+
+func (s *Session) delete(key interface{}) error {
+	if err := s.frozen(); err != nil {
+		return err
+	}
+
+	forwarded := s.forward(Write{Op: WriteDelete, Key: key})
+
 	s.lock.Lock()
-	defer s.lock.Unlock()
+	old, existed := s.sd[key]
+	s.unclass(key)
+	if existed {
+		delete(s.sd, key)
+		s.record(key, old, true, nil, false)
+		s.updatedAt = time.Now()
+	}
+	s.lock.Unlock()
 
-	delete(s.sd, key)
+	if !existed || forwarded {
+		return nil
+	}
+	return s.commit(s.undoer(map[interface{}]txValue{key: {value: old}}))
+}
 
-	return nil
+// Map size hint from config, ignoring nonsensical values
+func sizeHint(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return n
 }
 
+func (sm *SessionManager) newSession(sid string) *Session {
+	now := time.Now()
+	if sm.Config.PoolSessions {
+		s := sm.pool.get(sizeHint(sm.Config.ExpectedKeysPerSession))
+		s.manager = sm
+		s.sessionId = sid
+		s.lastAccessed = now
+		s.updatedAt = now
+		s.meta.CreatedAt = now
+		return s
+	}
+
+	return &Session{
+		manager:      sm,
+		sessionId:    sid,
+		lastAccessed: now,
+		updatedAt:    now,
+		meta:         Metadata{CreatedAt: now},
+		sd:           make(dict, sizeHint(sm.Config.ExpectedKeysPerSession)),
+	}
+}
+
+// Longest session cookie or header value accepted when
+// Config.MaxSessionIDLength is not set
+const DefaultMaxSessionIDLength = 512
+
+var ErrSessionIDTooLong = errors.New("session id is too long")
+
 type SessionCookie struct {
 	Name     string
 	Domain   string
+	Path     string // "/" if empty
 	HTTPOnly bool
 	Secure   bool
-	Lifetime time.Duration
+	Lifetime time.Duration // zero issues a browser-session cookie
+	Expiry   CookieExpiry
+
+	// Issue cookies without Expires and Max-Age, dropped when the browser
+	// closes, whatever Lifetime and Expiry say
+	BrowserSession bool
+
+	// Prepended to the names of all cookies of the package, which are then
+	// written with the attributes it requires, see Validate
+	Prefix CookiePrefix
 }
 
 type SessionManagerConfig struct {
 	CleanerInterval    time.Duration
 	MaxLifetime        time.Duration
-	CookieLifetime     time.Duration
+	CookieLifetime     time.Duration // Deprecated: use SessionManager.Cookie.Lifetime, New copies this value there
 	EnableHttpHeader   bool
 	SessionHeader      string
 	AutoRefreshSession bool
+	EnableExpiryHeader bool
+	ExpiryHeader       string
+
+	// Keep Middleware, SessionStart and SessionRegenerate from marking
+	// responses private with Cache-Control and Vary, e.g. when a caching
+	// layer in front already keys on the session
+	DisableCacheHeaders bool
+
+	// Re-issue the cookie with a new expiry whenever the middleware or
+	// KeepAliveHandler refreshes the session
+	RollingCookies bool
+
+	// Where RequireSession and RequireKeys redirect unauthorized requests,
+	// they respond with 401 if empty
+	LoginURL string
+
+	// Where RequireSudo redirects requests outside a sudo window to
+	// re-authenticate, it responds with 403 if empty
+	SudoURL string
+
+	// Called by the cleaner once a session is within IdleWarningThreshold
+	// of expiring. Accessing the session again re-arms the warning.
+	IdleWarningThreshold time.Duration
+	OnIdleWarning        func(s *Session, remaining time.Duration)
+
+	// Called by the cleaner with a summary of every session it is about to
+	// delete as expired. Middleware records the last page of sessions only
+	// while it is set.
+	ExpirySink func(sum ExpirySummary)
+
+	TrustedDeviceLifetime time.Duration
+
+	// Longest session cookie or header value accepted, as sent including
+	// any signature. Longer ones are rejected with ErrSessionIDTooLong
+	// before they reach the store. DefaultMaxSessionIDLength if zero.
+	MaxSessionIDLength int
+
+	// HMAC keys signing session ids in the cookie and session header, so
+	// forged or truncated ids are rejected before the store is asked. The
+	// first key signs, all of them verify: to rotate, put the new key first
+	// and drop the old one after MaxLifetime.
+	SessionIDKeys [][]byte
+
+	// AES keys of 16, 24 or 32 bytes sealing the session cookie value with
+	// AES-GCM, so the session id never appears on the wire. Cookies that
+	// fail to unseal are treated as carrying no session. The first key
+	// seals, all of them unseal: to rotate, put the new key first and drop
+	// the old one after MaxLifetime.
+	CookieKeys [][]byte
+
+	// Makes the ids of sessions started by SessionStart or promoted from
+	// guests, DefaultIDGenerator if nil
+	IDGenerator IDGenerator
+
+	GeoResolver GeoResolver
+
+	// Reject SessionRead with ErrIPMismatch for requests from another IP
+	// than the one the session was created from with
+	// SessionCreateFromRequest, so a stolen cookie can't be replayed from
+	// another network
+	BindIP bool
+
+	// Reject SessionRead with ErrUserAgentMismatch for requests with another
+	// User-Agent than the one the session was created with by
+	// SessionCreateFromRequest, e.g. to force re-authentication
+	BindUserAgent bool
+
+	// Addresses or CIDR ranges of reverse proxies whose X-Forwarded-For
+	// header is trusted to name the client IP
+	TrustedProxies []string
+
+	// Request headers recorded in Metadata.Headers when a session is created
+	// from a request, e.g. {"Accept-Language", "Sec-CH-UA"}. No others are
+	// kept.
+	CaptureHeaders []string
+
+	// Called when consecutive accesses to a session come from locations
+	// further apart than MaxTravelSpeed (km/h) allows. Returning true
+	// destroys the session.
+	MaxTravelSpeed     float64
+	OnImpossibleTravel func(s *Session, from, to Access) bool
+
+	RiskScorer RiskScorer
+	RiskRules  []RiskRule
+	OnRisk     func(s *Session, score float64, action RiskAction)
+
+	OnDecoy func(sid string, r *http.Request)
+
+	// Resolution of requests whose cookie and session header name different
+	// sessions, and a hook called with every such request, e.g. to log it
+	// as a possible session fixation or injection attempt
+	IDConflictPolicy IDConflictPolicy
+	OnIDConflict     func(r *http.Request)
+
+	// Called for every session lifecycle event
+	OnEvent func(e Event)
+
+	// Handling of sessions a BackendStore fails to decode, and a hook called
+	// with every such entry. The hook runs with the store locked for sid and
+	// must not use the manager for that session.
+	CorruptSessions  CorruptionPolicy
+	OnCorruptSession func(sid string, data []byte, err error)
+
+	// Keys whose values Dump writes out, all others are redacted. Keys in
+	// RedactKeys are redacted even if listed here.
+	DumpKeys   []string
+	RedactKeys []string
+
+	// Write session ids in Dump instead of only their AffinityHash
+	DumpSessionIDs bool
+
+	// Record the creation stack of every LeakSampleRate-th session so
+	// LeakReport can point at sessions never read after LeakThreshold
+	LeakSampleRate int
+	LeakThreshold  time.Duration
+
+	// Number of key mutations kept per session for Session.Journal, zero
+	// disables the journal
+	JournalSize int
+
+	// Share one copy of each string key across all sessions, cutting memory
+	// when millions of sessions store the same few keys
+	InternKeys bool
+
+	// Reuse destroyed and expired Session objects to reduce GC pressure at
+	// high create/destroy rates. Sessions must not be used after they were
+	// destroyed or expired when this is set.
+	PoolSessions bool
+
+	// Number of expired sessions the cleaner removes per write lock
+	// acquisition, DefaultCleanerBatchSize if zero
+	CleanerBatchSize int
+
+	// HMAC key signing guest tokens, required by GuestSession. GuestCookie
+	// names the token cookie, DefaultGuestCookie if empty.
+	GuestKey    []byte
+	GuestCookie string
+
+	// Where SetAffinity writes the affinity hash for sticky load balancing:
+	// the cookie name, DefaultAffinityCookie if empty, and an optional
+	// response header
+	AffinityCookie string
+	AffinityHeader string
+
+	// Hash of session ids written by SetAffinity and picking the store lock
+	// stripe of a session, XXHash if nil. Set it to match the load
+	// balancer's hashing for locality. Session handles stay AffinityHash.
+	Hash HashFunc
+
+	// Other instances asked for sessions that miss locally. The first live
+	// copy is adopted into this manager.
+	Peers []Peer
+
+	// Replicas taking part in reads and writes when Peers is set. Writes
+	// return ErrConsistency when too few peers acknowledged them.
+	ReadConsistency  Consistency
+	WriteConsistency Consistency
+
+	// Merges copies of a session written on two instances, LastWriteWins if
+	// nil
+	ConflictResolver ConflictResolver
+
+	// How often RepairReplicas reconciles copies with the peers, never if
+	// zero
+	AntiEntropyInterval time.Duration
+
+	// Pin sessions to the instance NodeID that created or last took them
+	// over: other instances forward writes to the owner while its lease of
+	// LeaseDuration, renewed by every write, runs. An unreachable owner loses
+	// the lease to the writing instance. Disabled if either is unset.
+	NodeID        string
+	LeaseDuration time.Duration
+
+	// Shared scheduler running the cleaner instead of a goroutine of the
+	// manager's own, see NewCleanerScheduler
+	Scheduler *CleanerScheduler
+
+	// How long Session.Flag caches evaluated feature flags, DefaultFlagTTL
+	// if zero
+	FlagTTL time.Duration
+
+	// Called when Session.Bucket first assigns a session to a variant
+	OnBucket func(s *Session, experiment, variant string)
+
+	// Validators run by Set, SetIn and Tx.Set on the values of a key, e.g.
+	// {"email": Matches(emailRe)}. Validate runs on every value set.
+	Validators map[interface{}]Validator
+	Validate   func(key, value interface{}) error
+
+	// Lifetimes of the value classes used with Session.SetIn by name,
+	// e.g. {"auth": 30 * time.Minute}
+	ValueClasses map[string]time.Duration
+
+	// Header carrying the request ID Middleware attaches to sessions,
+	// DefaultRequestIDHeader if empty
+	RequestIDHeader string
+
+	// Session table, a MemoryStore sized for ExpectedSessions if nil
+	Store Store
+
+	// Prefix of the keys this manager's sessions are kept under in a
+	// BackendStore, followed by a dot, so several applications can share
+	// one backend without reading, counting or expiring each other's
+	// sessions. Give every application sharing the backend its own. Dots
+	// and percent signs in it are escaped as %2E and %25.
+	Namespace string
+
+	// Hand sessions to a Store other than MemoryStore at most this often
+	// instead of on every write: writes only mark the session dirty, and a
+	// background flusher stores the dirty sessions in one batch per interval
+	// (see BatchSetter). Writes lost with the process or failing to store
+	// can't be undone or reported to the caller; failed ones are retried.
+	WriteBehind time.Duration
+
+	// Expire sessions not accessed for IdleTimeout, MaxLifetime if zero,
+	// and sessions created AbsoluteLifetime ago however often they are
+	// accessed, never if zero
+	IdleTimeout      time.Duration
+	AbsoluteLifetime time.Duration
+
+	// Tolerance for clocks of nodes sharing a store running ahead of each
+	// other: a session counts as expired only ClockSkew after it would
+	// otherwise have, so a node with a fast clock doesn't expire sessions
+	// last accessed elsewhere early. Expiry times reported to clients are
+	// not moved.
+	ClockSkew time.Duration
+
+	// Sizing hints so maps are allocated up-front instead of rehashing
+	// repeatedly under load
+	ExpectedSessions       int
+	ExpectedKeysPerSession int
 }
 
 type SessionManager struct {
-	lock     sync.RWMutex
-	sessions sessDict
-	Config   SessionManagerConfig
-	Cookie   SessionCookie
+	lock       sync.RWMutex
+	store      Store
+	storeLocks storeLocks
+	markLock   sync.RWMutex
+	decoys     map[string]struct{}
+	tombstones map[string]time.Time
+	deviceLock sync.RWMutex
+	devices    deviceDict
+	eventLock  sync.Mutex
+	events     []Event
+	eventPos   int
+	contention contention
+	corruption corruption
+	freezes    atomic.Int32
+	watch      watch
+	jobs       jobs
+
+	writeBehind *writeBehind
+
+	cleanerLastRun     atomic.Int64
+	cleanerLastExpired atomic.Int64
+	leakCounter        atomic.Uint64
+	interner           interner
+	leases             leaseCounters
+	flagGen            atomic.Uint64
+	pool               sessionPool
+	cleaner            cleaner
+	antiEntropy        antiEntropy
+	Config             SessionManagerConfig
+	Cookie             SessionCookie
 }
 
+// Session id of the request, from the cookie or else the session header.
+// When both are present and differ Config.IDConflictPolicy decides. Values
+// over Config.MaxSessionIDLength are rejected with ErrSessionIDTooLong, and
+// with Config.SessionIDKeys set ids without a valid signature with
+// ErrInvalidSessionID.
 func (sm *SessionManager) GetSessionId(r *http.Request) (string, error) {
-	cookie, err := r.Cookie(sm.Cookie.Name)
+	cookie, err := r.Cookie(sm.cookieName(sm.Cookie.Name))
 
 	if err != nil || cookie.Value == "" {
 
 		if sm.Config.EnableHttpHeader {
 			sids, found := r.Header[sm.Config.SessionHeader]
 			if found && len(sids) != 0 {
-				return sids[0], nil
+				if sids[0] == "" {
+					return "", nil
+				}
+				return sm.headerID(sids[0])
 			}
 		}
 
 		return "", err
 	}
 
-	return url.QueryUnescape(cookie.Value)
+	sid, err := sm.cookieID(cookie.Value)
+	if err != nil || !sm.Config.EnableHttpHeader {
+		return sid, err
+	}
+	return sm.resolveIDConflict(r, sid)
 }
 
 func (sm *SessionManager) GetSessionIdFromHeader(r *http.Request) (string, error) {
 	if sm.Config.EnableHttpHeader {
 		sids, found := r.Header[sm.Config.SessionHeader]
 		if found && len(sids) != 0 && sids[0] != "" {
-			return sids[0], nil
+			return sm.headerID(sids[0])
 		}
 	}
 
@@ -115,82 +566,199 @@ func (sm *SessionManager) GetSessionIdFromHeader(r *http.Request) (string, error
 }
 
 func (sm *SessionManager) GetSessionIdFromCookie(r *http.Request) (string, error) {
-	cookie, err := r.Cookie(sm.Cookie.Name)
+	cookie, err := r.Cookie(sm.cookieName(sm.Cookie.Name))
 
 	if err != nil || cookie.Value == "" {
 		return "", fmt.Errorf("error getting session id from cookie : %v", err)
 	}
 
-	return url.QueryUnescape(cookie.Value)
+	sid, err := sm.cookieID(cookie.Value)
+	if err == nil && sid == "" {
+		return "", errors.New("error getting session id from cookie : cookie does not unseal")
+	}
+	return sid, err
 }
 
-func (sm *SessionManager) ListSessions() {
-	sm.lock.RLock()
-	for _, s := range sm.sessions {
-		if s == nil {
-			continue
-		}
+func (sm *SessionManager) maxSessionIDLength() int {
+	if sm.Config.MaxSessionIDLength > 0 {
+		return sm.Config.MaxSessionIDLength
 	}
-	sm.lock.RUnlock()
+	return DefaultMaxSessionIDLength
+}
+
+// Session id carried by a session header value
+func (sm *SessionManager) headerID(value string) (string, error) {
+	if len(value) > sm.maxSessionIDLength() {
+		return "", ErrSessionIDTooLong
+	}
+	return sm.verifyID(value)
+}
+
+// Print all the sessions in the manager to stdout. See Dump.
+func (sm *SessionManager) ListSessions() {
+	sm.Dump(os.Stdout, FormatText)
 }
 
 func (sm *SessionManager) SessionCount() int {
-	return len(sm.sessions)
+	sm.rlock()
+	defer sm.lock.RUnlock()
+
+	return sm.store.Count()
 }
 
 func (sm *SessionManager) SessionRefresh(oldSid, sid string) (*Session, error) {
-	sm.lock.Lock()
-	defer sm.lock.Unlock()
+	return sm.refresh(oldSid, sid, false)
+}
+
+// Move session oldSid to sid. If exclusive, errIDInUse is returned instead
+// of replacing a session or decoy using sid.
+func (sm *SessionManager) refresh(oldSid, sid string, exclusive bool) (*Session, error) {
+	if sm.Frozen() {
+		return nil, ErrFrozen
+	}
+
+	unlock := sm.lockSid(oldSid, sid)
+
+	if exclusive && oldSid != sid {
+		if taken, err := sm.store.Get(sid); err != nil || taken != nil || sm.IsDecoy(sid) {
+			unlock()
+			if err != nil {
+				return nil, err
+			}
+			return nil, errIDInUse
+		}
+	}
+	if sm.IsDecoy(sid) {
+		unlock()
+		return nil, errors.New("session id is reserved as a decoy")
+	}
 
-	if s, ok := sm.sessions[oldSid]; ok {
+	old, err := sm.store.Get(oldSid)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	if old != nil && oldSid == sid {
+		// Nothing to move, and deleting oldSid would destroy the session
+		unlock()
+		return old, nil
+	}
+
+	if s := old; s != nil {
+		s.lock.Lock()
 		s.sessionId = sid
-		sm.sessions[sid] = s
-		delete(sm.sessions, oldSid)
+		s.lock.Unlock()
+		if err := sm.store.Set(sid, s); err != nil {
+			s.lock.Lock()
+			s.sessionId = oldSid
+			s.lock.Unlock()
+			unlock()
+			return nil, err
+		}
+		sm.store.Delete(oldSid)
+		sm.tombstone(oldSid)
+		unlock()
 
-		return s, nil
+		sm.emit(Event{Type: EventRefreshed, SessionID: sid, PreviousID: oldSid, RequestID: s.requestID(), Info: sm.eventInfo(s)})
+		if err := sm.replicate(s); err != nil {
+			return s, err
+		}
+		return s, sm.replicateDelete(oldSid)
 	}
-	newSess := &Session{
-		sessionId:    sid,
-		lastAccessed: time.Now(),
-		sd:           make(dict),
+	newSess := sm.newSession(sid)
+	sm.sampleLeak(newSess)
+	if err := sm.store.Set(sid, newSess); err != nil {
+		unlock()
+		sm.release(newSess)
+		return nil, err
 	}
-	sm.sessions[sid] = newSess
+	unlock()
 
-	return newSess, nil
+	sm.emit(Event{Type: EventCreated, SessionID: sid, Info: sm.eventInfo(newSess)})
+	return newSess, sm.replicate(newSess)
+}
+
+func (sm *SessionManager) session(sid string) (*Session, bool) {
+	sm.rlock()
+	defer sm.lock.RUnlock()
+
+	s := sm.lookup(sid)
+	return s, s != nil
 }
 
 func (sm *SessionManager) SessionExist(sid string) bool {
-	sm.lock.RLock()
+	sm.rlock()
 	defer sm.lock.RUnlock()
 
-	_, ok := sm.sessions[sid]
-	return ok
+	return sm.lookup(sid) != nil
 }
 
 // Update the session access time. Refresh Session
 func (sm *SessionManager) SessionUpdate(sid string) error {
-	sm.lock.Lock()
-	defer sm.lock.Unlock()
+	if sm.Frozen() {
+		return ErrFrozen
+	}
 
-	if s, ok := sm.sessions[sid]; ok {
+	unlock := sm.lockSid(sid)
+	defer unlock()
+
+	s, err := sm.store.Get(sid)
+	if err != nil {
+		return err
+	}
+	if s != nil {
+		s.lock.Lock()
 		s.lastAccessed = time.Now()
-		return nil
+		s.lock.Unlock()
+		s.idleWarned.Store(false)
+		return sm.store.Set(sid, s)
 	}
 
 	return errors.New("error while updating session")
 }
 
+// Time left before the session expires if it is not accessed again
+func (sm *SessionManager) SessionExpiresIn(sid string) (time.Duration, error) {
+	sm.rlock()
+	defer sm.lock.RUnlock()
+
+	if s := sm.lookup(sid); s != nil {
+		remaining := time.Until(sm.sessionExpiry(s))
+		if remaining < 0 {
+			remaining = 0
+		}
+		return remaining, nil
+	}
+
+	return 0, errors.New("session not found")
+}
+
 // Remove the session for matching sid
 func (sm *SessionManager) SessionDestroy(sid string) error {
-	sm.lock.Lock()
-	defer sm.lock.Unlock()
+	if sm.Frozen() {
+		return ErrFrozen
+	}
 
-	if _, ok := sm.sessions[sid]; ok {
-		delete(sm.sessions, sid)
-		return nil
+	unlock := sm.lockSid(sid)
+	s, err := sm.store.Get(sid)
+	if err == nil && s != nil {
+		err = sm.store.Delete(sid)
+	}
+	if err == nil && s != nil {
+		sm.tombstone(sid)
+	}
+	unlock()
+
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return errors.New("error while deleting session")
 	}
 
-	return errors.New("error while deleting session")
+	sm.emit(Event{Type: EventDestroyed, SessionID: sid, RequestID: s.requestID(), Info: sm.eventInfo(s)})
+	sm.release(s)
+	return sm.replicateDelete(sid)
 }
 
 // Read session. Error out if session not found
@@ -200,50 +768,110 @@ func (sm *SessionManager) SessionRead(r *http.Request) (*Session, error) {
 		return nil, err
 	}
 
-	sm.lock.RLock()
-	defer sm.lock.RUnlock()
-	if s, ok := sm.sessions[sid]; ok {
-		if sm.Config.AutoRefreshSession {
-			go sm.SessionUpdate(sid)
+	sm.rlock()
+	s, err := sm.store.Get(sid)
+	sm.lock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	if sm.IsDecoy(sid) {
+		if sm.Config.OnDecoy != nil {
+			sm.Config.OnDecoy(sid, r)
+		}
+		return nil, errors.New("session not found")
+	}
+	if s == nil || sm.Config.ReadConsistency != ConsistencyOne {
+		if s, err = sm.readReplicas(sid, s); err != nil {
+			return nil, err
+		}
+		if s == nil {
+			return nil, errors.New("session not found")
 		}
-		return s, nil
 	}
 
-	return nil, errors.New("session not found")
-}
+	s.reads.Add(1)
 
-func (sm *SessionManager) SessionCreate(sid string) (*Session, error) {
-	if sid == "" {
-		return nil, errors.New("session id is empty")
+	if err := sm.checkIP(s, r); err != nil {
+		return nil, err
 	}
 
-	sm.lock.Lock()
-	defer sm.lock.Unlock()
+	if err := sm.checkUserAgent(s, r); err != nil {
+		return nil, err
+	}
 
-	s := &Session{
-		sessionId:    sid,
-		lastAccessed: time.Now(),
-		sd:           make(dict),
+	if err := sm.checkTravel(s, r); err != nil {
+		return nil, err
+	}
+
+	if err := sm.assessRisk(s, r); err != nil {
+		return nil, err
+	}
+
+	if sm.Config.AutoRefreshSession {
+		go sm.SessionUpdate(sid)
 	}
-	sm.sessions[sid] = s
 
 	return s, nil
 }
 
-func (sm *SessionManager) GlobalCleaner() {
-	sm.lock.Lock()
-	defer sm.lock.Unlock()
+func (sm *SessionManager) SessionCreate(sid string) (*Session, error) {
+	if sid == "" {
+		return nil, errors.New("session id is empty")
+	}
+	return sm.create(sid, nil, nil)
+}
+
+// Create session sid, or under a generated id if sid is empty, calling init
+// on it before it is stored and replicated. A guest session is promoted to
+// the new session instead of allocating one.
+func (sm *SessionManager) create(sid string, guest *Session, init func(s *Session)) (*Session, error) {
+	if sm.Frozen() {
+		return nil, ErrFrozen
+	}
 
-	for sid, s := range sm.sessions {
-		if s == nil {
-			continue
+	var unlock func()
+	if sid == "" {
+		var err error
+		if sid, unlock, err = sm.claimID(); err != nil {
+			return nil, err
 		}
+	} else {
+		unlock = sm.lockSid(sid)
+		if sm.IsDecoy(sid) {
+			unlock()
+			return nil, errors.New("session id is reserved as a decoy")
+		}
+	}
 
-		if time.Now().After(s.lastAccessed.Add(sm.Config.MaxLifetime)) {
-			delete(sm.sessions, sid)
+	s := guest
+	if s == nil {
+		s = sm.newSession(sid)
+	}
+	s.lock.Lock()
+	if guest != nil {
+		s.sessionId = sid
+		s.lastAccessed = time.Now()
+		s.meta.CreatedAt = s.lastAccessed
+	}
+	if init != nil {
+		init(s)
+	}
+	if sm.pinning() {
+		sm.lease(s)
+	}
+	s.lock.Unlock()
+	sm.sampleLeak(s)
+	if err := sm.store.Set(sid, s); err != nil {
+		unlock()
+		if guest == nil {
+			sm.release(s)
 		}
+		return nil, err
 	}
-	time.AfterFunc(sm.Config.CleanerInterval, func() { sm.GlobalCleaner() })
+	unlock()
+
+	sm.emit(Event{Type: EventCreated, SessionID: sid, RequestID: s.requestID(), Info: sm.eventInfo(s)})
+	return s, sm.replicate(s)
 }
 
 // Create a new instance of session manager.
@@ -257,24 +885,51 @@ func New(config ...SessionManagerConfig) *SessionManager {
 			EnableHttpHeader:   false,
 			SessionHeader:      "",
 			AutoRefreshSession: false,
+			EnableExpiryHeader: false,
+			ExpiryHeader:       DefaultExpiryHeader,
+
+			TrustedDeviceLifetime: DefaultTrustedDeviceLifetime,
 		}
 	} else {
 		smc = config[0]
 	}
 
 	sm := &SessionManager{
-		sessions: make(sessDict),
-		Config:   smc,
+		store:   smc.Store,
+		devices: make(deviceDict),
+		Config:  smc,
 		Cookie: SessionCookie{
 			Name:     "sessionid",
 			Domain:   "",
+			Path:     "/",
 			HTTPOnly: true,
 			Secure:   false,
 			Lifetime: 24 * time.Hour,
+			Expiry:   CookieRolling,
 		},
 	}
 
-	go sm.GlobalCleaner()
+	if sm.store == nil {
+		sm.store = NewMemoryStore(smc.ExpectedSessions)
+	}
+	if smc.WriteBehind > 0 && !sm.inMemory() {
+		sm.writeBehind = newWriteBehind(sm.store, smc.WriteBehind)
+		sm.store = sm.writeBehind
+	}
+	if a, ok := sm.store.(attachable); ok {
+		a.attach(sm)
+	}
+
+	if smc.CookieLifetime != 0 {
+		sm.Cookie.Lifetime = smc.CookieLifetime
+	}
+
+	sm.startCleaner()
+	sm.startAntiEntropy()
+	sm.startWatch()
+	if sm.writeBehind != nil {
+		sm.writeBehind.start()
+	}
 
 	return sm
 }
@@ -4,11 +4,26 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 )
 
+// Session sid straight from the store
+func (sm *SessionManager) stored(sid string) *Session {
+	s, _ := sm.session(sid)
+	return s
+}
+
+// Make s look last accessed d ago
+func (s *Session) age(d time.Duration) {
+	s.lock.Lock()
+	s.lastAccessed = time.Now().Add(-d)
+	s.lock.Unlock()
+}
+
 func TestSession_Get(t *testing.T) {
 	// Case 1: Key Exists
 	s := &Session{sd: make(dict)}
@@ -421,6 +436,9 @@ func TestSessionManager_SessionRefresh(t *testing.T) {
 	if err != nil || s.sessionId != "sessionid123" {
 		t.Errorf("Expected sessionid123, got %v, error: %v", s.sessionId, err)
 	}
+	if !sm.SessionExist("sessionid123") {
+		t.Errorf("Expected sessionid123 to survive refreshing to itself")
+	}
 
 	// Case 4: Concurrent Session Refresh
 	smConcurrent := New()
@@ -504,7 +522,7 @@ func TestSessionManager_SessionUpdate(t *testing.T) {
 	}
 
 	// Verify that the session's lastAccessed time was updated
-	session, _ := sm.sessions["sessionid123"]
+	session, _ := sm.session("sessionid123")
 	if time.Since(session.lastAccessed) > time.Second {
 		t.Errorf("Expected lastAccessed to be updated recently, got %v", session.lastAccessed)
 	}
@@ -532,13 +550,38 @@ func TestSessionManager_SessionUpdate(t *testing.T) {
 
 	for i := 0; i < 100; i++ {
 		sid := fmt.Sprintf("sessionid%d", i)
-		session, _ := smConcurrent.sessions[sid]
+		session, _ := smConcurrent.session(sid)
 		if time.Since(session.lastAccessed) > time.Second {
 			t.Errorf("Expected lastAccessed to be updated recently for %v, got %v", sid, session.lastAccessed)
 		}
 	}
 }
 
+func TestSessionManager_SessionExpiresIn(t *testing.T) {
+	sm := New()
+	sm.Config.MaxLifetime = 1 * time.Hour
+	sm.SessionCreate("sessionid123")
+
+	// Case 1: Fresh Session
+	remaining, err := sm.SessionExpiresIn("sessionid123")
+	if err != nil || remaining <= 59*time.Minute || remaining > time.Hour {
+		t.Errorf("Expected about 1h, got %v, error: %v", remaining, err)
+	}
+
+	// Case 2: Already Expired Session
+	sm.stored("sessionid123").age(2 * time.Hour)
+	remaining, err = sm.SessionExpiresIn("sessionid123")
+	if err != nil || remaining != 0 {
+		t.Errorf("Expected 0, got %v, error: %v", remaining, err)
+	}
+
+	// Case 3: Non-Existent Session
+	_, err = sm.SessionExpiresIn("sessionid456")
+	if err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
 func TestSessionManager_SessionDestroy(t *testing.T) {
 	sm := New()
 	sm.SessionCreate("sessionid123")
@@ -762,3 +805,159 @@ func TestSessionManager_GlobalCleaner(t *testing.T) {
 		}
 	}
 }
+
+func TestSessionManager_IdleWarning(t *testing.T) {
+	sm := New()
+	sm.Config.MaxLifetime = 1 * time.Hour
+	sm.Config.IdleWarningThreshold = 5 * time.Minute
+
+	var warned []string
+	sm.Config.OnIdleWarning = func(s *Session, remaining time.Duration) {
+		if remaining > 5*time.Minute {
+			t.Errorf("Expected remaining below threshold, got %v", remaining)
+		}
+		warned = append(warned, s.ID())
+	}
+
+	sm.SessionCreate("sessionid123")
+	sm.SessionCreate("sessionid456")
+	sm.stored("sessionid123").age(58 * time.Minute)
+
+	// Case 1: Session Crossing Threshold Is Warned
+	sm.GlobalCleaner()
+	if len(warned) != 1 || warned[0] != "sessionid123" {
+		t.Errorf("Expected [sessionid123], got %v", warned)
+	}
+
+	// Case 2: Warning Fires Only Once
+	sm.GlobalCleaner()
+	if len(warned) != 1 {
+		t.Errorf("Expected 1 warning, got %v", warned)
+	}
+
+	// Case 3: Access Re-Arms the Warning
+	sm.SessionUpdate("sessionid123")
+	sm.stored("sessionid123").age(58 * time.Minute)
+	sm.GlobalCleaner()
+	if len(warned) != 2 {
+		t.Errorf("Expected 2 warnings, got %v", warned)
+	}
+
+	// Case 4: Expired Sessions Are Removed Without Warning
+	sm.stored("sessionid456").age(2 * time.Hour)
+	sm.GlobalCleaner()
+	if len(warned) != 2 || sm.SessionExist("sessionid456") {
+		t.Errorf("Expected sessionid456 removed without warning, got %v", warned)
+	}
+}
+
+func TestSession_UserID(t *testing.T) {
+	// Case 1: Unbound Session
+	s := &Session{sd: make(dict)}
+	if uid := s.UserID(); uid != "" {
+		t.Errorf("Expected empty user id, got %v", uid)
+	}
+
+	// Case 2: Bound Session
+	s.SetUserID("user1")
+	if uid := s.UserID(); uid != "user1" {
+		t.Errorf("Expected user1, got %v", uid)
+	}
+}
+
+func TestSessionManager_SizeHints(t *testing.T) {
+	setKeys := func(sm *SessionManager) func() {
+		return func() {
+			s := sm.newSession("sessionid123")
+			for i := 0; i < 64; i++ {
+				s.set(i, i)
+			}
+		}
+	}
+
+	// Case 1: Preallocated Maps Allocate Less
+	hinted := New(SessionManagerConfig{CleanerInterval: time.Minute, ExpectedKeysPerSession: 64})
+	plain := New()
+	if h, p := testing.AllocsPerRun(10, setKeys(hinted)), testing.AllocsPerRun(10, setKeys(plain)); h >= p {
+		t.Errorf("Expected fewer allocations with hint, got %v vs %v", h, p)
+	}
+
+	// Case 2: Negative Hints Ignored
+	sm := New(SessionManagerConfig{CleanerInterval: time.Minute, ExpectedSessions: -1, ExpectedKeysPerSession: -1})
+	if _, err := sm.SessionCreate("sessionid123"); err != nil || !sm.SessionExist("sessionid123") {
+		t.Errorf("Expected session to be created, error: %v", err)
+	}
+}
+
+func TestSessionManager_MaxSessionIDLength(t *testing.T) {
+	sm := New()
+	sm.Config.EnableHttpHeader = true
+	sm.Config.SessionHeader = "X-Session-Id"
+	sm.SessionCreate("sessionid123")
+
+	// Case 1: Oversized Cookie Rejected
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: strings.Repeat("a", DefaultMaxSessionIDLength+1)})
+	if _, err := sm.SessionRead(req); err != ErrSessionIDTooLong {
+		t.Errorf("Expected ErrSessionIDTooLong, got %v", err)
+	}
+	if _, err := sm.GetSessionIdFromCookie(req); err != ErrSessionIDTooLong {
+		t.Errorf("Expected ErrSessionIDTooLong, got %v", err)
+	}
+
+	// Case 2: Oversized Header Rejected
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header["X-Session-Id"] = []string{strings.Repeat("a", DefaultMaxSessionIDLength+1)}
+	if _, err := sm.GetSessionId(req); err != ErrSessionIDTooLong {
+		t.Errorf("Expected ErrSessionIDTooLong, got %v", err)
+	}
+
+	// Case 3: Configured Maximum
+	sm.Config.MaxSessionIDLength = 10
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+	if _, err := sm.SessionRead(req); err != ErrSessionIDTooLong {
+		t.Errorf("Expected ErrSessionIDTooLong, got %v", err)
+	}
+	sm.Config.MaxSessionIDLength = 12
+	if s, err := sm.SessionRead(req); err != nil || s.ID() != "sessionid123" {
+		t.Errorf("Expected sessionid123, got %v, error: %v", s, err)
+	}
+}
+
+func FuzzGetSessionId(f *testing.F) {
+	for _, seed := range []string{"sessionid123", "%", "%zz", "a%20b", "", "a;b", "\"quoted\"", "=", "\x00"} {
+		f.Add(seed)
+	}
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, EnableHttpHeader: true, SessionHeader: "X-Session-Id"})
+	defer sm.Close()
+
+	f.Fuzz(func(t *testing.T, value string) {
+		// Case 1: Raw Cookie Values Never Panic
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Cookie", sm.Cookie.Name+"="+value)
+		sm.GetSessionId(r)
+		sm.GetSessionIdFromCookie(r)
+
+		// Case 2: Ids Written By SetCookie Read Back Unchanged
+		if value == "" || len(url.QueryEscape(value)) > DefaultMaxSessionIDLength {
+			return
+		}
+		w := httptest.NewRecorder()
+		sm.SetCookie(w, &Session{sessionId: value, sd: make(dict)})
+		r = httptest.NewRequest("GET", "/", nil)
+		for _, c := range w.Result().Cookies() {
+			r.AddCookie(c)
+		}
+		if sid, err := sm.GetSessionIdFromCookie(r); err != nil || sid != value {
+			t.Errorf("Expected %q, got %q, %v", value, sid, err)
+		}
+
+		// Case 3: Header Values Taken As They Are
+		r = httptest.NewRequest("GET", "/", nil)
+		r.Header["X-Session-Id"] = []string{value}
+		if sid, err := sm.GetSessionIdFromHeader(r); err != nil || sid != value {
+			t.Errorf("Expected %q, got %q, %v", value, sid, err)
+		}
+	})
+}
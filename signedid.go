@@ -0,0 +1,52 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidSessionID = errors.New("session id signature is invalid")
+
+func sessionIDSignature(key []byte, sid string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(sid))
+	return mac.Sum(nil)
+}
+
+// Value carrying sid to the client, <sid>.<signature> under the first of
+// Config.SessionIDKeys, sid itself if none are set
+func (sm *SessionManager) signID(sid string) string {
+	if len(sm.Config.SessionIDKeys) == 0 {
+		return sid
+	}
+	return sid + "." + base64.RawURLEncoding.EncodeToString(sessionIDSignature(sm.Config.SessionIDKeys[0], sid))
+}
+
+// Session id carried by a value from the client, checked against every key
+// in Config.SessionIDKeys so ids signed before a rotation stay valid
+func (sm *SessionManager) verifyID(value string) (string, error) {
+	if len(sm.Config.SessionIDKeys) == 0 {
+		return value, nil
+	}
+
+	i := strings.LastIndexByte(value, '.')
+	if i <= 0 {
+		return "", ErrInvalidSessionID
+	}
+	sid := value[:i]
+	sig, err := base64.RawURLEncoding.DecodeString(value[i+1:])
+	if err != nil {
+		return "", ErrInvalidSessionID
+	}
+
+	for _, key := range sm.Config.SessionIDKeys {
+		if hmac.Equal(sig, sessionIDSignature(key, sid)) {
+			return sid, nil
+		}
+	}
+
+	return "", ErrInvalidSessionID
+}
@@ -0,0 +1,74 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionManager_SessionIDKeys(t *testing.T) {
+	sm := New()
+	sm.Config.EnableHttpHeader = true
+	sm.Config.SessionHeader = "X-Session-Id"
+	sm.Config.SessionIDKeys = [][]byte{[]byte("old")}
+	s, _ := sm.SessionCreate("sessionid123")
+
+	read := func(value string) (*Session, error) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: value})
+		return sm.SessionRead(req)
+	}
+
+	// Case 1: Cookie Carries the Signed Id
+	rec := httptest.NewRecorder()
+	sm.SetCookie(rec, s)
+	signed := rec.Result().Cookies()[0].Value
+	if signed == "sessionid123" {
+		t.Fatalf("Expected signed cookie value, got %v", signed)
+	}
+	if got, err := read(signed); err != nil || got != s {
+		t.Errorf("Expected session, got %v, error: %v", got, err)
+	}
+
+	// Case 2: Unsigned, Forged and Truncated Ids Rejected
+	for _, value := range []string{"sessionid123", "sessionid123.AAAA", signed[:len(signed)-2], "other" + signed[len("sessionid123"):]} {
+		if got, err := read(value); err != ErrInvalidSessionID || got != nil {
+			t.Errorf("Expected ErrInvalidSessionID for %q, got %v, %v", value, got, err)
+		}
+	}
+
+	// Case 3: Old Key Still Verifies After Rotation
+	sm.Config.SessionIDKeys = [][]byte{[]byte("new"), []byte("old")}
+	if got, err := read(signed); err != nil || got != s {
+		t.Errorf("Expected session under the old key, got %v, error: %v", got, err)
+	}
+	rec = httptest.NewRecorder()
+	sm.SetCookie(rec, s)
+	if rotated := rec.Result().Cookies()[0].Value; rotated == signed {
+		t.Errorf("Expected a signature under the new key")
+	}
+
+	// Case 4: Retired Key Rejected
+	sm.Config.SessionIDKeys = [][]byte{[]byte("new")}
+	if _, err := read(signed); err != ErrInvalidSessionID {
+		t.Errorf("Expected ErrInvalidSessionID, got %v", err)
+	}
+
+	// Case 5: Header Verified Too
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header["X-Session-Id"] = []string{sm.signID("sessionid123")}
+	if sid, err := sm.GetSessionIdFromHeader(req); err != nil || sid != "sessionid123" {
+		t.Errorf("Expected sessionid123, got %v, error: %v", sid, err)
+	}
+	req.Header["X-Session-Id"] = []string{"sessionid123"}
+	if _, err := sm.GetSessionId(req); err != ErrInvalidSessionID {
+		t.Errorf("Expected ErrInvalidSessionID, got %v", err)
+	}
+
+	// Case 6: SessionStart Issues Signed Ids
+	rec = httptest.NewRecorder()
+	started, _ := sm.SessionStart(rec, httptest.NewRequest("GET", "/", nil))
+	if sid, err := sm.verifyID(rec.Header().Get("X-Session-Id")); err != nil || sid != started.ID() {
+		t.Errorf("Expected signed header for %v, got %v, error: %v", started.ID(), sid, err)
+	}
+}
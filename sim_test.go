@@ -0,0 +1,268 @@
+package session
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Deterministic simulation of a manager: a workload drawn from a seeded
+// source runs create, read, write, refresh, update, destroy and cleaner
+// operations over a small pool of session ids, so they collide often. Time
+// is simulated: advancing the clock ages every stored session by the same
+// amount. A model of what the manager must hold is checked after every
+// step, and a failure reports the seed and the steps that led to it.
+
+const simLifetime = time.Hour
+
+var simSids = []string{"sessionid0", "sessionid1", "sessionid2", "sessionid3", "sessionid4"}
+
+// Expected state of a session
+type simSession struct {
+	idle   time.Duration
+	values map[string]int
+}
+
+type simulation struct {
+	t     *testing.T
+	rng   *rand.Rand
+	sm    *SessionManager
+	model map[string]*simSession
+	trace []string
+}
+
+func newSimulation(t *testing.T, seed int64, store Store) *simulation {
+	return &simulation{
+		t:     t,
+		rng:   rand.New(rand.NewSource(seed)),
+		sm:    New(SessionManagerConfig{MaxLifetime: simLifetime, Store: store}),
+		model: make(map[string]*simSession),
+	}
+}
+
+func (sim *simulation) sid() string {
+	return simSids[sim.rng.Intn(len(simSids))]
+}
+
+func (sim *simulation) fail(format string, args ...interface{}) {
+	sim.t.Helper()
+	sim.t.Fatalf("%s\nsteps:\n  %s", fmt.Sprintf(format, args...), strings.Join(sim.trace, "\n  "))
+}
+
+func (sim *simulation) step() {
+	sm := sim.sm
+	switch op := sim.rng.Intn(9); op {
+	case 0:
+		sid := sim.sid()
+		sim.trace = append(sim.trace, "create "+sid)
+		if _, err := sm.SessionCreate(sid); err != nil {
+			sim.fail("Expected no error creating %v, got %v", sid, err)
+		}
+		sim.model[sid] = &simSession{values: make(map[string]int)}
+
+	case 1:
+		sid, key, v := sim.sid(), fmt.Sprintf("key%d", sim.rng.Intn(3)), sim.rng.Intn(100)
+		sim.trace = append(sim.trace, fmt.Sprintf("set %v %v=%v", sid, key, v))
+		s, ok := sm.session(sid)
+		if !ok {
+			return
+		}
+		if err := s.Set(key, v); err != nil {
+			sim.fail("Expected no error setting %v, got %v", key, err)
+		}
+		sim.model[sid].values[key] = v
+
+	case 2:
+		sid, key := sim.sid(), fmt.Sprintf("key%d", sim.rng.Intn(3))
+		sim.trace = append(sim.trace, fmt.Sprintf("delete %v %v", sid, key))
+		if s, ok := sm.session(sid); ok {
+			s.Delete(key)
+			delete(sim.model[sid].values, key)
+		}
+
+	case 3:
+		sid := sim.sid()
+		sim.trace = append(sim.trace, "update "+sid)
+		err := sm.SessionUpdate(sid)
+		if m := sim.model[sid]; m != nil {
+			if err != nil {
+				sim.fail("Expected no error updating %v, got %v", sid, err)
+			}
+			m.idle = 0
+		} else if err == nil {
+			sim.fail("Expected error updating missing %v", sid)
+		}
+
+	case 4:
+		oldSid, sid := sim.sid(), sim.sid()
+		sim.trace = append(sim.trace, fmt.Sprintf("refresh %v %v", oldSid, sid))
+		s, err := sm.SessionRefresh(oldSid, sid)
+		if err != nil || s.ID() != sid {
+			sim.fail("Expected %v refreshed to %v, got %v", oldSid, sid, err)
+		}
+		// A missing session is created anew
+		m := sim.model[oldSid]
+		if m == nil {
+			m = &simSession{values: make(map[string]int)}
+		}
+		delete(sim.model, oldSid)
+		sim.model[sid] = m
+
+	case 5:
+		sid := sim.sid()
+		sim.trace = append(sim.trace, "destroy "+sid)
+		err := sm.SessionDestroy(sid)
+		if (err == nil) != (sim.model[sid] != nil) {
+			sim.fail("Expected destroying %v to succeed only if it exists, got %v", sid, err)
+		}
+		delete(sim.model, sid)
+
+	case 6, 7:
+		d := time.Duration(1+sim.rng.Intn(40)) * time.Minute
+		sim.trace = append(sim.trace, fmt.Sprintf("advance %v", d))
+		for sid, m := range sim.model {
+			m.idle += d
+			if s, ok := sm.session(sid); ok {
+				s.age(m.idle)
+				sm.store.Set(sid, s)
+			}
+			// Stores other than MemoryStore drop sessions written expired
+			if !sm.inMemory() && m.idle >= simLifetime {
+				delete(sim.model, sid)
+			}
+		}
+
+	case 8:
+		sim.trace = append(sim.trace, "clean")
+		sm.GlobalCleaner()
+		for sid, m := range sim.model {
+			if m.idle >= simLifetime {
+				delete(sim.model, sid)
+			}
+		}
+	}
+
+	sim.check()
+}
+
+// The manager holds exactly the sessions and values of the model
+func (sim *simulation) check() {
+	sim.t.Helper()
+	sm := sim.sm
+
+	if n := sm.SessionCount(); n != len(sim.model) {
+		sim.fail("Expected %v sessions, got %v", len(sim.model), n)
+	}
+	for _, sid := range simSids {
+		s, ok := sm.session(sid)
+		m := sim.model[sid]
+		if ok != (m != nil) {
+			sim.fail("Expected %v to exist: %v, got %v", sid, m != nil, ok)
+		}
+		if !ok {
+			continue
+		}
+		if s.ID() != sid {
+			sim.fail("Expected session stored as %v to have that id, got %v", sid, s.ID())
+		}
+		for key, v := range m.values {
+			if got := s.Get(key); got != v {
+				sim.fail("Expected %v %v=%v, got %v", sid, key, v, got)
+			}
+		}
+		if n := len(s.KeysWithPrefix("key")); n != len(m.values) {
+			sim.fail("Expected %v keys in %v, got %v", len(m.values), sid, n)
+		}
+	}
+}
+
+func TestSimulation(t *testing.T) {
+	seeds, steps := int64(20), 200
+	if testing.Short() {
+		seeds = 5
+	}
+
+	stores := map[string]func() Store{
+		"memory":  func() Store { return nil },
+		"backend": func() Store { return NewBackendStore(newMapBackend(), nil) },
+		"tiered":  func() Store { return NewTieredStore(NewBackendStore(newMapBackend(), nil), 2, 0) },
+	}
+	names := make([]string, 0, len(stores))
+	for name := range stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Case 1: Sequential Workloads Match The Model
+	for _, name := range names {
+		for seed := int64(1); seed <= seeds; seed++ {
+			t.Run(fmt.Sprintf("%s/seed=%d", name, seed), func(t *testing.T) {
+				sim := newSimulation(t, seed, stores[name]())
+				defer sim.sm.Close()
+				for i := 0; i < steps; i++ {
+					sim.step()
+				}
+			})
+		}
+	}
+}
+
+func TestSimulation_Concurrent(t *testing.T) {
+	sm := New(SessionManagerConfig{MaxLifetime: simLifetime})
+	defer sm.Close()
+
+	// Case 1: Scripted Workers Racing The Cleaner Keep The Table Consistent
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			for i := 0; i < 500; i++ {
+				sid := simSids[rng.Intn(len(simSids))]
+				switch rng.Intn(6) {
+				case 0:
+					sm.SessionCreate(sid)
+				case 1:
+					if s, ok := sm.session(sid); ok {
+						s.Set("key", i)
+					}
+				case 2:
+					sm.SessionUpdate(sid)
+				case 3:
+					sm.SessionRefresh(sid, simSids[rng.Intn(len(simSids))])
+				case 4:
+					sm.SessionDestroy(sid)
+				case 5:
+					if s, ok := sm.session(sid); ok {
+						s.age(2 * simLifetime)
+					}
+					sm.GlobalCleaner()
+				}
+			}
+		}(int64(w))
+	}
+	wg.Wait()
+
+	n := 0
+	sm.each(func(sid string, s *Session) {
+		n++
+		if s.ID() != sid {
+			t.Errorf("Expected session stored as %v to have that id, got %v", sid, s.ID())
+		}
+	})
+	if n != sm.SessionCount() {
+		t.Errorf("Expected count %v to match the table, got %v", n, sm.SessionCount())
+	}
+
+	// Case 2: Cleaner Removes Everything Expired
+	sm.each(func(_ string, s *Session) { s.age(2 * simLifetime) })
+	sm.GlobalCleaner()
+	if n := sm.SessionCount(); n != 0 {
+		t.Errorf("Expected no sessions after the cleaner, got %v", n)
+	}
+}
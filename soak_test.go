@@ -0,0 +1,131 @@
+package session
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Soak mode: go test -run TestSoak -soak 10m hammers managers for that long.
+// Without the flag the test runs for a moment so the assertions themselves
+// are exercised.
+var soakDuration = flag.Duration("soak", 0, "run TestSoak for this long")
+
+// Growth tolerated over the baseline once every manager is closed
+const (
+	soakGoroutineSlack = 2
+	soakHeapSlack      = 32 << 20
+)
+
+// Live heap after a full collection
+func soakHeap() uint64 {
+	runtime.GC()
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc
+}
+
+// One worker: creates, reads, writes, refreshes and destroys sessions on sm
+// until stop is closed
+func soakWorker(sm *SessionManager, seed int64, stop <-chan struct{}) {
+	rng := rand.New(rand.NewSource(seed))
+	for i := 0; ; i++ {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		sid := fmt.Sprintf("sessionid%d", rng.Intn(1000))
+		switch rng.Intn(6) {
+		case 0:
+			sm.SessionCreate(sid)
+		case 1:
+			req := httptest.NewRequest("GET", "/", nil)
+			req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: sid})
+			if s, err := sm.SessionRead(req); err == nil {
+				s.Set("key", i)
+			}
+		case 2:
+			sm.SessionUpdate(sid)
+		case 3:
+			sm.SessionRefresh(sid, fmt.Sprintf("sessionid%d", rng.Intn(1000)))
+		case 4:
+			sm.SessionDestroy(sid)
+		case 5:
+			if s, ok := sm.session(sid); ok {
+				s.age(2 * time.Minute)
+			}
+		}
+	}
+}
+
+func TestSoak(t *testing.T) {
+	duration := *soakDuration
+	if duration == 0 {
+		if testing.Short() {
+			t.Skip("soak test skipped in short mode")
+		}
+		duration = 500 * time.Millisecond
+	}
+
+	goroutines, heap := runtime.NumGoroutine(), soakHeap()
+
+	// Case 1: Managers Created, Hammered and Closed Over and Over
+	deadline := time.Now().Add(duration)
+	for round := int64(0); time.Now().Before(deadline); round++ {
+		configs := []SessionManagerConfig{
+			{CleanerInterval: time.Millisecond, MaxLifetime: time.Minute},
+			{CleanerInterval: time.Millisecond, MaxLifetime: time.Minute, Store: NewBackendStore(newMapBackend(), nil), WriteBehind: time.Millisecond},
+			{CleanerInterval: time.Millisecond, MaxLifetime: time.Minute, Store: NewTieredStore(NewBackendStore(newMapBackend(), nil), 64, 0)},
+		}
+
+		var managers []*SessionManager
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		for i, config := range configs {
+			sm := New(config)
+			managers = append(managers, sm)
+			for w := 0; w < 4; w++ {
+				wg.Add(1)
+				go func(sm *SessionManager, seed int64) {
+					defer wg.Done()
+					soakWorker(sm, seed, stop)
+				}(sm, round*100+int64(i*10+w))
+			}
+		}
+		managers[0].SetCleanerInterval(2 * time.Millisecond)
+
+		time.Sleep(50 * time.Millisecond)
+		close(stop)
+		wg.Wait()
+		for _, sm := range managers {
+			if err := sm.Close(); err != nil {
+				t.Fatalf("Expected no error closing, got %v", err)
+			}
+		}
+	}
+
+	// Case 2: Goroutines Back to the Baseline. Every ticker of the package
+	// belongs to a goroutine, so a leaked ticker shows here too.
+	settle := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > goroutines+soakGoroutineSlack && time.Now().Before(settle) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > goroutines+soakGoroutineSlack {
+		buf := make([]byte, 1<<20)
+		t.Errorf("Expected at most %v goroutines, got %v:\n%s", goroutines+soakGoroutineSlack, n, buf[:runtime.Stack(buf, true)])
+	}
+
+	// Case 3: Memory Released
+	if after := soakHeap(); after > heap+soakHeapSlack {
+		t.Errorf("Expected heap of at most %v bytes, got %v", heap+soakHeapSlack, after)
+	}
+}
@@ -0,0 +1,167 @@
+// Package sqlitestore keeps sessions in a SQLite file through database/sql,
+// giving single-node deployments durable sessions without an external
+// service. Register the SQLite driver of your choice and pass the *sql.DB
+// to New.
+//
+// The table holds one row per session, times as Unix nanoseconds so they
+// compare the same with every driver:
+//
+//	sid           TEXT PRIMARY KEY
+//	data          BLOB NOT NULL         session encoded by the codec
+//	last_accessed INTEGER NOT NULL
+//	expires_at    INTEGER NOT NULL
+//
+// Expired rows read as missing and are deleted on every cleaner run. The
+// database is switched to WAL mode so reads go on while a session is written.
+package sqlitestore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+)
+
+// Table used when Options.Table is empty
+const DefaultTable = "sessions"
+
+type Options struct {
+	// Table name, DefaultTable if empty
+	Table string
+
+	// Skip creating the table and its index if they do not exist
+	NoCreateTable bool
+
+	// Keep the journal mode of the database instead of switching it to WAL
+	NoWAL bool
+
+	// Serialization of sessions, session.GobCodec if nil
+	Codec session.Codec
+}
+
+// Backend implements session.Backend on top of a *sql.DB
+type Backend struct {
+	db *sql.DB
+
+	load, save, remove, scan, count, sweep string
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Store keeping sessions in db, creating the table unless
+// Options.NoCreateTable is set. Pass it as Config.Store.
+func New(db *sql.DB, opts Options) (*session.BackendStore, error) {
+	b, err := NewBackend(db, opts)
+	if err != nil {
+		return nil, err
+	}
+	return session.NewBackendStore(b, opts.Codec), nil
+}
+
+func NewBackend(db *sql.DB, opts Options) (*Backend, error) {
+	table := opts.Table
+	if table == "" {
+		table = DefaultTable
+	}
+	if !identifier.MatchString(table) {
+		return nil, errors.New("invalid table name")
+	}
+
+	b := &Backend{
+		db:     db,
+		load:   fmt.Sprintf("SELECT data FROM %s WHERE sid = ? AND expires_at > ?", table),
+		save:   fmt.Sprintf("INSERT INTO %s (sid, data, last_accessed, expires_at) VALUES (?, ?, ?, ?) ON CONFLICT (sid) DO UPDATE SET data = excluded.data, last_accessed = excluded.last_accessed, expires_at = excluded.expires_at", table),
+		remove: fmt.Sprintf("DELETE FROM %s WHERE sid = ?", table),
+		scan:   fmt.Sprintf("SELECT sid, data FROM %s WHERE expires_at > ?", table),
+		count:  fmt.Sprintf("SELECT count(*) FROM %s WHERE expires_at > ?", table),
+		sweep:  fmt.Sprintf("DELETE FROM %s WHERE expires_at <= ?", table),
+	}
+
+	if !opts.NoWAL {
+		if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
+			return nil, err
+		}
+	}
+	if !opts.NoCreateTable {
+		if err := b.createTable(table); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+func (b *Backend) createTable(table string) error {
+	_, err := b.db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	sid TEXT PRIMARY KEY,
+	data BLOB NOT NULL,
+	last_accessed INTEGER NOT NULL,
+	expires_at INTEGER NOT NULL
+)`, table))
+	if err != nil {
+		return err
+	}
+
+	_, err = b.db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_expires_at_idx ON %s (expires_at)", table, table))
+	return err
+}
+
+func (b *Backend) Load(sid string) ([]byte, error) {
+	var data []byte
+	err := b.db.QueryRow(b.load, sid, time.Now().UnixNano()).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (b *Backend) Save(r session.Record) error {
+	_, err := b.db.Exec(b.save, r.ID, r.Data, r.LastAccessed.UnixNano(), r.ExpiresAt.UnixNano())
+	return err
+}
+
+func (b *Backend) Remove(sid string) error {
+	_, err := b.db.Exec(b.remove, sid)
+	return err
+}
+
+func (b *Backend) Scan(fn func(sid string, data []byte) bool) error {
+	rows, err := b.db.Query(b.scan, time.Now().UnixNano())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sid string
+		var data []byte
+		if err := rows.Scan(&sid, &data); err != nil {
+			return err
+		}
+		if !fn(sid, data) {
+			break
+		}
+	}
+
+	return rows.Err()
+}
+
+func (b *Backend) Len() (int, error) {
+	var n int
+	err := b.db.QueryRow(b.count, time.Now().UnixNano()).Scan(&n)
+	return n, err
+}
+
+// Expired rows read as missing until Sweep deletes them
+func (b *Backend) SelfExpiring() bool {
+	return true
+}
+
+// Delete expired rows, run on every cleaner run
+func (b *Backend) Sweep() error {
+	_, err := b.db.Exec(b.sweep, time.Now().UnixNano())
+	return err
+}
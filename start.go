@@ -0,0 +1,82 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+)
+
+// SessionStart returns the session of the request, or creates one under an
+// id from Config.IDGenerator if it has none. A new session is recorded with
+// the client of r like SessionCreateFromRequest, and its id written to w in
+// the session cookie, and in Config.SessionHeader if EnableHttpHeader is
+// set, which must happen before the response header is written.
+func (sm *SessionManager) SessionStart(w http.ResponseWriter, r *http.Request) (*Session, error) {
+	s, _, err := sm.start(w, r)
+	return s, err
+}
+
+// SessionStart, also reporting whether the session was created
+func (sm *SessionManager) start(w http.ResponseWriter, r *http.Request) (*Session, bool, error) {
+	s, err := sm.SessionRead(r)
+	if errors.Is(err, ErrImpossibleTravel) || errors.Is(err, ErrSessionRisk) {
+		return nil, false, err
+	}
+	if s != nil {
+		return s, false, nil
+	}
+
+	if s, err = sm.createFromRequest("", nil, r); err != nil {
+		return nil, false, err
+	}
+
+	sm.writeID(w, s)
+	return s, true, nil
+}
+
+// SessionRegenerate moves the session of the request to a new id from
+// Config.IDGenerator, deleting the old one, and writes the new id to w like
+// SessionStart. Call it whenever the privileges of a session change, e.g.
+// on login, so an id planted before (session fixation) becomes useless.
+// Requests without a session get a new one.
+func (sm *SessionManager) SessionRegenerate(w http.ResponseWriter, r *http.Request) (*Session, error) {
+	s := FromContext(r.Context())
+	if s == nil {
+		var created bool
+		var err error
+		if s, created, err = sm.start(w, r); err != nil || created {
+			return s, err
+		}
+	}
+
+	oldSid := s.ID()
+	for i := 0; i < maxIDAttempts; i++ {
+		sid, err := sm.idGenerator().NewID()
+		if err != nil {
+			return nil, err
+		}
+		if sid == "" {
+			return nil, errors.New("generated session id is empty")
+		}
+
+		moved, err := sm.refresh(oldSid, sid, true)
+		if err == errIDInUse {
+			continue
+		}
+		if moved != nil {
+			sm.writeID(w, moved)
+		}
+		return moved, err
+	}
+
+	return nil, ErrIDCollision
+}
+
+// Write the id of s to the session cookie, and to Config.SessionHeader if
+// EnableHttpHeader is set
+func (sm *SessionManager) writeID(w http.ResponseWriter, s *Session) {
+	sm.writeCacheHeaders(w)
+	sm.SetCookie(w, s)
+	if sm.Config.EnableHttpHeader && sm.Config.SessionHeader != "" {
+		w.Header().Set(sm.Config.SessionHeader, sm.signID(s.ID()))
+	}
+}
@@ -0,0 +1,117 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionManager_SessionStart(t *testing.T) {
+	sm := New()
+	sm.Config.EnableHttpHeader = true
+	sm.Config.SessionHeader = "X-Session-Id"
+
+	// Case 1: New Session Issued
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	rec := httptest.NewRecorder()
+	s, err := sm.SessionStart(rec, req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(s.ID()) < 22 || !sm.SessionExist(s.ID()) {
+		t.Errorf("Expected stored session with a 128 bit id, got %v", s.ID())
+	}
+	if s.Metadata().IP != "192.0.2.1" {
+		t.Errorf("Expected metadata from the request, got %v", s.Metadata())
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != sm.Cookie.Name || cookies[0].Value != s.ID() {
+		t.Errorf("Expected session cookie, got %v", cookies)
+	}
+	if rec.Header().Get("X-Session-Id") != s.ID() {
+		t.Errorf("Expected session header %v, got %v", s.ID(), rec.Header().Get("X-Session-Id"))
+	}
+
+	// Case 2: Existing Session Returned
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	again, err := sm.SessionStart(rec, req)
+	if err != nil || again != s {
+		t.Errorf("Expected existing session, got %v, error: %v", again, err)
+	}
+	if rec.Header().Get("Set-Cookie") != "" {
+		t.Errorf("Expected no cookie for an existing session")
+	}
+
+	// Case 3: Ids Are Unique
+	other, _ := sm.SessionStart(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if other.ID() == s.ID() || sm.SessionCount() != 2 {
+		t.Errorf("Expected a second session, got %v", other.ID())
+	}
+
+	// Case 4: Frozen Manager
+	sm.freezes.Add(1)
+	if _, err := sm.SessionStart(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)); err != ErrFrozen {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+}
+
+func TestSessionManager_SessionRegenerate(t *testing.T) {
+	sm := New()
+	sm.Config.EnableHttpHeader = true
+	sm.Config.SessionHeader = "X-Session-Id"
+	ids := []string{"taken", "fresh1"}
+	sm.Config.IDGenerator = IDGeneratorFunc(func() (string, error) {
+		sid := ids[0]
+		ids = ids[1:]
+		return sid, nil
+	})
+	sm.SessionCreate("taken")
+	s, _ := sm.SessionCreate("planted")
+	s.Set("cart", 1)
+
+	// Case 1: Data Moved to a New Id
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "planted"})
+	rec := httptest.NewRecorder()
+	moved, err := sm.SessionRegenerate(rec, req)
+	if err != nil || moved.ID() != "fresh1" || moved.Get("cart") != 1 {
+		t.Fatalf("Expected fresh1 with the cart, got %v, error: %v", moved, err)
+	}
+	if sm.SessionExist("planted") || !sm.SessionExist("taken") {
+		t.Errorf("Expected planted gone and taken kept")
+	}
+
+	// Case 2: New Id Written to the Response
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value != "fresh1" || rec.Header().Get("X-Session-Id") != "fresh1" {
+		t.Errorf("Expected cookie and header with fresh1, got %v", rec.Header())
+	}
+
+	// Case 3: Session From the Middleware Context
+	ids = []string{"fresh2"}
+	req = httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), sessionContextKey, moved))
+	if again, err := sm.SessionRegenerate(httptest.NewRecorder(), req); err != nil || again.ID() != "fresh2" {
+		t.Errorf("Expected fresh2, got %v, error: %v", again, err)
+	}
+
+	// Case 4: Request Without a Session Gets a New One
+	ids = []string{"fresh3"}
+	rec = httptest.NewRecorder()
+	started, err := sm.SessionRegenerate(rec, httptest.NewRequest("GET", "/", nil))
+	if err != nil || started.ID() != "fresh3" || len(rec.Result().Cookies()) != 1 {
+		t.Errorf("Expected new session fresh3 with its cookie, got %v, error: %v", started, err)
+	}
+
+	// Case 5: Persistent Collisions
+	sm.Config.IDGenerator = IDGeneratorFunc(func() (string, error) { return "taken", nil })
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "fresh3"})
+	if _, err := sm.SessionRegenerate(httptest.NewRecorder(), req); err != ErrIDCollision || !sm.SessionExist("fresh3") {
+		t.Errorf("Expected ErrIDCollision with the session kept, got %v", err)
+	}
+}
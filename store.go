@@ -0,0 +1,183 @@
+package session
+
+import (
+	"sort"
+	"sync"
+)
+
+// Store holds the session table. A MemoryStore is guarded by the manager's
+// own lock like the rest of the table. Any other store is expected to do
+// I/O, so it is called outside that lock and must be safe for concurrent
+// use; the manager only serializes the calls for the same session, so a
+// Get and the Set following it are not interleaved with another write to
+// that session. Stores that keep a copy of the session rather than the
+// *Session itself are handed the session again with Set after every write
+// through it.
+type Store interface {
+	// Session sid, nil if there is none
+	Get(sid string) (*Session, error)
+	Set(sid string, s *Session) error
+	Delete(sid string) error
+
+	// Call fn for every session until it returns false
+	Iterate(fn func(sid string, s *Session) bool) error
+
+	Count() int
+}
+
+// MemoryStore is the default Store, a map of the live sessions
+type MemoryStore struct {
+	sessions sessDict
+}
+
+// Memory store with room for size sessions before it has to grow
+func NewMemoryStore(size int) *MemoryStore {
+	return &MemoryStore{sessions: make(sessDict, sizeHint(size))}
+}
+
+func (ms *MemoryStore) Get(sid string) (*Session, error) {
+	return ms.sessions[sid], nil
+}
+
+func (ms *MemoryStore) Set(sid string, s *Session) error {
+	ms.sessions[sid] = s
+	return nil
+}
+
+func (ms *MemoryStore) Delete(sid string) error {
+	delete(ms.sessions, sid)
+	return nil
+}
+
+func (ms *MemoryStore) Iterate(fn func(sid string, s *Session) bool) error {
+	for sid, s := range ms.sessions {
+		if !fn(sid, s) {
+			break
+		}
+	}
+	return nil
+}
+
+func (ms *MemoryStore) Count() int {
+	return len(ms.sessions)
+}
+
+// Session sid, nil if there is none or the store failed. Caller must hold
+// sm.lock or the lock of sid.
+func (sm *SessionManager) lookup(sid string) *Session {
+	s, err := sm.store.Get(sid)
+	if err != nil {
+		return nil
+	}
+	return s
+}
+
+// Call fn for every session, skipping what the store can't produce. Caller
+// must hold sm.lock.
+func (sm *SessionManager) each(fn func(sid string, s *Session)) {
+	sm.store.Iterate(func(sid string, s *Session) bool {
+		if s != nil {
+			fn(sid, s)
+		}
+		return true
+	})
+}
+
+// Stripes of the per-session locks taken around writes to stores other
+// than MemoryStore
+const storeStripes = 64
+
+type storeLocks [storeStripes]sync.Mutex
+
+// Whether the store is the in-memory table guarded by sm.lock
+func (sm *SessionManager) inMemory() bool {
+	_, ok := sm.store.(*MemoryStore)
+	return ok
+}
+
+func (sm *SessionManager) stripe(sid string) int {
+	return int(sm.hash(sid) % storeStripes)
+}
+
+// Lock the store for writes to sids, returning the unlock. The memory store
+// takes sm.lock. Other stores take the stripe of each sid instead, in order,
+// so a slow backend only holds up requests for the sessions sharing it.
+// Decoys and tombstones are guarded by sm.markLock either way.
+func (sm *SessionManager) lockSid(sids ...string) func() {
+	if sm.inMemory() {
+		sm.wlock()
+		return sm.lock.Unlock
+	}
+
+	stripes := make([]int, 0, len(sids))
+	for _, sid := range sids {
+		stripes = append(stripes, sm.stripe(sid))
+	}
+	sort.Ints(stripes)
+
+	var locked []*sync.Mutex
+	for i, n := range stripes {
+		if i > 0 && n == stripes[i-1] {
+			continue
+		}
+		m := &sm.storeLocks[n]
+		m.Lock()
+		locked = append(locked, m)
+	}
+
+	return func() {
+		for i := len(locked) - 1; i >= 0; i-- {
+			locked[i].Unlock()
+		}
+	}
+}
+
+// Hand s to the store again after a write through it, unless the store
+// holds the session itself
+func (sm *SessionManager) save(s *Session) error {
+	if sm.inMemory() {
+		return nil
+	}
+
+	sid := s.ID()
+	unlock := sm.lockSid(sid)
+	defer unlock()
+
+	if current := sm.lookup(sid); current == nil {
+		// Destroyed or expired meanwhile
+		return nil
+	}
+	return sm.store.Set(sid, s)
+}
+
+// Persist and replicate s after a write through it, if it belongs to a
+// manager. If the store rejects the session the write is undone with undo so
+// one bad value does not keep every later write from being stored.
+func (s *Session) commit(undo func()) error {
+	if s.manager == nil {
+		return nil
+	}
+	if err := s.manager.save(s); err != nil {
+		if undo != nil {
+			undo()
+		}
+		return err
+	}
+	return s.manager.replicate(s)
+}
+
+// Undo of writes to keys, restoring the values they had before
+func (s *Session) undoer(before map[interface{}]txValue) func() {
+	return func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		for key, v := range before {
+			if v.deleted {
+				delete(s.sd, key)
+			} else {
+				s.sd[key] = v.value
+			}
+		}
+	}
+}
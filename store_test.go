@@ -0,0 +1,175 @@
+package session
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Store wrapping a MemoryStore, counting writes and failing on demand
+type countingStore struct {
+	*MemoryStore
+	sets int
+	fail bool
+}
+
+func (cs *countingStore) Set(sid string, s *Session) error {
+	if cs.fail {
+		return errors.New("store unavailable")
+	}
+	cs.sets++
+	return cs.MemoryStore.Set(sid, s)
+}
+
+// BackendStore whose first write to sid blocks until release is closed
+type blockingStore struct {
+	*BackendStore
+	sid     string
+	held    bool
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (bs *blockingStore) Set(sid string, s *Session) error {
+	if sid == bs.sid && !bs.held {
+		bs.held = true
+		close(bs.blocked)
+		<-bs.release
+	}
+	return bs.BackendStore.Set(sid, s)
+}
+
+func TestMemoryStore(t *testing.T) {
+	ms := NewMemoryStore(0)
+	s := &Session{sessionId: "sessionid123", sd: make(dict)}
+
+	// Case 1: Set And Get
+	ms.Set("sessionid123", s)
+	if got, err := ms.Get("sessionid123"); err != nil || got != s {
+		t.Errorf("Expected stored session, got %v, %v", got, err)
+	}
+	if got, err := ms.Get("sessionid456"); err != nil || got != nil {
+		t.Errorf("Expected nil session, got %v, %v", got, err)
+	}
+
+	// Case 2: Iterate Stops Early
+	ms.Set("sessionid456", &Session{sessionId: "sessionid456", sd: make(dict)})
+	calls := 0
+	ms.Iterate(func(string, *Session) bool {
+		calls++
+		return false
+	})
+	if calls != 1 {
+		t.Errorf("Expected 1 call, got %v", calls)
+	}
+
+	// Case 3: Delete
+	ms.Delete("sessionid123")
+	if ms.Count() != 1 {
+		t.Errorf("Expected 1 session, got %v", ms.Count())
+	}
+}
+
+func TestSessionManager_Store(t *testing.T) {
+	cs := &countingStore{MemoryStore: NewMemoryStore(0)}
+	sm := New(SessionManagerConfig{Store: cs})
+
+	// Case 1: Sessions Kept In Configured Store
+	s, err := sm.SessionCreate("sessionid123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cs.Count() != 1 || sm.SessionCount() != 1 {
+		t.Errorf("Expected 1 session in the store, got %v", cs.Count())
+	}
+
+	// Case 2: Writes Handed Back To The Store
+	before := cs.sets
+	s.Set("key1", "value1")
+	s.SetUserID("user1")
+	if cs.sets != before+2 {
+		t.Errorf("Expected 2 more sets, got %v", cs.sets-before)
+	}
+
+	// Case 3: Store Errors Surfaced
+	cs.fail = true
+	if _, err := sm.SessionCreate("sessionid456"); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	if sm.SessionExist("sessionid456") {
+		t.Errorf("Expected sessionid456 not to exist")
+	}
+	if err := s.Set("key2", "value2"); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	cs.fail = false
+
+	// Case 4: Destroy Removes From Store
+	sm.SessionDestroy("sessionid123")
+	if cs.Count() != 0 {
+		t.Errorf("Expected empty store, got %v", cs.Count())
+	}
+}
+
+func TestSessionManager_StoreLocking(t *testing.T) {
+	bs := &blockingStore{
+		BackendStore: NewBackendStore(newMapBackend(), nil),
+		sid:          "sessionid123",
+		blocked:      make(chan struct{}),
+		release:      make(chan struct{}),
+	}
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: bs})
+	if sm.stripe("sessionid123") == sm.stripe("sessionid456") {
+		t.Fatalf("Expected the test sessions on different stripes")
+	}
+
+	created := make(chan error)
+	go func() {
+		_, err := sm.SessionCreate("sessionid123")
+		created <- err
+	}()
+	<-bs.blocked
+
+	// Case 1: Slow Write Does Not Block Other Sessions
+	served := make(chan bool)
+	go func() {
+		sm.SessionCreate("sessionid456")
+		served <- sm.SessionExist("sessionid456") && sm.SessionDestroy("sessionid456") == nil
+	}()
+	select {
+	case ok := <-served:
+		if !ok {
+			t.Errorf("Expected sessionid456 to be created and destroyed")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected other sessions to be served during a slow write")
+	}
+
+	// Case 2: Writes To The Same Session Wait
+	destroyed := make(chan error)
+	go func() {
+		destroyed <- sm.SessionDestroy("sessionid123")
+	}()
+	select {
+	case <-destroyed:
+		t.Errorf("Expected the destroy to wait for the pending write")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(bs.release)
+	if err := <-created; err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := <-destroyed; err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if sm.SessionExist("sessionid123") {
+		t.Errorf("Expected sessionid123 not to exist")
+	}
+
+	// Case 3: Stripes Follow the Configured Hash
+	sm.Config.Hash = func(sid string) uint64 { return 7 }
+	if sm.stripe("sessionid123") != 7%storeStripes || sm.stripe("sessionid456") != 7%storeStripes {
+		t.Errorf("Expected the stripes from Config.Hash")
+	}
+}
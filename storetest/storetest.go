@@ -0,0 +1,161 @@
+// Package storetest checks that a session.Store behaves like the default
+// MemoryStore, for the tests of store implementations:
+//
+//	func TestEquivalence(t *testing.T) {
+//		for seed := int64(1); seed <= 10; seed++ {
+//			storetest.Equivalent(t, redistore.New(newFakeRedis(), redistore.Options{}), seed, 200)
+//		}
+//	}
+package storetest
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	session "github.com/vpatel95/session-manager/v2"
+)
+
+var sids = []string{"sessionid0", "sessionid1", "sessionid2", "sessionid3", "sessionid4"}
+
+// Runs steps random operations drawn from seed on a manager using store and
+// on one using a MemoryStore, and fails t at the first operation whose
+// outcome differs between them: whether it failed, which sessions exist,
+// their values and user ids, or the session count. The sessions share a
+// small pool of ids so operations collide often, and values are strings so
+// any codec round-trips them. store must be empty and is not used after.
+func Equivalent(t testing.TB, store session.Store, seed int64, steps int) {
+	t.Helper()
+
+	newManager := func(store session.Store) *session.SessionManager {
+		return session.New(session.SessionManagerConfig{
+			CleanerInterval: time.Minute,
+			MaxLifetime:     time.Hour,
+			Store:           store,
+		})
+	}
+	want, got := newManager(nil), newManager(store)
+	defer want.Close()
+	defer got.Close()
+
+	rng := rand.New(rand.NewSource(seed))
+	var trace []string
+	fail := func(format string, args ...interface{}) {
+		t.Helper()
+		t.Fatalf("seed %d: %s\nsteps:\n  %s", seed, fmt.Sprintf(format, args...), strings.Join(trace, "\n  "))
+	}
+
+	for i := 0; i < steps; i++ {
+		sid, other := sids[rng.Intn(len(sids))], sids[rng.Intn(len(sids))]
+		key, value := fmt.Sprintf("key%d", rng.Intn(3)), fmt.Sprintf("value%d", rng.Intn(100))
+
+		var op string
+		var apply func(sm *session.SessionManager) error
+		switch rng.Intn(7) {
+		case 0:
+			op = "create " + sid
+			apply = func(sm *session.SessionManager) error {
+				_, err := sm.SessionCreate(sid)
+				return err
+			}
+		case 1:
+			op = fmt.Sprintf("set %v %v=%v", sid, key, value)
+			apply = func(sm *session.SessionManager) error {
+				s, err := read(sm, sid)
+				if err != nil {
+					return err
+				}
+				return s.Set(key, value)
+			}
+		case 2:
+			op = fmt.Sprintf("delete %v %v", sid, key)
+			apply = func(sm *session.SessionManager) error {
+				s, err := read(sm, sid)
+				if err != nil {
+					return err
+				}
+				return s.Delete(key)
+			}
+		case 3:
+			op = fmt.Sprintf("bind %v %v", sid, value)
+			apply = func(sm *session.SessionManager) error {
+				s, err := read(sm, sid)
+				if err != nil {
+					return err
+				}
+				s.SetUserID(value)
+				return nil
+			}
+		case 4:
+			op = "update " + sid
+			apply = func(sm *session.SessionManager) error { return sm.SessionUpdate(sid) }
+		case 5:
+			op = fmt.Sprintf("refresh %v %v", sid, other)
+			apply = func(sm *session.SessionManager) error {
+				_, err := sm.SessionRefresh(sid, other)
+				return err
+			}
+		case 6:
+			op = "destroy " + sid
+			apply = func(sm *session.SessionManager) error { return sm.SessionDestroy(sid) }
+		}
+		trace = append(trace, op)
+
+		if wantErr, gotErr := apply(want), apply(got); (wantErr == nil) != (gotErr == nil) {
+			fail("Expected error %v, got %v", wantErr, gotErr)
+		}
+		if diff := compare(want, got); diff != "" {
+			fail("%s", diff)
+		}
+	}
+}
+
+// Session sid as a request presenting its cookie reads it
+func read(sm *session.SessionManager, sid string) (*session.Session, error) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: sid})
+	return sm.SessionRead(req)
+}
+
+// Observable difference between the sessions of want and got, empty if
+// there is none
+func compare(want, got *session.SessionManager) string {
+	if w, g := want.SessionCount(), got.SessionCount(); w != g {
+		return fmt.Sprintf("Expected %v sessions, got %v", w, g)
+	}
+
+	for _, sid := range sids {
+		w, wok := want.Snapshot(sid)
+		g, gok := got.Snapshot(sid)
+		if wok != gok {
+			return fmt.Sprintf("Expected %v to exist: %v, got %v", sid, wok, gok)
+		}
+		if !wok {
+			continue
+		}
+		if w.ID != g.ID || w.UserID != g.UserID {
+			return fmt.Sprintf("Expected %v with id %q and user %q, got %q and %q", sid, w.ID, w.UserID, g.ID, g.UserID)
+		}
+		if wv, gv := values(w), values(g); !reflect.DeepEqual(wv, gv) {
+			return fmt.Sprintf("Expected %v values %v, got %v", sid, wv, gv)
+		}
+	}
+
+	return ""
+}
+
+// Values set by Equivalent, leaving out those the manager keeps itself
+func values(snap session.Snapshot) map[string]interface{} {
+	values := make(map[string]interface{})
+	for k, v := range snap.Values {
+		if key, ok := k.(string); ok && strings.HasPrefix(key, "key") {
+			values[key] = v
+		}
+	}
+	return values
+}
@@ -0,0 +1,112 @@
+package storetest
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	session "github.com/vpatel95/session-manager/v2"
+)
+
+// Backend keeping encoded sessions in a map
+type mapBackend struct {
+	lock sync.Mutex
+	data map[string][]byte
+}
+
+func newMapBackend() *mapBackend {
+	return &mapBackend{data: make(map[string][]byte)}
+}
+
+func (b *mapBackend) Load(sid string) ([]byte, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.data[sid], nil
+}
+
+func (b *mapBackend) Save(r session.Record) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.data[r.ID] = r.Data
+	return nil
+}
+
+func (b *mapBackend) Remove(sid string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	delete(b.data, sid)
+	return nil
+}
+
+func (b *mapBackend) Scan(fn func(sid string, data []byte) bool) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for sid, data := range b.data {
+		if !fn(sid, data) {
+			break
+		}
+	}
+	return nil
+}
+
+func (b *mapBackend) Len() (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.data), nil
+}
+
+// Backend that forgets to remove sessions
+type leakyBackend struct {
+	*mapBackend
+}
+
+func (leakyBackend) Remove(sid string) error {
+	return nil
+}
+
+// Records the failure of Equivalent instead of failing the test
+type recorder struct {
+	testing.TB
+	failure string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failure = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+func TestEquivalent(t *testing.T) {
+	stores := map[string]func() session.Store{
+		"backend": func() session.Store { return session.NewBackendStore(newMapBackend(), nil) },
+		"json":    func() session.Store { return session.NewBackendStore(newMapBackend(), session.JSONCodec{}) },
+		"msgpack": func() session.Store { return session.NewBackendStore(newMapBackend(), session.MessagePackCodec{}) },
+		"tiered": func() session.Store {
+			return session.NewTieredStore(session.NewBackendStore(newMapBackend(), nil), 2, 0)
+		},
+	}
+
+	// Case 1: Stores Behaving Like MemoryStore
+	for name, store := range stores {
+		for seed := int64(1); seed <= 5; seed++ {
+			t.Run(fmt.Sprintf("%s/seed=%d", name, seed), func(t *testing.T) {
+				Equivalent(t, store(), seed, 200)
+			})
+		}
+	}
+
+	// Case 2: Divergent Store Reported With Its Steps
+	rec := &recorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Equivalent(rec, session.NewBackendStore(leakyBackend{newMapBackend()}, nil), 1, 200)
+	}()
+	<-done
+	if !strings.Contains(rec.failure, "destroy") {
+		t.Errorf("Expected a failure after a destroy, got %q", rec.failure)
+	}
+}
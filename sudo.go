@@ -0,0 +1,51 @@
+package session
+
+import (
+	"net/http"
+	"time"
+)
+
+const sudoKey = "_sm.sudo_until"
+
+// Start a sudo window of d, e.g. after the user confirmed their password,
+// during which RequireSudo lets dangerous requests through
+func (s *Session) EnterSudo(d time.Duration) error {
+	return s.set(sudoKey, time.Now().Add(d))
+}
+
+// End the sudo window early
+func (s *Session) ExitSudo() {
+	s.delete(sudoKey)
+}
+
+// Whether the session is within a sudo window
+func (s *Session) InSudo() bool {
+	until, ok := s.Get(sudoKey).(time.Time)
+	return ok && time.Now().Before(until)
+}
+
+// RequireSudo only lets requests through whose session is in sudo mode.
+// Others are redirected to Config.SudoURL to re-authenticate, remembering
+// the requested URL for ConsumeReturnURL, or rejected with 403 if it is not
+// set. Requests without a session are handled like RequireSession.
+func (sm *SessionManager) RequireSudo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, r := sm.requestSession(r)
+		if s == nil {
+			sm.unauthorized(w, r)
+			return
+		}
+
+		if s.InSudo() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if sm.Config.SudoURL == "" {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		s.rememberURL(r)
+		http.Redirect(w, r, sm.Config.SudoURL, http.StatusFound)
+	})
+}
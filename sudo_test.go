@@ -0,0 +1,81 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSession_Sudo(t *testing.T) {
+	s := &Session{sd: make(dict)}
+
+	// Case 1: Not in Sudo by Default
+	if s.InSudo() {
+		t.Errorf("Expected no sudo window")
+	}
+
+	// Case 2: Sudo Window
+	s.EnterSudo(time.Minute)
+	if !s.InSudo() {
+		t.Errorf("Expected sudo window")
+	}
+
+	// Case 3: Window Ends
+	s.EnterSudo(-time.Second)
+	if s.InSudo() {
+		t.Errorf("Expected the sudo window to be over")
+	}
+	s.EnterSudo(time.Minute)
+	s.ExitSudo()
+	if s.InSudo() {
+		t.Errorf("Expected ExitSudo to end the window")
+	}
+
+	// Case 4: Reserved Key
+	if err := s.Set(sudoKey, time.Now().Add(time.Hour)); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey, got %v", err)
+	}
+}
+
+func TestSessionManager_RequireSudo(t *testing.T) {
+	sm := New()
+	s, _ := sm.SessionCreate("sessionid123")
+	handler := sm.RequireSudo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func(cookie bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/settings/delete", nil)
+		if cookie {
+			req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Case 1: No Session
+	if rec := serve(false); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %v", rec.Code)
+	}
+
+	// Case 2: Outside Sudo Without SudoURL
+	if rec := serve(true); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403, got %v", rec.Code)
+	}
+
+	// Case 3: Redirect to SudoURL
+	sm.Config.SudoURL = "/sudo"
+	if rec := serve(true); rec.Code != http.StatusFound || rec.Header().Get("Location") != "/sudo" {
+		t.Errorf("Expected redirect to /sudo, got %v %v", rec.Code, rec.Header().Get("Location"))
+	}
+	if got := s.ConsumeReturnURL(); got != "/settings/delete" {
+		t.Errorf("Expected the return URL to be remembered, got %v", got)
+	}
+
+	// Case 4: In Sudo
+	s.EnterSudo(time.Minute)
+	if rec := serve(true); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected the request through, got %v", rec.Code)
+	}
+}
@@ -0,0 +1,48 @@
+package session
+
+import (
+	"net/http"
+	"time"
+)
+
+const lastPageKey = "_sm.last_page"
+
+// Compact record of an expired session handed to Config.ExpirySink, e.g.
+// for product analytics. It identifies the session by its AffinityHash as
+// the session id is a credential.
+type ExpirySummary struct {
+	Handle       string
+	UserID       string
+	CreatedAt    time.Time
+	LastAccessed time.Time
+	Duration     time.Duration // from creation to the last access
+	LastPage     string        // path of the last GET request seen by Middleware
+}
+
+// Summary of s, taken before the cleaner deletes it
+func (s *Session) summary() ExpirySummary {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	page, _ := s.sd[lastPageKey].(string)
+	return ExpirySummary{
+		Handle:       AffinityHash(s.sessionId),
+		UserID:       s.userId,
+		CreatedAt:    s.meta.CreatedAt,
+		LastAccessed: s.lastAccessed,
+		Duration:     s.lastAccessed.Sub(s.meta.CreatedAt),
+		LastPage:     page,
+	}
+}
+
+// Remember the path of r as the last page of s for its ExpirySummary. Only
+// written when the page changed so plain reloads don't reach the store.
+func (sm *SessionManager) trackPage(s *Session, r *http.Request) {
+	if sm.Config.ExpirySink == nil || r.Method != http.MethodGet {
+		return
+	}
+
+	if page, _ := s.Get(lastPageKey).(string); page != r.URL.Path {
+		s.set(lastPageKey, r.URL.Path)
+	}
+}
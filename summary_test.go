@@ -0,0 +1,62 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_ExpirySink(t *testing.T) {
+	sm := New()
+	sm.Config.MaxLifetime = time.Hour
+
+	var summaries []ExpirySummary
+	sm.Config.ExpirySink = func(sum ExpirySummary) {
+		summaries = append(summaries, sum)
+	}
+	handler := sm.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	s, _ := sm.SessionCreate("sessionid123")
+	s.SetUserID("user1")
+	sm.SessionCreate("sessionid456")
+
+	// Case 1: Middleware Records the Last Page
+	for _, path := range []string{"/cart", "/checkout"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	req := httptest.NewRequest("POST", "/pay", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if page := s.Get(lastPageKey); page != "/checkout" {
+		t.Errorf("Expected /checkout, got %v", page)
+	}
+
+	// Case 2: Only Expired Sessions Are Summarized
+	sm.stored("sessionid123").age(2 * time.Hour)
+	sm.GlobalCleaner()
+	if len(summaries) != 1 {
+		t.Fatalf("Expected 1 summary, got %v", summaries)
+	}
+	sum := summaries[0]
+	if sum.Handle != AffinityHash("sessionid123") || sum.UserID != "user1" || sum.LastPage != "/checkout" {
+		t.Errorf("Expected summary of sessionid123, got %+v", sum)
+	}
+	if sum.Duration != sum.LastAccessed.Sub(sum.CreatedAt) {
+		t.Errorf("Expected duration from creation to last access, got %v", sum.Duration)
+	}
+	if sm.SessionExist("sessionid123") || !sm.SessionExist("sessionid456") {
+		t.Errorf("Expected only sessionid123 to be deleted")
+	}
+
+	// Case 3: No Page Tracking Without a Sink
+	sm.Config.ExpirySink = nil
+	req = httptest.NewRequest("GET", "/home", nil)
+	req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid456"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if page := sm.stored("sessionid456").Get(lastPageKey); page != nil {
+		t.Errorf("Expected no last page, got %v", page)
+	}
+}
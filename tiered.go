@@ -0,0 +1,308 @@
+package session
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// TieredStore keeps the most recently used sessions in memory in front of a
+// slower persistent Store such as a redistore. Reads are served from memory
+// when possible and fall through to the persistent store on a miss, keeping
+// the session read in memory for the next request. Writes go to both, so
+// the persistent store stays complete.
+//
+// Other instances sharing the persistent store don't update this memory
+// tier: cached sessions are read again after ttl, and dropped as soon as a
+// persistent store implementing Watcher reports them removed.
+//
+// With CacheMisses it also remembers ids the persistent store doesn't know,
+// so requests carrying a dead cookie are answered from memory. Concurrent
+// reads of a session that is not in memory share a single read of the
+// persistent store, which is not kept if the session was written or removed
+// while it was read.
+type TieredStore struct {
+	back    Store
+	size    int
+	ttl     time.Duration
+	missTTL time.Duration
+	sm      *SessionManager
+
+	lock    sync.Mutex
+	lru     *list.List // of *tieredEntry, most recently used first
+	entries map[string]*list.Element
+	misses  *list.List // of *tieredEntry without session, oldest last
+	missed  map[string]*list.Element
+	flights map[string]*flight
+}
+
+// Read of the persistent store shared by concurrent Gets of one session
+type flight struct {
+	done   sync.WaitGroup
+	s      *Session
+	err    error
+	shared int  // Gets waiting for the result besides the one reading
+	stale  bool // the session was written or removed during the read
+}
+
+type tieredEntry struct {
+	sid    string
+	s      *Session
+	cached time.Time
+}
+
+// Tiered store over back keeping up to size sessions in memory, unbounded
+// if zero, each for at most ttl before it is read from back again, forever
+// if zero
+func NewTieredStore(back Store, size int, ttl time.Duration) *TieredStore {
+	return &TieredStore{
+		back:    back,
+		size:    size,
+		ttl:     ttl,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element, sizeHint(size)),
+		misses:  list.New(),
+		missed:  make(map[string]*list.Element),
+		flights: make(map[string]*flight),
+	}
+}
+
+// Remember sessions missing from the persistent store for ttl, up to the
+// size of the store, and report them missing without asking it again. A
+// session created through this store is seen at once; one created by
+// another instance only after ttl. Returns ts.
+func (ts *TieredStore) CacheMisses(ttl time.Duration) *TieredStore {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	ts.missTTL = ttl
+	return ts
+}
+
+func (ts *TieredStore) attach(sm *SessionManager) {
+	ts.sm = sm
+	if a, ok := ts.back.(attachable); ok {
+		a.attach(sm)
+	}
+}
+
+func (ts *TieredStore) SelfExpiring() bool {
+	e, ok := ts.back.(Expiring)
+	return ok && e.SelfExpiring()
+}
+
+func (ts *TieredStore) Sweep() error {
+	if sw, ok := ts.back.(Sweeper); ok {
+		return sw.Sweep()
+	}
+	return nil
+}
+
+// Sessions the persistent store reports removed are dropped from memory
+// before fn is called
+func (ts *TieredStore) Watch(fn func(sid string)) (func(), error) {
+	w, ok := ts.back.(Watcher)
+	if !ok {
+		return nil, nil
+	}
+	return w.Watch(func(sid string) {
+		ts.evict(sid)
+		fn(sid)
+	})
+}
+
+func (ts *TieredStore) Get(sid string) (*Session, error) {
+	if s := ts.cached(sid); s != nil {
+		return s, nil
+	}
+	if ts.knownMissing(sid) {
+		return nil, nil
+	}
+
+	return ts.load(sid)
+}
+
+// Read sid from the persistent store into memory, or wait for the read
+// already in flight
+func (ts *TieredStore) load(sid string) (*Session, error) {
+	ts.lock.Lock()
+	if f, ok := ts.flights[sid]; ok {
+		f.shared++
+		ts.lock.Unlock()
+		f.done.Wait()
+		return f.s, f.err
+	}
+	f := &flight{}
+	f.done.Add(1)
+	ts.flights[sid] = f
+	ts.lock.Unlock()
+
+	f.s, f.err = ts.back.Get(sid)
+
+	ts.lock.Lock()
+	if f.err == nil && !f.stale {
+		if f.s == nil {
+			ts.miss(sid)
+		} else {
+			ts.cache(sid, f.s)
+		}
+	}
+	delete(ts.flights, sid)
+	ts.lock.Unlock()
+	f.done.Done()
+
+	return f.s, f.err
+}
+
+func (ts *TieredStore) Set(sid string, s *Session) error {
+	if err := ts.back.Set(sid, s); err != nil {
+		// Memory must not hold what the persistent store refused
+		ts.evict(sid)
+		return err
+	}
+
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	ts.invalidate(sid)
+	ts.cache(sid, s)
+	return nil
+}
+
+// The persistent copy goes first, so a read racing the delete can't cache
+// it again
+func (ts *TieredStore) Delete(sid string) error {
+	err := ts.back.Delete(sid)
+	ts.evict(sid)
+	return err
+}
+
+// Sessions held in memory are handed out instead of the persistent copies
+func (ts *TieredStore) Iterate(fn func(sid string, s *Session) bool) error {
+	return ts.back.Iterate(func(sid string, s *Session) bool {
+		if c := ts.cached(sid); c != nil {
+			s = c
+		}
+		return fn(sid, s)
+	})
+}
+
+func (ts *TieredStore) Count() int {
+	return ts.back.Count()
+}
+
+// Session sid held in memory, nil if it is not or is too old or expired
+func (ts *TieredStore) cached(sid string) *Session {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	el, ok := ts.entries[sid]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*tieredEntry)
+	now := time.Now()
+	if (ts.ttl > 0 && now.Sub(e.cached) >= ts.ttl) || ts.expired(e.s, now) {
+		ts.lru.Remove(el)
+		delete(ts.entries, sid)
+		return nil
+	}
+
+	ts.lru.MoveToFront(el)
+	return e.s
+}
+
+// Whether s outlived its lifetime, which a self-expiring persistent store
+// would already have dropped
+func (ts *TieredStore) expired(s *Session, now time.Time) bool {
+	return ts.sm != nil && ts.sm.idleTimeout() > 0 && ts.sm.expired(s, now)
+}
+
+// Whether sid was recently found missing from the persistent store
+func (ts *TieredStore) knownMissing(sid string) bool {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	el, ok := ts.missed[sid]
+	if !ok {
+		return false
+	}
+	if time.Since(el.Value.(*tieredEntry).cached) >= ts.missTTL {
+		ts.misses.Remove(el)
+		delete(ts.missed, sid)
+		return false
+	}
+	return true
+}
+
+// Remember sid missing, dropping misses that are too old or over size.
+// Caller must hold ts.lock.
+func (ts *TieredStore) miss(sid string) {
+	if ts.missTTL <= 0 {
+		return
+	}
+
+	now := time.Now()
+	if el, ok := ts.missed[sid]; ok {
+		ts.misses.Remove(el)
+	}
+	ts.missed[sid] = ts.misses.PushFront(&tieredEntry{sid: sid, cached: now})
+
+	for oldest := ts.misses.Back(); oldest != nil; oldest = ts.misses.Back() {
+		e := oldest.Value.(*tieredEntry)
+		if now.Sub(e.cached) < ts.missTTL && (ts.size <= 0 || ts.misses.Len() <= ts.size) {
+			break
+		}
+		ts.misses.Remove(oldest)
+		delete(ts.missed, e.sid)
+	}
+}
+
+// Caller must hold ts.lock
+func (ts *TieredStore) cache(sid string, s *Session) {
+	if el, ok := ts.missed[sid]; ok {
+		ts.misses.Remove(el)
+		delete(ts.missed, sid)
+	}
+	if el, ok := ts.entries[sid]; ok {
+		el.Value = &tieredEntry{sid: sid, s: s, cached: time.Now()}
+		ts.lru.MoveToFront(el)
+		return
+	}
+
+	ts.entries[sid] = ts.lru.PushFront(&tieredEntry{sid: sid, s: s, cached: time.Now()})
+	if ts.size > 0 && ts.lru.Len() > ts.size {
+		oldest := ts.lru.Back()
+		ts.lru.Remove(oldest)
+		delete(ts.entries, oldest.Value.(*tieredEntry).sid)
+	}
+}
+
+// Whether sid is held in memory
+func (ts *TieredStore) Holds(sid string) bool {
+	return ts.cached(sid) != nil
+}
+
+// Drop sid from memory, keeping it in the persistent store
+func (ts *TieredStore) Evict(sid string) {
+	ts.evict(sid)
+}
+
+func (ts *TieredStore) evict(sid string) {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	ts.invalidate(sid)
+	if el, ok := ts.entries[sid]; ok {
+		ts.lru.Remove(el)
+		delete(ts.entries, sid)
+	}
+}
+
+// Keep the read of sid in flight, if any, from being cached over a newer
+// write or removal. Caller must hold ts.lock.
+func (ts *TieredStore) invalidate(sid string) {
+	if f, ok := ts.flights[sid]; ok {
+		f.stale = true
+	}
+}
@@ -0,0 +1,337 @@
+package session
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Backend counting its loads
+type loadCountingBackend struct {
+	*mapBackend
+	loads int
+}
+
+func (b *loadCountingBackend) Load(sid string) ([]byte, error) {
+	b.loads++
+	return b.mapBackend.Load(sid)
+}
+
+func TestTieredStore(t *testing.T) {
+	b := &loadCountingBackend{mapBackend: newMapBackend()}
+	ts := NewTieredStore(NewBackendStore(b, nil), 2, 0)
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: ts})
+	defer sm.Close()
+
+	// Case 1: Writes Reach The Persistent Store
+	s, err := sm.SessionCreate("sessionid123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	s.Set("cart", 1)
+	if _, ok := b.data["sessionid123"]; !ok {
+		t.Errorf("Expected session in backend, got %v", b.data)
+	}
+
+	// Case 2: Hot Reads Served From Memory
+	b.loads = 0
+	if got, _ := sm.session("sessionid123"); got != s {
+		t.Errorf("Expected cached session, got %v", got)
+	}
+	if b.loads != 0 {
+		t.Errorf("Expected no backend load, got %v", b.loads)
+	}
+
+	// Case 3: Miss Falls Through And Is Cached
+	ts.evict("sessionid123")
+	got, ok := sm.session("sessionid123")
+	if !ok || got.Get("cart") != 1 {
+		t.Fatalf("Expected session from backend, got %v", got)
+	}
+	if b.loads != 1 {
+		t.Errorf("Expected 1 backend load, got %v", b.loads)
+	}
+	sm.session("sessionid123")
+	if b.loads != 1 {
+		t.Errorf("Expected session kept in memory, got %v loads", b.loads)
+	}
+
+	// Case 4: Least Recently Used Evicted Past Size
+	sm.SessionCreate("sessionid456")
+	sm.SessionCreate("sessionid789")
+	if ts.lru.Len() != 2 {
+		t.Errorf("Expected 2 cached sessions, got %v", ts.lru.Len())
+	}
+	if _, ok := ts.entries["sessionid123"]; ok {
+		t.Errorf("Expected sessionid123 evicted")
+	}
+	if sm.SessionCount() != 3 {
+		t.Errorf("Expected 3 sessions, got %v", sm.SessionCount())
+	}
+
+	// Case 5: Destroy Removes Both Tiers
+	sm.SessionDestroy("sessionid456")
+	if _, ok := ts.entries["sessionid456"]; ok {
+		t.Errorf("Expected sessionid456 dropped from memory")
+	}
+	if _, ok := b.data["sessionid456"]; ok {
+		t.Errorf("Expected sessionid456 dropped from backend")
+	}
+}
+
+func TestTieredStore_Staleness(t *testing.T) {
+	// Case 1: Cached Copy Read Again After TTL
+	b := &loadCountingBackend{mapBackend: newMapBackend()}
+	ts := NewTieredStore(NewBackendStore(b, nil), 0, time.Minute)
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: ts})
+	defer sm.Close()
+
+	sm.SessionCreate("sessionid123")
+	ts.entries["sessionid123"].Value.(*tieredEntry).cached = time.Now().Add(-2 * time.Minute)
+	b.loads = 0
+	if _, ok := sm.session("sessionid123"); !ok {
+		t.Fatalf("Expected session, got nil")
+	}
+	if b.loads != 1 {
+		t.Errorf("Expected 1 backend load, got %v", b.loads)
+	}
+
+	// Case 2: Expired Session Not Served From Memory
+	s := ts.entries["sessionid123"].Value.(*tieredEntry).s
+	s.age(2 * time.Hour)
+	delete(b.data, "sessionid123")
+	if got, _ := ts.Get("sessionid123"); got != nil {
+		t.Errorf("Expected expired session missing, got %v", got)
+	}
+
+	// Case 3: Remote Removal Drops Cached Copy
+	wb := &watchBackend{mapBackend: newMapBackend()}
+	ws := NewTieredStore(NewBackendStore(wb, nil), 0, 0)
+	var removed []string
+	wm := New(SessionManagerConfig{
+		MaxLifetime: time.Hour,
+		Store:       ws,
+		OnEvent: func(e Event) {
+			if e.Type == EventRemoved {
+				removed = append(removed, e.SessionID)
+			}
+		},
+	})
+	defer wm.Close()
+
+	wm.SessionCreate("sessionid456")
+	wb.Remove("sessionid456")
+	if _, ok := ws.entries["sessionid456"]; ok || len(removed) != 1 {
+		t.Errorf("Expected cached copy dropped and removal reported, got %v", removed)
+	}
+}
+
+func TestTieredStore_CacheMisses(t *testing.T) {
+	b := &loadCountingBackend{mapBackend: newMapBackend()}
+	ts := NewTieredStore(NewBackendStore(b, nil), 2, 0).CacheMisses(time.Minute)
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: ts})
+	defer sm.Close()
+
+	// Case 1: Repeated Misses Answered From Memory
+	for i := 0; i < 3; i++ {
+		if sm.SessionExist("dead") {
+			t.Fatalf("Expected dead session missing")
+		}
+	}
+	if b.loads != 1 {
+		t.Errorf("Expected 1 backend load, got %v", b.loads)
+	}
+
+	// Case 2: Create Invalidates the Miss
+	sm.SessionCreate("dead")
+	if !sm.SessionExist("dead") {
+		t.Errorf("Expected created session to be found")
+	}
+
+	// Case 3: Miss Forgotten After TTL
+	b.loads = 0
+	sm.SessionExist("gone")
+	ts.missed["gone"].Value.(*tieredEntry).cached = time.Now().Add(-2 * time.Minute)
+	sm.SessionExist("gone")
+	if b.loads != 2 {
+		t.Errorf("Expected 2 backend loads, got %v", b.loads)
+	}
+
+	// Case 4: Misses Bounded by Size
+	sm.SessionExist("a")
+	sm.SessionExist("b")
+	sm.SessionExist("c")
+	if ts.misses.Len() != 2 {
+		t.Errorf("Expected 2 cached misses, got %v", ts.misses.Len())
+	}
+
+	// Case 5: Disabled by Default
+	plain := NewTieredStore(NewBackendStore(newMapBackend(), nil), 0, 0)
+	plain.Get("dead")
+	if plain.misses.Len() != 0 {
+		t.Errorf("Expected no cached misses, got %v", plain.misses.Len())
+	}
+}
+
+// Store whose reads return only once release is closed
+type gatedStore struct {
+	Store
+	release chan struct{}
+	reads   atomic.Int32
+}
+
+func (g *gatedStore) Get(sid string) (*Session, error) {
+	g.reads.Add(1)
+	s, err := g.Store.Get(sid)
+	<-g.release
+	return s, err
+}
+
+func TestTieredStore_CoalescedReads(t *testing.T) {
+	back := NewBackendStore(newMapBackend(), nil)
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: back})
+	sm.SessionCreate("sessionid123")
+	sm.Close()
+
+	g := &gatedStore{Store: back, release: make(chan struct{})}
+	ts := NewTieredStore(g, 0, 0)
+
+	// Case 1: Concurrent Cold Reads Share One Store Read
+	const readers = 50
+	var wg sync.WaitGroup
+	got := make([]*Session, readers)
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i], _ = ts.Get("sessionid123")
+		}(i)
+	}
+	for {
+		ts.lock.Lock()
+		f := ts.flights["sessionid123"]
+		joined := f != nil && f.shared == readers-1
+		ts.lock.Unlock()
+		if joined {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(g.release)
+	wg.Wait()
+
+	if n := g.reads.Load(); n != 1 {
+		t.Errorf("Expected 1 store read, got %v", n)
+	}
+	for _, s := range got {
+		if s == nil || s != got[0] {
+			t.Fatalf("Expected every reader to get the same session, got %v", got)
+		}
+	}
+
+	// Case 2: Flight Ends With the Read
+	if len(ts.flights) != 0 {
+		t.Errorf("Expected no flights left, got %v", ts.flights)
+	}
+	if s, _ := ts.Get("sessionid123"); s != got[0] || g.reads.Load() != 1 {
+		t.Errorf("Expected session served from memory")
+	}
+}
+
+func TestTieredStore_DestroyDuringRead(t *testing.T) {
+	back := NewBackendStore(newMapBackend(), nil)
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: back})
+	sm.SessionCreate("sessionid123")
+	sm.SessionCreate("sessionid456")
+	sm.Close()
+
+	g := &gatedStore{Store: back}
+	ts := NewTieredStore(g, 0, 0).CacheMisses(time.Hour)
+	slowGet := func(sid string) chan struct{} {
+		g.release = make(chan struct{})
+		read := make(chan struct{})
+		go func() {
+			ts.Get(sid)
+			close(read)
+		}()
+		for {
+			ts.lock.Lock()
+			_, ok := ts.flights[sid]
+			ts.lock.Unlock()
+			if ok {
+				return read
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// Case 1: Session Destroyed During a Slow Read Is Not Cached
+	read := slowGet("sessionid123")
+	if err := ts.Delete("sessionid123"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	close(g.release)
+	<-read
+	if ts.Holds("sessionid123") {
+		t.Errorf("Expected the destroyed session not to be cached")
+	}
+	if s, _ := ts.Get("sessionid123"); s != nil {
+		t.Errorf("Expected the destroyed session gone, got %v", s)
+	}
+
+	// Case 2: Session Written During a Slow Read Keeps the Write
+	read = slowGet("sessionid456")
+	s := sm.newSession("sessionid456")
+	if err := ts.Set("sessionid456", s); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	close(g.release)
+	<-read
+	if got := ts.cached("sessionid456"); got != s {
+		t.Errorf("Expected the written session cached, got %v", got)
+	}
+}
+
+// Store whose deletes wait for release once they were called
+type slowDeleteStore struct {
+	Store
+	deleting chan struct{}
+	release  chan struct{}
+}
+
+func (d *slowDeleteStore) Delete(sid string) error {
+	close(d.deleting)
+	<-d.release
+	return d.Store.Delete(sid)
+}
+
+func TestTieredStore_ReadDuringDelete(t *testing.T) {
+	back := NewBackendStore(newMapBackend(), nil)
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: back})
+	sm.SessionCreate("sessionid123")
+	sm.Close()
+
+	d := &slowDeleteStore{Store: back, deleting: make(chan struct{}), release: make(chan struct{})}
+	ts := NewTieredStore(d, 0, 0)
+	ts.Get("sessionid123")
+	ts.Evict("sessionid123")
+
+	// Case 1: Read Before the Persistent Copy Is Gone Is Not Cached
+	deleted := make(chan error)
+	go func() {
+		deleted <- ts.Delete("sessionid123")
+	}()
+	<-d.deleting
+	ts.Get("sessionid123")
+	close(d.release)
+	if err := <-deleted; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ts.Holds("sessionid123") {
+		t.Errorf("Expected the deleted session not to be cached")
+	}
+	if s, _ := ts.Get("sessionid123"); s != nil {
+		t.Errorf("Expected the deleted session gone, got %v", s)
+	}
+}
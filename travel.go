@@ -0,0 +1,87 @@
+package session
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"time"
+)
+
+// Travel speed in km/h above which consecutive accesses are considered
+// impossible when Config.MaxTravelSpeed is not set. Roughly a commercial flight.
+const DefaultMaxTravelSpeed = 1000
+
+const earthRadiusKm = 6371
+
+var ErrImpossibleTravel = errors.New("session terminated due to impossible travel")
+
+// A single access to a session
+type Access struct {
+	IP       string
+	Location Location
+	Time     time.Time
+}
+
+// Great-circle distance between two locations in kilometers
+func distanceKm(a, b Location) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+func (sm *SessionManager) maxTravelSpeed() float64 {
+	if sm.Config.MaxTravelSpeed > 0 {
+		return sm.Config.MaxTravelSpeed
+	}
+	return DefaultMaxTravelSpeed
+}
+
+// Compare the location of r with the previous access of the session and
+// consult OnImpossibleTravel when the two could not have been reached in time.
+func (sm *SessionManager) checkTravel(s *Session, r *http.Request) error {
+	if sm.Config.OnImpossibleTravel == nil || sm.Config.GeoResolver == nil {
+		return nil
+	}
+
+	cur := Access{IP: sm.clientIP(r), Time: time.Now()}
+
+	s.lock.RLock()
+	prev := s.lastAccess
+	s.lock.RUnlock()
+
+	if prev.IP == cur.IP {
+		cur.Location = prev.Location
+	} else if loc, err := sm.Config.GeoResolver.Resolve(cur.IP); err == nil {
+		cur.Location = loc
+	}
+
+	s.lock.Lock()
+	s.lastAccess = cur
+	s.lock.Unlock()
+	if prev.IP != cur.IP {
+		// Stored so the next check sees it on every instance
+		s.commit(nil)
+	}
+
+	if prev.IP == "" || prev.IP == cur.IP || !prev.Location.known() || !cur.Location.known() {
+		return nil
+	}
+
+	dist := distanceKm(prev.Location, cur.Location)
+	hours := cur.Time.Sub(prev.Time).Hours()
+	if dist <= sm.maxTravelSpeed()*hours {
+		return nil
+	}
+
+	if sm.Config.OnImpossibleTravel(s, prev, cur) {
+		sm.SessionDestroy(s.ID())
+		return ErrImpossibleTravel
+	}
+
+	return nil
+}
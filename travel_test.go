@@ -0,0 +1,85 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var (
+	seattle = Location{Country: "US", City: "Seattle", Latitude: 47.61, Longitude: -122.33}
+	tacoma  = Location{Country: "US", City: "Tacoma", Latitude: 47.25, Longitude: -122.44}
+	london  = Location{Country: "GB", City: "London", Latitude: 51.51, Longitude: -0.13}
+)
+
+func TestDistanceKm(t *testing.T) {
+	// Case 1: Same Location
+	if d := distanceKm(seattle, seattle); d != 0 {
+		t.Errorf("Expected 0, got %v", d)
+	}
+
+	// Case 2: Seattle to London is about 7700km
+	if d := distanceKm(seattle, london); d < 7600 || d > 7800 {
+		t.Errorf("Expected about 7700, got %v", d)
+	}
+}
+
+func TestSessionManager_ImpossibleTravel(t *testing.T) {
+	sm := New()
+	sm.Config.GeoResolver = geoResolverFunc(func(ip string) (Location, error) {
+		switch ip {
+		case "192.0.2.1":
+			return seattle, nil
+		case "192.0.2.2":
+			return tacoma, nil
+		}
+		return london, nil
+	})
+
+	var flagged []Access
+	terminate := false
+	sm.Config.OnImpossibleTravel = func(s *Session, from, to Access) bool {
+		flagged = append(flagged, from, to)
+		return terminate
+	}
+
+	read := func(ip string) (*Session, error) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: "sessionid123"})
+		return sm.SessionRead(req)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	s, _ := sm.SessionCreateFromRequest("sessionid123", req)
+
+	// Case 1: Same IP
+	if _, err := read("192.0.2.1"); err != nil || len(flagged) != 0 {
+		t.Errorf("Expected no flag, got %v, error: %v", flagged, err)
+	}
+
+	// Case 2: Plausible Travel
+	s.lastAccess.Time = time.Now().Add(-1 * time.Hour)
+	if _, err := read("192.0.2.2"); err != nil || len(flagged) != 0 {
+		t.Errorf("Expected no flag, got %v, error: %v", flagged, err)
+	}
+
+	// Case 3: Impossible Travel Flagged
+	if _, err := read("203.0.113.1"); err != nil || len(flagged) != 2 {
+		t.Fatalf("Expected flag, got %v, error: %v", flagged, err)
+	}
+	if flagged[0].Location != tacoma || flagged[1].Location != london {
+		t.Errorf("Expected Tacoma to London, got %v to %v", flagged[0].Location, flagged[1].Location)
+	}
+
+	// Case 4: Impossible Travel Terminates Session
+	terminate = true
+	if _, err := read("192.0.2.1"); err != ErrImpossibleTravel {
+		t.Errorf("Expected ErrImpossibleTravel, got %v", err)
+	}
+	if sm.SessionExist("sessionid123") {
+		t.Errorf("Expected sessionid123 to be destroyed")
+	}
+}
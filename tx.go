@@ -0,0 +1,134 @@
+package session
+
+import "time"
+
+// Tx is the view of a session inside Session.Update. Writes are staged and
+// only applied to the session once the callback returns nil.
+type Tx interface {
+	Get(key interface{}) interface{}
+	Exist(key interface{}) bool
+	Set(key, value interface{}) error
+	Delete(key interface{}) error
+}
+
+type tx struct {
+	s      *Session
+	now    time.Time
+	staged map[interface{}]txValue
+	writes []Write
+}
+
+type txValue struct {
+	value   interface{}
+	deleted bool
+}
+
+// Caller must hold t.s.lock
+func (t *tx) lookup(key interface{}) (interface{}, bool) {
+	if v, ok := t.staged[key]; ok {
+		return v.value, !v.deleted
+	}
+	if val, ok := t.s.sd[key]; ok && !t.s.classExpired(key, t.now) {
+		return val, true
+	}
+	return nil, false
+}
+
+func (t *tx) Get(key interface{}) interface{} {
+	val, _ := t.lookup(key)
+	return val
+}
+
+func (t *tx) Exist(key interface{}) bool {
+	_, ok := t.lookup(key)
+	return ok
+}
+
+func (t *tx) Set(key, value interface{}) error {
+	if isReservedKey(key) {
+		return ErrReservedKey
+	}
+	if err := t.s.validate(key, value); err != nil {
+		return err
+	}
+
+	key = t.s.internKey(key)
+	t.staged[key] = txValue{value: value}
+	t.writes = append(t.writes, Write{Op: WriteSet, Key: key, Value: value})
+	return nil
+}
+
+func (t *tx) Delete(key interface{}) error {
+	if isReservedKey(key) {
+		return ErrReservedKey
+	}
+
+	t.staged[key] = txValue{deleted: true}
+	t.writes = append(t.writes, Write{Op: WriteDelete, Key: key})
+	return nil
+}
+
+// Change several keys at once with fn, e.g. to keep a cart and its total in
+// step. If fn returns an error nothing is changed, otherwise all of its
+// writes are applied together. Other writes to the session wait for fn, which
+// must not call methods of the session itself.
+func (s *Session) Update(fn func(tx Tx) error) error {
+	if err := s.frozen(); err != nil {
+		return err
+	}
+	if err := s.promoteGuest(); err != nil {
+		return err
+	}
+
+	writes, before, err := s.runTx(fn)
+	if err != nil || len(writes) == 0 {
+		return err
+	}
+
+	if s.forward(Write{Op: WriteBatch, Value: writes}) {
+		return nil
+	}
+	return s.commit(s.undoer(before))
+}
+
+// Apply the writes of fn, returning them and the values they replaced
+func (s *Session) runTx(fn func(tx Tx) error) ([]Write, map[interface{}]txValue, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	t := &tx{s: s, now: time.Now(), staged: make(map[interface{}]txValue)}
+	if err := fn(t); err != nil {
+		return nil, nil, err
+	}
+
+	changed := false
+	before := make(map[interface{}]txValue)
+	for _, w := range t.writes {
+		old, existed := s.sd[w.Key]
+		if _, ok := before[w.Key]; !ok {
+			before[w.Key] = txValue{value: old, deleted: !existed}
+		}
+		switch w.Op {
+		case WriteSet:
+			if s.classExpired(w.Key, t.now) {
+				s.unclass(w.Key)
+			}
+			s.sd[w.Key] = w.Value
+			s.record(w.Key, old, existed, w.Value, true)
+			changed = true
+		case WriteDelete:
+			s.unclass(w.Key)
+			if existed {
+				delete(s.sd, w.Key)
+				s.record(w.Key, old, true, nil, false)
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return nil, nil, nil
+	}
+	s.updatedAt = time.Now()
+
+	return t.writes, before, nil
+}
@@ -0,0 +1,71 @@
+package session
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestSession_Update(t *testing.T) {
+	sm := New()
+	s, _ := sm.SessionCreate("sessionid123")
+	s.Set("items", 1)
+	s.Set("total", 10)
+
+	// Case 1: All Writes Applied
+	err := s.Update(func(tx Tx) error {
+		tx.Set("items", tx.Get("items").(int)+1)
+		tx.Set("total", tx.Get("total").(int)+10)
+		tx.Delete("coupon")
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if s.Get("items") != 2 || s.Get("total") != 20 {
+		t.Errorf("Expected 2 items for 20, got %v for %v", s.Get("items"), s.Get("total"))
+	}
+
+	// Case 2: Writes Discarded On Error
+	errAbort := errors.New("abort")
+	err = s.Update(func(tx Tx) error {
+		tx.Set("items", 3)
+		tx.Delete("total")
+		if tx.Exist("total") {
+			t.Errorf("Expected staged delete to be visible")
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		t.Errorf("Expected %v, got %v", errAbort, err)
+	}
+	if s.Get("items") != 2 || s.Get("total") != 20 {
+		t.Errorf("Expected session unchanged, got %v for %v", s.Get("items"), s.Get("total"))
+	}
+
+	// Case 3: Reserved Keys Rejected
+	err = s.Update(func(tx Tx) error {
+		return tx.Set(ReservedKeyPrefix+"x", 1)
+	})
+	if err != ErrReservedKey {
+		t.Errorf("Expected %v, got %v", ErrReservedKey, err)
+	}
+
+	// Case 4: Concurrent Updates Keep Invariant
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Update(func(tx Tx) error {
+				tx.Set("items", tx.Get("items").(int)+1)
+				tx.Set("total", tx.Get("total").(int)+10)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	if s.Get("items") != 52 || s.Get("total") != 520 {
+		t.Errorf("Expected 52 items for 520, got %v for %v", s.Get("items"), s.Get("total"))
+	}
+}
@@ -0,0 +1,26 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+)
+
+var ErrUserAgentMismatch = errors.New("session used from another user agent")
+
+// Reject r if Config.BindUserAgent is set and its User-Agent differs from
+// the one s was created with. Sessions not created from a request are not
+// bound.
+func (sm *SessionManager) checkUserAgent(s *Session, r *http.Request) error {
+	if !sm.Config.BindUserAgent {
+		return nil
+	}
+
+	s.lock.RLock()
+	bound := s.meta.UserAgent
+	s.lock.RUnlock()
+
+	if bound != "" && bound != r.UserAgent() {
+		return ErrUserAgentMismatch
+	}
+	return nil
+}
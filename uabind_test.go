@@ -0,0 +1,45 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionManager_BindUserAgent(t *testing.T) {
+	sm := New()
+	sm.Config.BindUserAgent = true
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("User-Agent", "Browser/1.0")
+	sm.SessionCreateFromRequest("sessionid123", req)
+	sm.SessionCreate("sessionid456")
+
+	read := func(sid, agent string) (*Session, error) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("User-Agent", agent)
+		req.AddCookie(&http.Cookie{Name: sm.Cookie.Name, Value: sid})
+		return sm.SessionRead(req)
+	}
+
+	// Case 1: Same User Agent Accepted
+	if s, err := read("sessionid123", "Browser/1.0"); err != nil || s == nil {
+		t.Errorf("Expected session, got %v, error: %v", s, err)
+	}
+
+	// Case 2: Other User Agent Rejected
+	if s, err := read("sessionid123", "curl/8.0"); err != ErrUserAgentMismatch || s != nil {
+		t.Errorf("Expected ErrUserAgentMismatch, got %v, %v", s, err)
+	}
+
+	// Case 3: Sessions Without a Recorded User Agent Are Not Bound
+	if s, err := read("sessionid456", "curl/8.0"); err != nil || s == nil {
+		t.Errorf("Expected unbound session, got %v, error: %v", s, err)
+	}
+
+	// Case 4: Binding Off
+	sm.Config.BindUserAgent = false
+	if s, err := read("sessionid123", "curl/8.0"); err != nil || s == nil {
+		t.Errorf("Expected session, got %v, error: %v", s, err)
+	}
+}
@@ -0,0 +1,104 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// Validator rejects values that must not enter a session
+type Validator func(value interface{}) error
+
+// Returned by Set, SetIn and Tx.Set when a validator rejects a value, wrapping the
+// error of the validator
+type ValidationError struct {
+	Key interface{}
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid value for session key %v: %v", e.Key, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Run the validators of key and the session-wide one on value. Validators
+// may run within Session.Update, under s.lock, so they get no session.
+func (s *Session) validate(key, value interface{}) error {
+	if s.manager == nil {
+		return nil
+	}
+	config := &s.manager.Config
+
+	if v, ok := config.Validators[key]; ok {
+		if err := v(value); err != nil {
+			return &ValidationError{key, err}
+		}
+	}
+	if config.Validate != nil {
+		if err := config.Validate(key, value); err != nil {
+			return &ValidationError{key, err}
+		}
+	}
+
+	return nil
+}
+
+// Reject values that are not of the same type as example
+func TypeOf(example interface{}) Validator {
+	want := reflect.TypeOf(example)
+	return func(value interface{}) error {
+		if got := reflect.TypeOf(value); got != want {
+			return fmt.Errorf("expected %v, got %v", want, got)
+		}
+		return nil
+	}
+}
+
+// Reject strings and byte slices longer than n bytes
+func MaxLen(n int) Validator {
+	return func(value interface{}) error {
+		var l int
+		switch v := value.(type) {
+		case string:
+			l = len(v)
+		case []byte:
+			l = len(v)
+		default:
+			return nil
+		}
+		if l > n {
+			return fmt.Errorf("longer than %d bytes", n)
+		}
+		return nil
+	}
+}
+
+// Reject strings not matching re
+func Matches(re *regexp.Regexp) Validator {
+	return func(value interface{}) error {
+		v, ok := value.(string)
+		if !ok {
+			return errors.New("not a string")
+		}
+		if !re.MatchString(v) {
+			return fmt.Errorf("does not match %v", re)
+		}
+		return nil
+	}
+}
+
+// Validator passing only values all of validators accept
+func All(validators ...Validator) Validator {
+	return func(value interface{}) error {
+		for _, v := range validators {
+			if err := v(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
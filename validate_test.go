@@ -0,0 +1,63 @@
+package session
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSession_Validators(t *testing.T) {
+	errNil := errors.New("nil value")
+	sm := New(SessionManagerConfig{
+		Validators: map[interface{}]Validator{
+			"email": All(MaxLen(64), Matches(regexp.MustCompile(`^[^@]+@[^@]+$`))),
+			"count": TypeOf(0),
+		},
+		Validate: func(key, value interface{}) error {
+			if value == nil {
+				return errNil
+			}
+			return nil
+		},
+	})
+	s, _ := sm.SessionCreate("sessionid123")
+
+	// Case 1: Valid Values Accepted
+	if err := s.Set("email", "user@example.com"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := s.Set("count", 1); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	// Case 2: Invalid Values Rejected
+	var verr *ValidationError
+	if err := s.Set("email", "nobody"); !errors.As(err, &verr) || verr.Key != "email" {
+		t.Errorf("Expected validation error for email, got %v", err)
+	}
+	if err := s.Set("email", strings.Repeat("a", 64)+"@example.com"); err == nil {
+		t.Errorf("Expected error for a long email, got nil")
+	}
+	if err := s.Set("count", "1"); err == nil {
+		t.Errorf("Expected error for a string count, got nil")
+	}
+	if s.Get("email") != "user@example.com" || s.Get("count") != 1 {
+		t.Errorf("Expected rejected values not to be stored")
+	}
+
+	// Case 3: Session-Wide Validator
+	s.Set("theme", "dark")
+	if err := s.Set("locale", nil); !errors.Is(err, errNil) {
+		t.Errorf("Expected %v, got %v", errNil, err)
+	}
+
+	// Case 4: Transactions Validated
+	err := s.Update(func(tx Tx) error {
+		tx.Set("theme", "light")
+		return tx.Set("count", 2.5)
+	})
+	if err == nil || s.Get("theme") != "dark" {
+		t.Errorf("Expected the transaction to be rejected, got %v", err)
+	}
+}
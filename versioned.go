@@ -0,0 +1,102 @@
+package session
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Marks data written by a VersionedCodec. 0xc1 can't start a gob, gzip,
+// JSON or MessagePack encoding, so older unversioned data is told apart.
+var versionedMagic = []byte{0xc1, 's', 'v'}
+
+var ErrSchemaVersion = errors.New("session written with a newer schema version")
+
+// Upgrades a session decoded at one schema version to the next
+type Migration func(snap *Snapshot) error
+
+// VersionedCodec writes a schema version in front of what another Codec
+// encodes, and on decode runs the migrations from the version the session
+// was written with up to the current one. Data written without a version,
+// e.g. before the codec was introduced, is version 0.
+//
+// Bump the version whenever the values an application stores change shape,
+// keep the old types registered with RegisterType, and register a migration
+// converting them. Sessions are written at the current version on their
+// next write. Data from a newer version, e.g. after a rollback, fails with
+// ErrSchemaVersion, see Config.CorruptSessions.
+type VersionedCodec struct {
+	codec      Codec
+	version    int
+	migrations map[int]Migration
+}
+
+// Codec versioning the output of codec, GobCodec if nil, as version
+func NewVersionedCodec(codec Codec, version int) *VersionedCodec {
+	if codec == nil {
+		codec = GobCodec{}
+	}
+	if version < 0 {
+		panic("session: negative schema version")
+	}
+	return &VersionedCodec{codec: codec, version: version, migrations: make(map[int]Migration)}
+}
+
+// Register m to upgrade sessions from version from to from+1. Versions
+// without a migration are upgraded as they are. Register migrations before
+// the codec is used.
+func (vc *VersionedCodec) RegisterMigration(from int, m Migration) {
+	if from < 0 || from >= vc.version {
+		panic(fmt.Sprintf("session: migration from version %d, current version is %d", from, vc.version))
+	}
+	vc.migrations[from] = m
+}
+
+func (vc *VersionedCodec) Encode(snap Snapshot) ([]byte, error) {
+	data, err := vc.codec.Encode(snap)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(versionedMagic)+binary.MaxVarintLen64+len(data))
+	out = append(out, versionedMagic...)
+	out = binary.AppendUvarint(out, uint64(vc.version))
+	return append(out, data...), nil
+}
+
+func (vc *VersionedCodec) Decode(data []byte) (Snapshot, error) {
+	version, data, err := splitVersion(data)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if version > vc.version {
+		return Snapshot{}, fmt.Errorf("%w: %d, current version is %d", ErrSchemaVersion, version, vc.version)
+	}
+
+	snap, err := vc.codec.Decode(data)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	for v := version; v < vc.version; v++ {
+		if m := vc.migrations[v]; m != nil {
+			if err := m(&snap); err != nil {
+				return Snapshot{}, fmt.Errorf("migrating session from version %d: %w", v, err)
+			}
+		}
+	}
+	return snap, nil
+}
+
+// Schema version of data and the encoding following it
+func splitVersion(data []byte) (int, []byte, error) {
+	if !bytes.HasPrefix(data, versionedMagic) {
+		return 0, data, nil
+	}
+
+	version, n := binary.Uvarint(data[len(versionedMagic):])
+	if n <= 0 || version > uint64(^uint(0)>>1) {
+		return 0, nil, errors.New("invalid schema version header")
+	}
+	return int(version), data[len(versionedMagic)+n:], nil
+}
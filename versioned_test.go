@@ -0,0 +1,93 @@
+package session
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type versionedCartV1 struct {
+	Items int
+}
+
+type versionedCartV2 struct {
+	Items []string
+}
+
+func init() {
+	RegisterTypeName("session.versionedCartV1", versionedCartV1{})
+	RegisterTypeName("session.versionedCartV2", versionedCartV2{})
+}
+
+func TestVersionedCodec(t *testing.T) {
+	snap := Snapshot{ID: "sessionid123", LastAccessed: time.Now(), Values: map[interface{}]interface{}{"count": versionedCartV1{Items: 2}}}
+	legacy, _ := GobCodec{}.Encode(snap)
+
+	vc := NewVersionedCodec(nil, 2)
+	vc.RegisterMigration(0, func(snap *Snapshot) error {
+		snap.Values["cart"] = snap.Values["count"]
+		delete(snap.Values, "count")
+		return nil
+	})
+	vc.RegisterMigration(1, func(snap *Snapshot) error {
+		old, ok := snap.Values["cart"].(versionedCartV1)
+		if !ok {
+			return errors.New("no v1 cart")
+		}
+		snap.Values["cart"] = versionedCartV2{Items: make([]string, old.Items)}
+		return nil
+	})
+
+	// Case 1: Unversioned Data Migrated From Version 0
+	got, err := vc.Decode(legacy)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cart, ok := got.Values["cart"].(versionedCartV2); !ok || len(cart.Items) != 2 || got.Values["count"] != nil {
+		t.Errorf("Expected migrated v2 cart, got %v", got.Values)
+	}
+
+	// Case 2: Current Version Written and Decoded Without Migrations
+	data, err := vc.Encode(got)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if version, _, _ := splitVersion(data); version != 2 {
+		t.Errorf("Expected version 2 header, got %v", version)
+	}
+	if got, err := vc.Decode(data); err != nil || got.ID != "sessionid123" {
+		t.Errorf("Expected decoded snapshot, got %+v, %v", got, err)
+	}
+
+	// Case 3: Only Later Migrations Run
+	v1, _ := NewVersionedCodec(nil, 1).Encode(Snapshot{ID: "sessionid123", Values: map[interface{}]interface{}{"cart": versionedCartV1{Items: 1}}})
+	if got, err := vc.Decode(v1); err != nil || len(got.Values["cart"].(versionedCartV2).Items) != 1 {
+		t.Errorf("Expected v1 data migrated once, got %+v, %v", got, err)
+	}
+
+	// Case 4: Newer Versions Rejected
+	v3, _ := NewVersionedCodec(nil, 3).Encode(snap)
+	if _, err := vc.Decode(v3); !errors.Is(err, ErrSchemaVersion) {
+		t.Errorf("Expected ErrSchemaVersion, got %v", err)
+	}
+
+	// Case 5: Failed Migration
+	v1, _ = NewVersionedCodec(nil, 1).Encode(Snapshot{ID: "sessionid123", Values: map[interface{}]interface{}{"cart": 1}})
+	if _, err := vc.Decode(v1); err == nil {
+		t.Errorf("Expected error from the failed migration")
+	}
+
+	// Case 6: Corrupt Header
+	if _, err := vc.Decode(append(append([]byte{}, versionedMagic...), 0xff)); err == nil {
+		t.Errorf("Expected error for a truncated version")
+	}
+
+	// Case 7: Stacked Under Compression
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: NewBackendStore(newMapBackend(), NewCompressedCodec(vc, 0))})
+	defer sm.Close()
+	s, _ := sm.SessionCreate("sessionid123")
+	s.Set("cart", versionedCartV2{Items: []string{"a"}})
+	if s, ok := sm.session("sessionid123"); !ok || s.Get("cart").(versionedCartV2).Items[0] != "a" {
+		t.Errorf("Expected cart kept through the store")
+	}
+}
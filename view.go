@@ -0,0 +1,26 @@
+package session
+
+import "time"
+
+// Values of allowedKeys, to hand to HTML templates or serialize for the
+// client without exposing anything else stored in the session. Keys not
+// listed, keys with the reserved prefix such as CSRF tokens and values
+// whose class expired are left out. The map is a copy: changing it does not
+// change the session.
+func (s *Session) PublicView(allowedKeys ...string) map[string]interface{} {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	now := time.Now()
+	view := make(map[string]interface{}, len(allowedKeys))
+	for _, key := range allowedKeys {
+		if isReservedKey(key) {
+			continue
+		}
+		if val, ok := s.sd[key]; ok && !s.classExpired(key, now) {
+			view[key] = val
+		}
+	}
+
+	return view
+}
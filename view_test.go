@@ -0,0 +1,41 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSession_PublicView(t *testing.T) {
+	sm := New()
+	sm.Config.ValueClasses = map[string]time.Duration{"auth": time.Hour}
+	s, _ := sm.SessionCreate("sessionid123")
+	s.Set("theme", "dark")
+	s.Set("password_hash", "secret")
+	s.set(returnURLKey, "/admin")
+	s.SetIn("auth", "user_id", "user1")
+
+	// Case 1: Only Allowed Keys
+	view := s.PublicView("theme", "user_id", "missing")
+	if len(view) != 2 || view["theme"] != "dark" || view["user_id"] != "user1" {
+		t.Errorf("Expected theme and user_id, got %v", view)
+	}
+
+	// Case 2: Reserved Keys Never Exposed
+	if view := s.PublicView(returnURLKey); len(view) != 0 {
+		t.Errorf("Expected empty view, got %v", view)
+	}
+
+	// Case 3: Expired Classes Left Out
+	s.lock.Lock()
+	s.classes["auth"].expires = time.Now().Add(-time.Second)
+	s.lock.Unlock()
+	if view := s.PublicView("theme", "user_id"); len(view) != 1 {
+		t.Errorf("Expected only theme, got %v", view)
+	}
+
+	// Case 4: Changes Do Not Reach The Session
+	view["theme"] = "light"
+	if s.Get("theme") != "dark" {
+		t.Errorf("Expected dark, got %v", s.Get("theme"))
+	}
+}
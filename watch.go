@@ -0,0 +1,103 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// Implemented by stores and backends reporting entries removed outside this
+// manager, e.g. destroyed by another instance sharing the store or expired
+// through a lease. New starts watching such a store and Close stops it; the
+// manager emits EventRemoved for every entry reported.
+type Watcher interface {
+	// Call fn with the id of every removed entry until stop is called. A nil
+	// stop means the store can't watch.
+	Watch(fn func(sid string)) (stop func(), err error)
+}
+
+// Removals by this manager are not reported back to it, unless the store
+// takes longer than this to report them
+const watchEcho = time.Minute
+
+type watch struct {
+	lock sync.Mutex
+	stop func()
+}
+
+func (sm *SessionManager) startWatch() {
+	w, ok := sm.store.(Watcher)
+	if !ok {
+		return
+	}
+
+	stop, err := w.Watch(func(sid string) {
+		sm.emit(Event{Type: EventRemoved, SessionID: sid})
+	})
+	if err != nil || stop == nil {
+		return
+	}
+
+	sm.watch.lock.Lock()
+	sm.watch.stop = stop
+	sm.watch.lock.Unlock()
+}
+
+func (sm *SessionManager) stopWatch() {
+	sm.watch.lock.Lock()
+	stop := sm.watch.stop
+	sm.watch.stop = nil
+	sm.watch.lock.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+}
+
+func (bs *BackendStore) Watch(fn func(sid string)) (func(), error) {
+	w, ok := bs.backend.(Watcher)
+	if !ok {
+		return nil, nil
+	}
+
+	bs.watching.Store(true)
+	stop, err := w.Watch(func(key string) {
+		if t, ok := bs.removing.LoadAndDelete(key); ok && time.Since(t.(time.Time)) < watchEcho {
+			// Removed by this manager
+			return
+		}
+		if sid, ok := bs.sid(key); ok {
+			fn(sid)
+		}
+	})
+	if err != nil || stop == nil {
+		bs.watching.Store(false)
+	}
+	return stop, err
+}
+
+// Remove sid from the backend, remembering the removal so the watch does
+// not report it back
+func (bs *BackendStore) remove(sid string) error {
+	key := bs.key(sid)
+	if !bs.watching.Load() {
+		return bs.backend.Remove(key)
+	}
+
+	bs.removing.Store(key, time.Now())
+	err := bs.backend.Remove(key)
+	if err != nil {
+		bs.removing.Delete(key)
+	}
+	return err
+}
+
+// Forget removals not reported back within watchEcho, which the watch will
+// no longer skip anyway. Called by the cleaner through Sweep.
+func (bs *BackendStore) pruneRemoving() {
+	bs.removing.Range(func(key, t any) bool {
+		if time.Since(t.(time.Time)) >= watchEcho {
+			bs.removing.CompareAndDelete(key, t)
+		}
+		return true
+	})
+}
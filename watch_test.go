@@ -0,0 +1,97 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+// mapBackend reporting every removal to its watcher
+type watchBackend struct {
+	*mapBackend
+	fn func(sid string)
+}
+
+func (b *watchBackend) Watch(fn func(sid string)) (func(), error) {
+	b.fn = fn
+	return func() { b.fn = nil }, nil
+}
+
+func (b *watchBackend) Remove(sid string) error {
+	b.mapBackend.Remove(sid)
+	if b.fn != nil {
+		b.fn(sid)
+	}
+	return nil
+}
+
+func TestSessionManager_Watch(t *testing.T) {
+	backend := &watchBackend{mapBackend: newMapBackend()}
+	var removed []string
+	sm := New(SessionManagerConfig{
+		MaxLifetime: time.Hour,
+		Store:       NewBackendStore(backend, nil),
+		OnEvent: func(e Event) {
+			if e.Type == EventRemoved {
+				removed = append(removed, e.SessionID)
+			}
+		},
+	})
+
+	// Case 1: Own Removals Not Reported
+	sm.SessionCreate("sessionid123")
+	sm.SessionDestroy("sessionid123")
+	if len(removed) != 0 {
+		t.Errorf("Expected no removals reported, got %v", removed)
+	}
+
+	// Case 2: Removals By Others Reported
+	sm.SessionCreate("sessionid456")
+	backend.Remove("sessionid456")
+	if len(removed) != 1 || removed[0] != "sessionid456" {
+		t.Errorf("Expected sessionid456 reported, got %v", removed)
+	}
+
+	// Case 3: Stopped On Close
+	sm.Close()
+	if backend.fn != nil {
+		t.Errorf("Expected the watch to be stopped")
+	}
+
+	// Case 4: Backends Without A Watch
+	plain := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: NewBackendStore(newMapBackend(), nil)})
+	defer plain.Close()
+	if plain.watch.stop != nil {
+		t.Errorf("Expected no watch")
+	}
+}
+
+func TestBackendStore_PruneRemoving(t *testing.T) {
+	backend := &watchBackend{mapBackend: newMapBackend()}
+	bs := NewBackendStore(backend, nil)
+	bs.Watch(func(sid string) {})
+	backend.fn = nil
+	count := func() (n int) {
+		bs.removing.Range(func(key, t any) bool {
+			n++
+			return true
+		})
+		return n
+	}
+
+	// Case 1: Unreported Removals Kept Within watchEcho
+	bs.remove("sessionid123")
+	bs.remove("sessionid456")
+	bs.Sweep()
+	if n := count(); n != 2 {
+		t.Errorf("Expected 2 removals remembered, got %v", n)
+	}
+
+	// Case 2: Older Removals Pruned By the Cleaner
+	bs.removing.Store(bs.key("sessionid123"), time.Now().Add(-watchEcho))
+	sm := New(SessionManagerConfig{MaxLifetime: time.Hour, Store: bs})
+	defer sm.Close()
+	sm.GlobalCleaner()
+	if _, ok := bs.removing.Load(bs.key("sessionid123")); ok || count() != 1 {
+		t.Errorf("Expected only the old removal pruned, got %v left", count())
+	}
+}
@@ -0,0 +1,242 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// Implemented by stores writing several sessions at once, used to flush
+// Config.WriteBehind batches
+type BatchSetter interface {
+	SetBatch(sessions map[string]*Session) error
+}
+
+// Implemented by backends saving several records in one round trip, e.g.
+// in one pipeline or transaction
+type BatchSaver interface {
+	SaveBatch(records []Record) error
+}
+
+// Store handing writes to back at most once per interval. Set only marks
+// the session dirty; reads see dirty sessions before they reach back.
+type writeBehind struct {
+	back     Store
+	interval time.Duration
+	sm       *SessionManager
+
+	lock  sync.Mutex
+	dirty map[string]*Session
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+func newWriteBehind(back Store, interval time.Duration) *writeBehind {
+	return &writeBehind{
+		back:     back,
+		interval: interval,
+		dirty:    make(map[string]*Session),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (wb *writeBehind) attach(sm *SessionManager) {
+	wb.sm = sm
+	if a, ok := wb.back.(attachable); ok {
+		a.attach(sm)
+	}
+}
+
+func (wb *writeBehind) SelfExpiring() bool {
+	e, ok := wb.back.(Expiring)
+	return ok && e.SelfExpiring()
+}
+
+func (wb *writeBehind) Sweep() error {
+	if sw, ok := wb.back.(Sweeper); ok {
+		return sw.Sweep()
+	}
+	return nil
+}
+
+// A dirty session removed by another instance is not written back
+func (wb *writeBehind) Watch(fn func(sid string)) (func(), error) {
+	w, ok := wb.back.(Watcher)
+	if !ok {
+		return nil, nil
+	}
+	return w.Watch(func(sid string) {
+		wb.lock.Lock()
+		delete(wb.dirty, sid)
+		wb.lock.Unlock()
+		fn(sid)
+	})
+}
+
+func (wb *writeBehind) Get(sid string) (*Session, error) {
+	wb.lock.Lock()
+	s := wb.dirty[sid]
+	wb.lock.Unlock()
+
+	if s != nil {
+		return s, nil
+	}
+	return wb.back.Get(sid)
+}
+
+func (wb *writeBehind) Set(sid string, s *Session) error {
+	wb.lock.Lock()
+	defer wb.lock.Unlock()
+
+	wb.dirty[sid] = s
+	return nil
+}
+
+func (wb *writeBehind) Delete(sid string) error {
+	wb.lock.Lock()
+	delete(wb.dirty, sid)
+	wb.lock.Unlock()
+
+	return wb.back.Delete(sid)
+}
+
+// Dirty sessions are handed out instead of the stored copies, followed by
+// the dirty sessions back doesn't hold yet
+func (wb *writeBehind) Iterate(fn func(sid string, s *Session) bool) error {
+	pending := wb.pending()
+	more := true
+
+	err := wb.back.Iterate(func(sid string, s *Session) bool {
+		if d, ok := pending[sid]; ok {
+			s = d
+			delete(pending, sid)
+		}
+		more = fn(sid, s)
+		return more
+	})
+	if err != nil || !more {
+		return err
+	}
+
+	for sid, s := range pending {
+		if !fn(sid, s) {
+			break
+		}
+	}
+	return nil
+}
+
+func (wb *writeBehind) Count() int {
+	n := wb.back.Count()
+	for sid := range wb.pending() {
+		if s, err := wb.back.Get(sid); err == nil && s == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func (wb *writeBehind) pending() map[string]*Session {
+	wb.lock.Lock()
+	defer wb.lock.Unlock()
+
+	pending := make(map[string]*Session, len(wb.dirty))
+	for sid, s := range wb.dirty {
+		pending[sid] = s
+	}
+	return pending
+}
+
+func (wb *writeBehind) start() {
+	go func() {
+		defer close(wb.done)
+
+		ticker := time.NewTicker(wb.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-wb.stop:
+				return
+			case <-ticker.C:
+				wb.flush()
+			}
+		}
+	}()
+}
+
+// Hand every dirty session to back in one batch. The sessions stay dirty,
+// and so visible to reads, until back has them; those back failed to store
+// are retried on the next flush.
+func (wb *writeBehind) flush() error {
+	pending := wb.pending()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	sids := make([]string, 0, len(pending))
+	for sid := range pending {
+		sids = append(sids, sid)
+	}
+	// No write or destroy of these sessions can slip in between storing a
+	// copy and marking it clean
+	unlock := wb.sm.lockSid(sids...)
+	defer unlock()
+
+	// Take the latest copies, leaving out those destroyed meanwhile
+	wb.lock.Lock()
+	for sid := range pending {
+		if wb.dirty[sid] == nil {
+			delete(pending, sid)
+		} else {
+			pending[sid] = wb.dirty[sid]
+		}
+	}
+	wb.lock.Unlock()
+
+	var err error
+	stored := pending
+	if bs, ok := wb.back.(BatchSetter); ok {
+		if err = bs.SetBatch(pending); err != nil {
+			stored = nil
+		}
+	} else {
+		stored = make(map[string]*Session, len(pending))
+		for sid, s := range pending {
+			if serr := wb.back.Set(sid, s); serr != nil {
+				if err == nil {
+					err = serr
+				}
+				continue
+			}
+			stored[sid] = s
+		}
+	}
+
+	wb.lock.Lock()
+	for sid := range stored {
+		delete(wb.dirty, sid)
+	}
+	wb.lock.Unlock()
+
+	return err
+}
+
+func (wb *writeBehind) close() error {
+	wb.once.Do(func() {
+		close(wb.stop)
+		<-wb.done
+	})
+	return wb.flush()
+}
+
+// Hand the sessions written since the last flush to the store now, when
+// Config.WriteBehind is set. Close flushes as well.
+func (sm *SessionManager) Flush() error {
+	if sm.writeBehind == nil {
+		return nil
+	}
+	return sm.writeBehind.flush()
+}
@@ -0,0 +1,124 @@
+package session
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// mapBackend counting its saves, optionally saving in batches or failing
+type savingBackend struct {
+	*mapBackend
+	saves   int
+	batches int
+	fail    bool
+}
+
+func (b *savingBackend) Save(r Record) error {
+	if b.fail {
+		return errors.New("backend unavailable")
+	}
+	b.saves++
+	return b.mapBackend.Save(r)
+}
+
+type batchBackend struct {
+	*savingBackend
+}
+
+func (b *batchBackend) SaveBatch(records []Record) error {
+	b.batches++
+	for _, r := range records {
+		b.mapBackend.Save(r)
+	}
+	return nil
+}
+
+func TestSessionManager_WriteBehind(t *testing.T) {
+	b := &savingBackend{mapBackend: newMapBackend()}
+	sm := New(SessionManagerConfig{
+		MaxLifetime: time.Hour,
+		Store:       NewBackendStore(b, nil),
+		WriteBehind: time.Hour,
+	})
+
+	// Case 1: Writes Only Mark The Session Dirty
+	s, _ := sm.SessionCreate("sessionid123")
+	for i := 0; i < 5; i++ {
+		s.Set("cart", i)
+	}
+	if b.saves != 0 {
+		t.Errorf("Expected no saves before the flush, got %v", b.saves)
+	}
+
+	// Case 2: Dirty Sessions Visible To Reads
+	if got, ok := sm.session("sessionid123"); !ok || got.Get("cart") != 4 {
+		t.Errorf("Expected dirty session, got %v", got)
+	}
+	if sm.SessionCount() != 1 {
+		t.Errorf("Expected 1 session, got %v", sm.SessionCount())
+	}
+
+	// Case 3: Flush Writes Each Session Once
+	if err := sm.Flush(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if b.saves != 1 {
+		t.Errorf("Expected 1 save, got %v", b.saves)
+	}
+	if sm.SessionCount() != 1 {
+		t.Errorf("Expected 1 session, got %v", sm.SessionCount())
+	}
+
+	// Case 4: Failed Writes Retried
+	s.Set("cart", 5)
+	b.fail = true
+	if err := sm.Flush(); err == nil {
+		t.Errorf("Expected an error")
+	}
+	b.fail = false
+	if err := sm.Flush(); err != nil || b.saves != 2 {
+		t.Errorf("Expected the write retried, got %v saves, %v", b.saves, err)
+	}
+
+	// Case 5: Destroyed Sessions Not Written Back
+	sm.SessionCreate("sessionid456")
+	sm.SessionDestroy("sessionid456")
+	sm.Flush()
+	if _, ok := b.data["sessionid456"]; ok {
+		t.Errorf("Expected sessionid456 not written back")
+	}
+
+	// Case 6: Close Flushes
+	s.Set("cart", 6)
+	saves := b.saves
+	sm.Close()
+	if b.saves != saves+1 {
+		t.Errorf("Expected a save on close, got %v", b.saves-saves)
+	}
+}
+
+func TestSessionManager_WriteBehindBatch(t *testing.T) {
+	b := &batchBackend{&savingBackend{mapBackend: newMapBackend()}}
+	sm := New(SessionManagerConfig{
+		MaxLifetime: time.Hour,
+		Store:       NewBackendStore(b, nil),
+		WriteBehind: 10 * time.Millisecond,
+	})
+	defer sm.Close()
+
+	// Case 1: Dirty Sessions Saved Through SaveBatch
+	sm.SessionCreate("sessionid123")
+	sm.SessionCreate("sessionid456")
+	stored := func() int {
+		n, _ := b.Len()
+		return n
+	}
+	deadline := time.Now().Add(time.Second)
+	for stored() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := stored(); n != 2 || b.saves != 0 {
+		t.Errorf("Expected 2 sessions saved in batches, got %v and %v saves", n, b.saves)
+	}
+}